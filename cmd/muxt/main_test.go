@@ -57,7 +57,10 @@ func Test(t *testing.T) {
 	e.Cmds["muxt"] = scriptCommand()
 	e.Cmds["count-matches"] = countRedirectBlocksCommand()
 	ctx := t.Context()
-	scripttest.Test(t, ctx, e, nil, filepath.FromSlash("testdata/*.txt"))
+	// Scripts share the user cache directory, so keep the generation cache
+	// out of it; howto_generate_cache.txt sets MUXTCACHE itself.
+	env := append(os.Environ(), "MUXTCACHE=off")
+	scripttest.Test(t, ctx, e, env, filepath.FromSlash("testdata/*.txt"))
 }
 
 func scriptCommand() script.Cmd {
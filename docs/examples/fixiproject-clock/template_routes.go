@@ -12,6 +12,7 @@ import (
 	"io"
 	"log/slog"
 	"net/http"
+	"net/url"
 	"path"
 	"strconv"
 	"strings"
@@ -28,72 +29,74 @@ func TemplateRoutes(mux *http.ServeMux, receiver RoutesReceiver) TemplateRoutePa
 	bytesBufferPool := sync.Pool{New: func() any {
 		return bytes.NewBuffer(nil)
 	}}
-	mux.HandleFunc("GET /time", func(response http.ResponseWriter, request *http.Request) {
-		defer func() {
-			_ = request.Body.Close()
-		}()
-		flusher, ok := response.(http.Flusher)
-		if !ok {
-			http.Error(response, "streaming unsupported", http.StatusInternalServerError)
-			return
+	{
+		routeTemplate := templates.Lookup("GET /time sse(Time(ctx, lastEventID, execute))")
+		if routeTemplate == nil {
+			panic("template \"GET /time sse(Time(ctx, lastEventID, execute))\" not found in templates")
 		}
-		ctx := request.Context()
-		lastEventID := request.Header.Get("Last-Event-Id")
-		h := response.Header()
-		h.Set("Content-Type", "text/event-stream")
-		h.Set("Connection", "keep-alive")
-		h.Set("Cache-Control", "no-cache")
-		response.WriteHeader(http.StatusOK)
-		flusher.Flush()
-		var mut sync.Mutex
-		receiver.Time(ctx, lastEventID, func(result string) error {
-			if err := request.Context().Err(); err != nil {
-				return err
+		mux.HandleFunc("GET /time", func(response http.ResponseWriter, request *http.Request) {
+			defer func() {
+				_ = request.Body.Close()
+			}()
+			flusher, ok := response.(http.Flusher)
+			if !ok {
+				http.Error(response, "streaming unsupported", http.StatusInternalServerError)
+				return
 			}
+			ctx := request.Context()
+			lastEventID := request.Header.Get("Last-Event-Id")
+			h := response.Header()
+			h.Set("Content-Type", "text/event-stream")
+			h.Set("Connection", "keep-alive")
+			h.Set("Cache-Control", "no-cache")
+			response.WriteHeader(http.StatusOK)
+			flusher.Flush()
+			var mut sync.Mutex
+			receiver.Time(ctx, lastEventID, func(result string) error {
+				if err := request.Context().Err(); err != nil {
+					return err
+				}
+				buf := bytesBufferPool.Get().(*bytes.Buffer)
+				buf.Reset()
+				defer bytesBufferPool.Put(buf)
+				td := SSETemplateData[RoutesReceiver, string]{receiver: receiver, request: request, pathsPrefix: pathsPrefix, result: result}
+				if err := routeTemplate.Execute(buf, &td); err != nil {
+					slog.ErrorContext(request.Context(), "failed to render page", slog.String("path", request.URL.Path), slog.String("pattern", request.Pattern), slog.String("error", err.Error()))
+					return err
+				}
+				td.data = buf
+				mut.Lock()
+				defer mut.Unlock()
+				if _, err := td.WriteTo(response); err != nil {
+					return err
+				}
+				flusher.Flush()
+				return nil
+			})
+		})
+	}
+	{
+		routeTemplate := templates.Lookup("GET /{$} Index()")
+		if routeTemplate == nil {
+			panic("template \"GET /{$} Index()\" not found in templates")
+		}
+		mux.HandleFunc("GET /{$}", func(response http.ResponseWriter, request *http.Request) {
+			var td = TemplateData[RoutesReceiver, string]{receiver: receiver, response: response, request: request, pathsPrefix: pathsPrefix}
 			buf := bytesBufferPool.Get().(*bytes.Buffer)
 			buf.Reset()
 			defer bytesBufferPool.Put(buf)
-			td := SSETemplateData[RoutesReceiver, string]{receiver: receiver, request: request, pathsPrefix: pathsPrefix, result: result}
-			if err := templates.ExecuteTemplate(buf, "GET /time sse(Time(ctx, lastEventID, execute))", &td); err != nil {
-				slog.ErrorContext(request.Context(), "failed to render page", slog.String("path", request.URL.Path), slog.String("pattern", request.Pattern), slog.String("error", err.Error()))
-				return err
+			if len(td.errList) == 0 {
+				td.result = receiver.Index()
+				td.okay = true
 			}
-			td.data = buf
-			mut.Lock()
-			defer mut.Unlock()
-			if _, err := td.WriteTo(response); err != nil {
-				return err
+			if err := routeTemplate.Execute(buf, &td); err != nil {
+				td.executeTemplateFailed(err)
+				return
 			}
-			flusher.Flush()
-			return nil
+			statusCode := td.responseStatusCode(buf, 0, http.StatusOK)
+			td.writeResponse(buf, statusCode)
 		})
-	})
-	mux.HandleFunc("GET /{$}", func(response http.ResponseWriter, request *http.Request) {
-		var td = TemplateData[RoutesReceiver, string]{receiver: receiver, response: response, request: request, pathsPrefix: pathsPrefix}
-		buf := bytesBufferPool.Get().(*bytes.Buffer)
-		buf.Reset()
-		defer bytesBufferPool.Put(buf)
-		if len(td.errList) == 0 {
-			td.result = receiver.Index()
-			td.okay = true
-		}
-		if err := templates.ExecuteTemplate(buf, "GET /{$} Index()", &td); err != nil {
-			slog.ErrorContext(request.Context(), "failed to render page", slog.String("path", request.URL.Path), slog.String("pattern", request.Pattern), slog.String("error", err.Error()))
-			http.Error(response, "failed to render page", http.StatusInternalServerError)
-			return
-		}
-		defaultStatusCode := http.StatusOK
-		if buf.Len() == 0 {
-			defaultStatusCode = http.StatusNoContent
-		}
-		statusCode := cmp.Or(td.statusCode, td.errStatusCode, defaultStatusCode)
-		if contentType := response.Header().Get("content-type"); contentType == "" {
-			response.Header().Set("content-type", "text/html; charset=utf-8")
-		}
-		response.Header().Set("content-length", strconv.Itoa(buf.Len()))
-		response.WriteHeader(statusCode)
-		_, _ = buf.WriteTo(response)
-	})
+	}
 	return TemplateRoutePaths{pathsPrefix: pathsPrefix}
 }
 
@@ -106,6 +109,8 @@ type TemplateData[R any, T any] struct {
 	errStatusCode int
 	okay          bool
 	errList       []error
+	fieldErrors   map[string]TemplateDataFieldError
+	formData      any
 	redirectURL   string
 	pathsPrefix   string
 }
@@ -145,6 +150,56 @@ func (data *TemplateData[R, T]) Err() error {
 	return errors.Join(data.errList...)
 }
 
+func (data *TemplateData[R, T]) FieldErrors() map[string]TemplateDataFieldError {
+	return data.fieldErrors
+}
+
+func (data *TemplateData[R, T]) FieldError(name string) *TemplateDataFieldError {
+	if fe, ok := data.fieldErrors[name]; ok {
+		return &fe
+	}
+	return nil
+}
+
+func (data *TemplateData[R, T]) appendFieldError(name, constraint string, err error) {
+	if data.fieldErrors == nil {
+		data.fieldErrors = make(map[string]TemplateDataFieldError)
+	}
+	if _, ok := data.fieldErrors[name]; !ok {
+		data.fieldErrors[name] = TemplateDataFieldError{Name: name, Constraint: constraint, Err: err}
+	}
+	data.errList = append(data.errList, err)
+}
+
+func (data *TemplateData[R, T]) responseStatusCode(buf *bytes.Buffer, resultStatusCode, defaultStatusCode int) int {
+	if defaultStatusCode == http.StatusOK && buf.Len() == 0 {
+		defaultStatusCode = http.StatusNoContent
+	}
+	return cmp.Or(data.statusCode, data.errStatusCode, resultStatusCode, defaultStatusCode)
+}
+
+func (data *TemplateData[R, T]) writeResponse(buf *bytes.Buffer, statusCode int) {
+	if contentType := data.response.Header().Get("content-type"); contentType == "" {
+		data.response.Header().Set("content-type", "text/html; charset=utf-8")
+	}
+	data.response.Header().Set("content-length", strconv.Itoa(buf.Len()))
+	data.response.WriteHeader(statusCode)
+	_, _ = buf.WriteTo(data.response)
+}
+
+func (data *TemplateData[R, T]) writeRedirect(statusCode int) bool {
+	if data.redirectURL == "" {
+		return false
+	}
+	http.Redirect(data.response, data.request, data.redirectURL, statusCode)
+	return true
+}
+
+func (data *TemplateData[R, T]) executeTemplateFailed(err error) {
+	slog.ErrorContext(data.request.Context(), "failed to render page", slog.String("path", data.request.URL.Path), slog.String("pattern", data.request.Pattern), slog.String("error", err.Error()))
+	http.Error(data.response, "failed to render page", http.StatusInternalServerError)
+}
+
 func (data *TemplateData[R, T]) Receiver() R {
 	return data.receiver
 }
@@ -173,10 +228,35 @@ func (data *TemplateData[R, T]) RedirectSeeOther(url string) (*TemplateData[R, T
 	return data.Redirect(url, http.StatusSeeOther)
 }
 
+func (data *TemplateData[R, T]) Form() url.Values {
+	if data.request == nil {
+		return nil
+	}
+	return data.request.Form
+}
+
+func (data *TemplateData[R, T]) FormValue(name string) string {
+	return data.Form().Get(name)
+}
+
 func (data *TemplateData[R, T]) String() string {
 	return ""
 }
 
+type TemplateDataFieldError struct {
+	Name       string
+	Constraint string
+	Err        error
+}
+
+func (e TemplateDataFieldError) Error() string {
+	return e.Err.Error()
+}
+
+func (e TemplateDataFieldError) Unwrap() error {
+	return e.Err
+}
+
 type SSETemplateData[R, T any] struct {
 	receiver          R
 	request           *http.Request
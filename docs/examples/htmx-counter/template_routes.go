@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
+	"net/url"
 	"path"
 	"strconv"
 	"sync"
@@ -26,119 +27,100 @@ func TemplateRoutes(mux *http.ServeMux, receiver RoutesReceiver) TemplateRoutePa
 	bytesBufferPool := sync.Pool{New: func() any {
 		return bytes.NewBuffer(nil)
 	}}
-	mux.HandleFunc("/", func(response http.ResponseWriter, request *http.Request) {
-		var td = TemplateData[RoutesReceiver, int64]{receiver: receiver, response: response, request: request, pathsPrefix: pathsPrefix}
-		buf := bytesBufferPool.Get().(*bytes.Buffer)
-		buf.Reset()
-		defer bytesBufferPool.Put(buf)
-		if len(td.errList) == 0 {
-			td.result = receiver.Count()
-			td.okay = true
+	{
+		routeTemplate := templates.Lookup("/ Count()")
+		if routeTemplate == nil {
+			panic("template \"/ Count()\" not found in templates")
 		}
-		if err := templates.ExecuteTemplate(buf, "/ Count()", &td); err != nil {
-			slog.ErrorContext(request.Context(), "failed to render page", slog.String("path", request.URL.Path), slog.String("pattern", request.Pattern), slog.String("error", err.Error()))
-			http.Error(response, "failed to render page", http.StatusInternalServerError)
-			return
-		}
-		defaultStatusCode := http.StatusOK
-		if buf.Len() == 0 {
-			defaultStatusCode = http.StatusNoContent
-		}
-		statusCode := cmp.Or(td.statusCode, td.errStatusCode, defaultStatusCode)
-		if contentType := response.Header().Get("content-type"); contentType == "" {
-			response.Header().Set("content-type", "text/html; charset=utf-8")
-		}
-		response.Header().Set("content-length", strconv.Itoa(buf.Len()))
-		response.WriteHeader(statusCode)
-		_, _ = buf.WriteTo(response)
-	})
-	mux.HandleFunc("POST /count", func(response http.ResponseWriter, request *http.Request) {
-		var td = TemplateData[RoutesReceiver, struct {
-		}]{receiver: receiver, response: response, request: request, pathsPrefix: pathsPrefix}
-		buf := bytesBufferPool.Get().(*bytes.Buffer)
-		buf.Reset()
-		defer bytesBufferPool.Put(buf)
-		if err := templates.ExecuteTemplate(buf, "POST /count", &td); err != nil {
-			slog.ErrorContext(request.Context(), "failed to render page", slog.String("path", request.URL.Path), slog.String("pattern", request.Pattern), slog.String("error", err.Error()))
-			http.Error(response, "failed to render page", http.StatusInternalServerError)
-			return
-		}
-		defaultStatusCode := http.StatusOK
-		if buf.Len() == 0 {
-			defaultStatusCode = http.StatusNoContent
-		}
-		statusCode := cmp.Or(td.statusCode, td.errStatusCode, defaultStatusCode)
-		if td.redirectURL != "" {
-			http.Redirect(response, request, td.redirectURL, statusCode)
-			return
-		}
-		if contentType := response.Header().Get("content-type"); contentType == "" {
-			response.Header().Set("content-type", "text/html; charset=utf-8")
-		}
-		response.Header().Set("content-length", strconv.Itoa(buf.Len()))
-		response.WriteHeader(statusCode)
-		_, _ = buf.WriteTo(response)
-	})
-	mux.HandleFunc("/decrement-count", func(response http.ResponseWriter, request *http.Request) {
-		var td = TemplateData[RoutesReceiver, int64]{receiver: receiver, response: response, request: request, pathsPrefix: pathsPrefix}
-		buf := bytesBufferPool.Get().(*bytes.Buffer)
-		buf.Reset()
-		defer bytesBufferPool.Put(buf)
-		if len(td.errList) == 0 {
-			td.result = receiver.Decrement()
-			td.okay = true
-		}
-		if err := templates.ExecuteTemplate(buf, "/decrement-count Decrement()", &td); err != nil {
-			slog.ErrorContext(request.Context(), "failed to render page", slog.String("path", request.URL.Path), slog.String("pattern", request.Pattern), slog.String("error", err.Error()))
-			http.Error(response, "failed to render page", http.StatusInternalServerError)
-			return
-		}
-		defaultStatusCode := http.StatusOK
-		if buf.Len() == 0 {
-			defaultStatusCode = http.StatusNoContent
-		}
-		statusCode := cmp.Or(td.statusCode, td.errStatusCode, defaultStatusCode)
-		if td.redirectURL != "" {
-			http.Redirect(response, request, td.redirectURL, statusCode)
-			return
-		}
-		if contentType := response.Header().Get("content-type"); contentType == "" {
-			response.Header().Set("content-type", "text/html; charset=utf-8")
-		}
-		response.Header().Set("content-length", strconv.Itoa(buf.Len()))
-		response.WriteHeader(statusCode)
-		_, _ = buf.WriteTo(response)
-	})
-	mux.HandleFunc("/increment-count", func(response http.ResponseWriter, request *http.Request) {
-		var td = TemplateData[RoutesReceiver, int64]{receiver: receiver, response: response, request: request, pathsPrefix: pathsPrefix}
-		buf := bytesBufferPool.Get().(*bytes.Buffer)
-		buf.Reset()
-		defer bytesBufferPool.Put(buf)
-		if len(td.errList) == 0 {
-			td.result = receiver.Increment()
-			td.okay = true
-		}
-		if err := templates.ExecuteTemplate(buf, "/increment-count Increment()", &td); err != nil {
-			slog.ErrorContext(request.Context(), "failed to render page", slog.String("path", request.URL.Path), slog.String("pattern", request.Pattern), slog.String("error", err.Error()))
-			http.Error(response, "failed to render page", http.StatusInternalServerError)
-			return
-		}
-		defaultStatusCode := http.StatusOK
-		if buf.Len() == 0 {
-			defaultStatusCode = http.StatusNoContent
+		mux.HandleFunc("/", func(response http.ResponseWriter, request *http.Request) {
+			var td = TemplateData[RoutesReceiver, int64]{receiver: receiver, response: response, request: request, pathsPrefix: pathsPrefix}
+			buf := bytesBufferPool.Get().(*bytes.Buffer)
+			buf.Reset()
+			defer bytesBufferPool.Put(buf)
+			if len(td.errList) == 0 {
+				td.result = receiver.Count()
+				td.okay = true
+			}
+			if err := routeTemplate.Execute(buf, &td); err != nil {
+				td.executeTemplateFailed(err)
+				return
+			}
+			statusCode := td.responseStatusCode(buf, 0, http.StatusOK)
+			td.writeResponse(buf, statusCode)
+		})
+	}
+	{
+		routeTemplate := templates.Lookup("POST /count")
+		if routeTemplate == nil {
+			panic("template \"POST /count\" not found in templates")
 		}
-		statusCode := cmp.Or(td.statusCode, td.errStatusCode, defaultStatusCode)
-		if td.redirectURL != "" {
-			http.Redirect(response, request, td.redirectURL, statusCode)
-			return
+		mux.HandleFunc("POST /count", func(response http.ResponseWriter, request *http.Request) {
+			var td = TemplateData[RoutesReceiver, struct {
+			}]{receiver: receiver, response: response, request: request, pathsPrefix: pathsPrefix}
+			buf := bytesBufferPool.Get().(*bytes.Buffer)
+			buf.Reset()
+			defer bytesBufferPool.Put(buf)
+			if err := routeTemplate.Execute(buf, &td); err != nil {
+				td.executeTemplateFailed(err)
+				return
+			}
+			statusCode := td.responseStatusCode(buf, 0, http.StatusOK)
+			if td.writeRedirect(statusCode) {
+				return
+			}
+			td.writeResponse(buf, statusCode)
+		})
+	}
+	{
+		routeTemplate := templates.Lookup("/decrement-count Decrement()")
+		if routeTemplate == nil {
+			panic("template \"/decrement-count Decrement()\" not found in templates")
 		}
-		if contentType := response.Header().Get("content-type"); contentType == "" {
-			response.Header().Set("content-type", "text/html; charset=utf-8")
+		mux.HandleFunc("/decrement-count", func(response http.ResponseWriter, request *http.Request) {
+			var td = TemplateData[RoutesReceiver, int64]{receiver: receiver, response: response, request: request, pathsPrefix: pathsPrefix}
+			buf := bytesBufferPool.Get().(*bytes.Buffer)
+			buf.Reset()
+			defer bytesBufferPool.Put(buf)
+			if len(td.errList) == 0 {
+				td.result = receiver.Decrement()
+				td.okay = true
+			}
+			if err := routeTemplate.Execute(buf, &td); err != nil {
+				td.executeTemplateFailed(err)
+				return
+			}
+			statusCode := td.responseStatusCode(buf, 0, http.StatusOK)
+			if td.writeRedirect(statusCode) {
+				return
+			}
+			td.writeResponse(buf, statusCode)
+		})
+	}
+	{
+		routeTemplate := templates.Lookup("/increment-count Increment()")
+		if routeTemplate == nil {
+			panic("template \"/increment-count Increment()\" not found in templates")
 		}
-		response.Header().Set("content-length", strconv.Itoa(buf.Len()))
-		response.WriteHeader(statusCode)
-		_, _ = buf.WriteTo(response)
-	})
+		mux.HandleFunc("/increment-count", func(response http.ResponseWriter, request *http.Request) {
+			var td = TemplateData[RoutesReceiver, int64]{receiver: receiver, response: response, request: request, pathsPrefix: pathsPrefix}
+			buf := bytesBufferPool.Get().(*bytes.Buffer)
+			buf.Reset()
+			defer bytesBufferPool.Put(buf)
+			if len(td.errList) == 0 {
+				td.result = receiver.Increment()
+				td.okay = true
+			}
+			if err := routeTemplate.Execute(buf, &td); err != nil {
+				td.executeTemplateFailed(err)
+				return
+			}
+			statusCode := td.responseStatusCode(buf, 0, http.StatusOK)
+			if td.writeRedirect(statusCode) {
+				return
+			}
+			td.writeResponse(buf, statusCode)
+		})
+	}
 	return TemplateRoutePaths{pathsPrefix: pathsPrefix}
 }
 
@@ -151,6 +133,8 @@ type TemplateData[R any, T any] struct {
 	errStatusCode int
 	okay          bool
 	errList       []error
+	fieldErrors   map[string]TemplateDataFieldError
+	formData      any
 	redirectURL   string
 	pathsPrefix   string
 }
@@ -190,6 +174,56 @@ func (data *TemplateData[R, T]) Err() error {
 	return errors.Join(data.errList...)
 }
 
+func (data *TemplateData[R, T]) FieldErrors() map[string]TemplateDataFieldError {
+	return data.fieldErrors
+}
+
+func (data *TemplateData[R, T]) FieldError(name string) *TemplateDataFieldError {
+	if fe, ok := data.fieldErrors[name]; ok {
+		return &fe
+	}
+	return nil
+}
+
+func (data *TemplateData[R, T]) appendFieldError(name, constraint string, err error) {
+	if data.fieldErrors == nil {
+		data.fieldErrors = make(map[string]TemplateDataFieldError)
+	}
+	if _, ok := data.fieldErrors[name]; !ok {
+		data.fieldErrors[name] = TemplateDataFieldError{Name: name, Constraint: constraint, Err: err}
+	}
+	data.errList = append(data.errList, err)
+}
+
+func (data *TemplateData[R, T]) responseStatusCode(buf *bytes.Buffer, resultStatusCode, defaultStatusCode int) int {
+	if defaultStatusCode == http.StatusOK && buf.Len() == 0 {
+		defaultStatusCode = http.StatusNoContent
+	}
+	return cmp.Or(data.statusCode, data.errStatusCode, resultStatusCode, defaultStatusCode)
+}
+
+func (data *TemplateData[R, T]) writeResponse(buf *bytes.Buffer, statusCode int) {
+	if contentType := data.response.Header().Get("content-type"); contentType == "" {
+		data.response.Header().Set("content-type", "text/html; charset=utf-8")
+	}
+	data.response.Header().Set("content-length", strconv.Itoa(buf.Len()))
+	data.response.WriteHeader(statusCode)
+	_, _ = buf.WriteTo(data.response)
+}
+
+func (data *TemplateData[R, T]) writeRedirect(statusCode int) bool {
+	if data.redirectURL == "" {
+		return false
+	}
+	http.Redirect(data.response, data.request, data.redirectURL, statusCode)
+	return true
+}
+
+func (data *TemplateData[R, T]) executeTemplateFailed(err error) {
+	slog.ErrorContext(data.request.Context(), "failed to render page", slog.String("path", data.request.URL.Path), slog.String("pattern", data.request.Pattern), slog.String("error", err.Error()))
+	http.Error(data.response, "failed to render page", http.StatusInternalServerError)
+}
+
 func (data *TemplateData[R, T]) Receiver() R {
 	return data.receiver
 }
@@ -218,10 +252,35 @@ func (data *TemplateData[R, T]) RedirectSeeOther(url string) (*TemplateData[R, T
 	return data.Redirect(url, http.StatusSeeOther)
 }
 
+func (data *TemplateData[R, T]) Form() url.Values {
+	if data.request == nil {
+		return nil
+	}
+	return data.request.Form
+}
+
+func (data *TemplateData[R, T]) FormValue(name string) string {
+	return data.Form().Get(name)
+}
+
 func (data *TemplateData[R, T]) String() string {
 	return ""
 }
 
+type TemplateDataFieldError struct {
+	Name       string
+	Constraint string
+	Err        error
+}
+
+func (e TemplateDataFieldError) Error() string {
+	return e.Err.Error()
+}
+
+func (e TemplateDataFieldError) Unwrap() error {
+	return e.Err
+}
+
 func (data *TemplateData[R, T]) HXLocation(link string) *TemplateData[R, T] {
 	return data.Header("HX-Location", link)
 }
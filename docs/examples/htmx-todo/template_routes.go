@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
+	"net/url"
 	"path"
 	"strconv"
 	"sync"
@@ -30,214 +31,186 @@ func TemplateRoutes(mux *http.ServeMux, receiver RoutesReceiver) TemplateRoutePa
 	bytesBufferPool := sync.Pool{New: func() any {
 		return bytes.NewBuffer(nil)
 	}}
-	mux.HandleFunc("POST /todos", func(response http.ResponseWriter, request *http.Request) {
-		var td = TemplateData[RoutesReceiver, TodoChange]{receiver: receiver, response: response, request: request, pathsPrefix: pathsPrefix}
-		request.ParseForm()
-		var form NewTodo
-		form.Title = request.FormValue("todo")
-		buf := bytesBufferPool.Get().(*bytes.Buffer)
-		buf.Reset()
-		defer bytesBufferPool.Put(buf)
-		if len(td.errList) == 0 {
-			td.result = receiver.CreateTodo(form)
-			td.okay = true
+	{
+		routeTemplate := templates.Lookup("POST /todos CreateTodo(form)")
+		if routeTemplate == nil {
+			panic("template \"POST /todos CreateTodo(form)\" not found in templates")
 		}
-		if err := templates.ExecuteTemplate(buf, "POST /todos CreateTodo(form)", &td); err != nil {
-			slog.ErrorContext(request.Context(), "failed to render page", slog.String("path", request.URL.Path), slog.String("pattern", request.Pattern), slog.String("error", err.Error()))
-			http.Error(response, "failed to render page", http.StatusInternalServerError)
-			return
-		}
-		defaultStatusCode := http.StatusOK
-		if buf.Len() == 0 {
-			defaultStatusCode = http.StatusNoContent
-		}
-		statusCode := cmp.Or(td.statusCode, td.errStatusCode, defaultStatusCode)
-		if td.redirectURL != "" {
-			http.Redirect(response, request, td.redirectURL, statusCode)
-			return
-		}
-		if contentType := response.Header().Get("content-type"); contentType == "" {
-			response.Header().Set("content-type", "text/html; charset=utf-8")
-		}
-		response.Header().Set("content-length", strconv.Itoa(buf.Len()))
-		response.WriteHeader(statusCode)
-		_, _ = buf.WriteTo(response)
-	})
-	mux.HandleFunc("POST /todos/clear-completed", func(response http.ResponseWriter, request *http.Request) {
-		var td = TemplateData[RoutesReceiver, TodoListChange]{receiver: receiver, response: response, request: request, pathsPrefix: pathsPrefix}
-		buf := bytesBufferPool.Get().(*bytes.Buffer)
-		buf.Reset()
-		defer bytesBufferPool.Put(buf)
-		if len(td.errList) == 0 {
-			td.result = receiver.ClearCompleted()
-			td.okay = true
-		}
-		if err := templates.ExecuteTemplate(buf, "POST /todos/clear-completed ClearCompleted()", &td); err != nil {
-			slog.ErrorContext(request.Context(), "failed to render page", slog.String("path", request.URL.Path), slog.String("pattern", request.Pattern), slog.String("error", err.Error()))
-			http.Error(response, "failed to render page", http.StatusInternalServerError)
-			return
-		}
-		defaultStatusCode := http.StatusOK
-		if buf.Len() == 0 {
-			defaultStatusCode = http.StatusNoContent
-		}
-		statusCode := cmp.Or(td.statusCode, td.errStatusCode, defaultStatusCode)
-		if td.redirectURL != "" {
-			http.Redirect(response, request, td.redirectURL, statusCode)
-			return
-		}
-		if contentType := response.Header().Get("content-type"); contentType == "" {
-			response.Header().Set("content-type", "text/html; charset=utf-8")
-		}
-		response.Header().Set("content-length", strconv.Itoa(buf.Len()))
-		response.WriteHeader(statusCode)
-		_, _ = buf.WriteTo(response)
-	})
-	mux.HandleFunc("POST /todos/toggle-all", func(response http.ResponseWriter, request *http.Request) {
-		var td = TemplateData[RoutesReceiver, TodoListChange]{receiver: receiver, response: response, request: request, pathsPrefix: pathsPrefix}
-		buf := bytesBufferPool.Get().(*bytes.Buffer)
-		buf.Reset()
-		defer bytesBufferPool.Put(buf)
-		if len(td.errList) == 0 {
-			td.result = receiver.ToggleAll()
-			td.okay = true
-		}
-		if err := templates.ExecuteTemplate(buf, "POST /todos/toggle-all ToggleAll()", &td); err != nil {
-			slog.ErrorContext(request.Context(), "failed to render page", slog.String("path", request.URL.Path), slog.String("pattern", request.Pattern), slog.String("error", err.Error()))
-			http.Error(response, "failed to render page", http.StatusInternalServerError)
-			return
-		}
-		defaultStatusCode := http.StatusOK
-		if buf.Len() == 0 {
-			defaultStatusCode = http.StatusNoContent
-		}
-		statusCode := cmp.Or(td.statusCode, td.errStatusCode, defaultStatusCode)
-		if td.redirectURL != "" {
-			http.Redirect(response, request, td.redirectURL, statusCode)
-			return
-		}
-		if contentType := response.Header().Get("content-type"); contentType == "" {
-			response.Header().Set("content-type", "text/html; charset=utf-8")
-		}
-		response.Header().Set("content-length", strconv.Itoa(buf.Len()))
-		response.WriteHeader(statusCode)
-		_, _ = buf.WriteTo(response)
-	})
-	mux.HandleFunc("DELETE /todos/{id}", func(response http.ResponseWriter, request *http.Request) {
-		var td = TemplateData[RoutesReceiver, TodoChange]{receiver: receiver, response: response, request: request, pathsPrefix: pathsPrefix}
-		idParsed, err := strconv.Atoi(request.PathValue("id"))
-		if err != nil {
-			td.errList = append(td.errList, err)
-			td.errStatusCode = http.StatusBadRequest
-		}
-		id := idParsed
-		buf := bytesBufferPool.Get().(*bytes.Buffer)
-		buf.Reset()
-		defer bytesBufferPool.Put(buf)
-		if len(td.errList) == 0 {
-			td.result = receiver.DeleteTodo(id)
-			td.okay = true
-		}
-		if err := templates.ExecuteTemplate(buf, "DELETE /todos/{id} DeleteTodo(id)", &td); err != nil {
-			slog.ErrorContext(request.Context(), "failed to render page", slog.String("path", request.URL.Path), slog.String("pattern", request.Pattern), slog.String("error", err.Error()))
-			http.Error(response, "failed to render page", http.StatusInternalServerError)
-			return
-		}
-		defaultStatusCode := http.StatusOK
-		if buf.Len() == 0 {
-			defaultStatusCode = http.StatusNoContent
-		}
-		statusCode := cmp.Or(td.statusCode, td.errStatusCode, defaultStatusCode)
-		if td.redirectURL != "" {
-			http.Redirect(response, request, td.redirectURL, statusCode)
-			return
+		mux.HandleFunc("POST /todos", func(response http.ResponseWriter, request *http.Request) {
+			var td = TemplateData[RoutesReceiver, TodoChange]{receiver: receiver, response: response, request: request, pathsPrefix: pathsPrefix}
+			request.ParseForm()
+			var form NewTodo
+			form.Title = request.FormValue("todo")
+			td.formData = form
+			buf := bytesBufferPool.Get().(*bytes.Buffer)
+			buf.Reset()
+			defer bytesBufferPool.Put(buf)
+			if len(td.errList) == 0 {
+				td.result = receiver.CreateTodo(form)
+				td.okay = true
+			}
+			if err := routeTemplate.Execute(buf, newTemplateDataForm[NewTodo](&td)); err != nil {
+				td.executeTemplateFailed(err)
+				return
+			}
+			statusCode := td.responseStatusCode(buf, 0, http.StatusOK)
+			if td.writeRedirect(statusCode) {
+				return
+			}
+			td.writeResponse(buf, statusCode)
+		})
+	}
+	{
+		routeTemplate := templates.Lookup("POST /todos/clear-completed ClearCompleted()")
+		if routeTemplate == nil {
+			panic("template \"POST /todos/clear-completed ClearCompleted()\" not found in templates")
 		}
-		if contentType := response.Header().Get("content-type"); contentType == "" {
-			response.Header().Set("content-type", "text/html; charset=utf-8")
+		mux.HandleFunc("POST /todos/clear-completed", func(response http.ResponseWriter, request *http.Request) {
+			var td = TemplateData[RoutesReceiver, TodoListChange]{receiver: receiver, response: response, request: request, pathsPrefix: pathsPrefix}
+			buf := bytesBufferPool.Get().(*bytes.Buffer)
+			buf.Reset()
+			defer bytesBufferPool.Put(buf)
+			if len(td.errList) == 0 {
+				td.result = receiver.ClearCompleted()
+				td.okay = true
+			}
+			if err := routeTemplate.Execute(buf, &td); err != nil {
+				td.executeTemplateFailed(err)
+				return
+			}
+			statusCode := td.responseStatusCode(buf, 0, http.StatusOK)
+			if td.writeRedirect(statusCode) {
+				return
+			}
+			td.writeResponse(buf, statusCode)
+		})
+	}
+	{
+		routeTemplate := templates.Lookup("POST /todos/toggle-all ToggleAll()")
+		if routeTemplate == nil {
+			panic("template \"POST /todos/toggle-all ToggleAll()\" not found in templates")
 		}
-		response.Header().Set("content-length", strconv.Itoa(buf.Len()))
-		response.WriteHeader(statusCode)
-		_, _ = buf.WriteTo(response)
-	})
-	mux.HandleFunc("PATCH /todos/{id}", func(response http.ResponseWriter, request *http.Request) {
-		var td = TemplateData[RoutesReceiver, TodoChange]{receiver: receiver, response: response, request: request, pathsPrefix: pathsPrefix}
-		idParsed, err := strconv.Atoi(request.PathValue("id"))
-		if err != nil {
-			td.errList = append(td.errList, err)
-			td.errStatusCode = http.StatusBadRequest
+		mux.HandleFunc("POST /todos/toggle-all", func(response http.ResponseWriter, request *http.Request) {
+			var td = TemplateData[RoutesReceiver, TodoListChange]{receiver: receiver, response: response, request: request, pathsPrefix: pathsPrefix}
+			buf := bytesBufferPool.Get().(*bytes.Buffer)
+			buf.Reset()
+			defer bytesBufferPool.Put(buf)
+			if len(td.errList) == 0 {
+				td.result = receiver.ToggleAll()
+				td.okay = true
+			}
+			if err := routeTemplate.Execute(buf, &td); err != nil {
+				td.executeTemplateFailed(err)
+				return
+			}
+			statusCode := td.responseStatusCode(buf, 0, http.StatusOK)
+			if td.writeRedirect(statusCode) {
+				return
+			}
+			td.writeResponse(buf, statusCode)
+		})
+	}
+	{
+		routeTemplate := templates.Lookup("DELETE /todos/{id} DeleteTodo(id)")
+		if routeTemplate == nil {
+			panic("template \"DELETE /todos/{id} DeleteTodo(id)\" not found in templates")
 		}
-		id := idParsed
-		buf := bytesBufferPool.Get().(*bytes.Buffer)
-		buf.Reset()
-		defer bytesBufferPool.Put(buf)
-		if len(td.errList) == 0 {
-			var err error
-			td.result, err = receiver.ToggleTodo(id)
+		mux.HandleFunc("DELETE /todos/{id}", func(response http.ResponseWriter, request *http.Request) {
+			var td = TemplateData[RoutesReceiver, TodoChange]{receiver: receiver, response: response, request: request, pathsPrefix: pathsPrefix}
+			idParsed, err := strconv.Atoi(request.PathValue("id"))
 			if err != nil {
 				td.errList = append(td.errList, err)
-				td.errStatusCode = http.StatusInternalServerError
+				td.errStatusCode = http.StatusBadRequest
 			}
+			id := idParsed
+			buf := bytesBufferPool.Get().(*bytes.Buffer)
+			buf.Reset()
+			defer bytesBufferPool.Put(buf)
+			if len(td.errList) == 0 {
+				td.result = receiver.DeleteTodo(id)
+				td.okay = true
+			}
+			if err := routeTemplate.Execute(buf, &td); err != nil {
+				td.executeTemplateFailed(err)
+				return
+			}
+			statusCode := td.responseStatusCode(buf, 0, http.StatusOK)
+			if td.writeRedirect(statusCode) {
+				return
+			}
+			td.writeResponse(buf, statusCode)
+		})
+	}
+	{
+		routeTemplate := templates.Lookup("PATCH /todos/{id} ToggleTodo(id)")
+		if routeTemplate == nil {
+			panic("template \"PATCH /todos/{id} ToggleTodo(id)\" not found in templates")
 		}
-		if err := templates.ExecuteTemplate(buf, "PATCH /todos/{id} ToggleTodo(id)", &td); err != nil {
-			slog.ErrorContext(request.Context(), "failed to render page", slog.String("path", request.URL.Path), slog.String("pattern", request.Pattern), slog.String("error", err.Error()))
-			http.Error(response, "failed to render page", http.StatusInternalServerError)
-			return
-		}
-		defaultStatusCode := http.StatusOK
-		if buf.Len() == 0 {
-			defaultStatusCode = http.StatusNoContent
-		}
-		statusCode := cmp.Or(td.statusCode, td.errStatusCode, defaultStatusCode)
-		if td.redirectURL != "" {
-			http.Redirect(response, request, td.redirectURL, statusCode)
-			return
-		}
-		if contentType := response.Header().Get("content-type"); contentType == "" {
-			response.Header().Set("content-type", "text/html; charset=utf-8")
-		}
-		response.Header().Set("content-length", strconv.Itoa(buf.Len()))
-		response.WriteHeader(statusCode)
-		_, _ = buf.WriteTo(response)
-	})
-	mux.HandleFunc("GET /{$}", func(response http.ResponseWriter, request *http.Request) {
-		var td = TemplateData[RoutesReceiver, TodoPage]{receiver: receiver, response: response, request: request, pathsPrefix: pathsPrefix}
-		request.ParseForm()
-		var form TodoFilter
-		form.Filter = request.FormValue("filter")
-		buf := bytesBufferPool.Get().(*bytes.Buffer)
-		buf.Reset()
-		defer bytesBufferPool.Put(buf)
-		var executed atomic.Bool
-		if len(td.errList) == 0 {
-			if err := receiver.ListTodos(form, func(data TodoPage) error {
-				if !executed.CompareAndSwap(false, true) {
-					return errors.New("execute callback called more than once")
+		mux.HandleFunc("PATCH /todos/{id}", func(response http.ResponseWriter, request *http.Request) {
+			var td = TemplateData[RoutesReceiver, TodoChange]{receiver: receiver, response: response, request: request, pathsPrefix: pathsPrefix}
+			idParsed, err := strconv.Atoi(request.PathValue("id"))
+			if err != nil {
+				td.errList = append(td.errList, err)
+				td.errStatusCode = http.StatusBadRequest
+			}
+			id := idParsed
+			buf := bytesBufferPool.Get().(*bytes.Buffer)
+			buf.Reset()
+			defer bytesBufferPool.Put(buf)
+			if len(td.errList) == 0 {
+				var err error
+				td.result, err = receiver.ToggleTodo(id)
+				if err != nil {
+					td.errList = append(td.errList, err)
+					td.errStatusCode = http.StatusInternalServerError
 				}
-				td.result = data
-				return templates.ExecuteTemplate(buf, "GET /{$} ListTodos(form, execute)", &td)
-			}); err != nil {
-				slog.ErrorContext(request.Context(), "failed to render page", slog.String("path", request.URL.Path), slog.String("pattern", request.Pattern), slog.String("error", err.Error()))
-				http.Error(response, "failed to render page", http.StatusInternalServerError)
+			}
+			if err := routeTemplate.Execute(buf, &td); err != nil {
+				td.executeTemplateFailed(err)
 				return
 			}
-			td.okay = true
-		}
-		defaultStatusCode := http.StatusOK
-		if buf.Len() == 0 {
-			defaultStatusCode = http.StatusNoContent
-		}
-		statusCode := cmp.Or(td.statusCode, td.errStatusCode, defaultStatusCode)
-		if td.redirectURL != "" {
-			http.Redirect(response, request, td.redirectURL, statusCode)
-			return
-		}
-		if contentType := response.Header().Get("content-type"); contentType == "" {
-			response.Header().Set("content-type", "text/html; charset=utf-8")
+			statusCode := td.responseStatusCode(buf, 0, http.StatusOK)
+			if td.writeRedirect(statusCode) {
+				return
+			}
+			td.writeResponse(buf, statusCode)
+		})
+	}
+	{
+		routeTemplate := templates.Lookup("GET /{$} ListTodos(form, execute)")
+		if routeTemplate == nil {
+			panic("template \"GET /{$} ListTodos(form, execute)\" not found in templates")
 		}
-		response.Header().Set("content-length", strconv.Itoa(buf.Len()))
-		response.WriteHeader(statusCode)
-		_, _ = buf.WriteTo(response)
-	})
+		mux.HandleFunc("GET /{$}", func(response http.ResponseWriter, request *http.Request) {
+			var td = TemplateData[RoutesReceiver, TodoPage]{receiver: receiver, response: response, request: request, pathsPrefix: pathsPrefix}
+			request.ParseForm()
+			var form TodoFilter
+			form.Filter = request.FormValue("filter")
+			td.formData = form
+			buf := bytesBufferPool.Get().(*bytes.Buffer)
+			buf.Reset()
+			defer bytesBufferPool.Put(buf)
+			var executed atomic.Bool
+			if len(td.errList) == 0 {
+				if err := receiver.ListTodos(form, func(data TodoPage) error {
+					if !executed.CompareAndSwap(false, true) {
+						return errors.New("execute callback called more than once")
+					}
+					td.result = data
+					return routeTemplate.Execute(buf, newTemplateDataForm[TodoFilter](&td))
+				}); err != nil {
+					td.executeTemplateFailed(err)
+					return
+				}
+				td.okay = true
+			}
+			statusCode := td.responseStatusCode(buf, 0, http.StatusOK)
+			if td.writeRedirect(statusCode) {
+				return
+			}
+			td.writeResponse(buf, statusCode)
+		})
+	}
 	return TemplateRoutePaths{pathsPrefix: pathsPrefix}
 }
 
@@ -250,6 +223,8 @@ type TemplateData[R any, T any] struct {
 	errStatusCode int
 	okay          bool
 	errList       []error
+	fieldErrors   map[string]TemplateDataFieldError
+	formData      any
 	redirectURL   string
 	pathsPrefix   string
 }
@@ -289,6 +264,56 @@ func (data *TemplateData[R, T]) Err() error {
 	return errors.Join(data.errList...)
 }
 
+func (data *TemplateData[R, T]) FieldErrors() map[string]TemplateDataFieldError {
+	return data.fieldErrors
+}
+
+func (data *TemplateData[R, T]) FieldError(name string) *TemplateDataFieldError {
+	if fe, ok := data.fieldErrors[name]; ok {
+		return &fe
+	}
+	return nil
+}
+
+func (data *TemplateData[R, T]) appendFieldError(name, constraint string, err error) {
+	if data.fieldErrors == nil {
+		data.fieldErrors = make(map[string]TemplateDataFieldError)
+	}
+	if _, ok := data.fieldErrors[name]; !ok {
+		data.fieldErrors[name] = TemplateDataFieldError{Name: name, Constraint: constraint, Err: err}
+	}
+	data.errList = append(data.errList, err)
+}
+
+func (data *TemplateData[R, T]) responseStatusCode(buf *bytes.Buffer, resultStatusCode, defaultStatusCode int) int {
+	if defaultStatusCode == http.StatusOK && buf.Len() == 0 {
+		defaultStatusCode = http.StatusNoContent
+	}
+	return cmp.Or(data.statusCode, data.errStatusCode, resultStatusCode, defaultStatusCode)
+}
+
+func (data *TemplateData[R, T]) writeResponse(buf *bytes.Buffer, statusCode int) {
+	if contentType := data.response.Header().Get("content-type"); contentType == "" {
+		data.response.Header().Set("content-type", "text/html; charset=utf-8")
+	}
+	data.response.Header().Set("content-length", strconv.Itoa(buf.Len()))
+	data.response.WriteHeader(statusCode)
+	_, _ = buf.WriteTo(data.response)
+}
+
+func (data *TemplateData[R, T]) writeRedirect(statusCode int) bool {
+	if data.redirectURL == "" {
+		return false
+	}
+	http.Redirect(data.response, data.request, data.redirectURL, statusCode)
+	return true
+}
+
+func (data *TemplateData[R, T]) executeTemplateFailed(err error) {
+	slog.ErrorContext(data.request.Context(), "failed to render page", slog.String("path", data.request.URL.Path), slog.String("pattern", data.request.Pattern), slog.String("error", err.Error()))
+	http.Error(data.response, "failed to render page", http.StatusInternalServerError)
+}
+
 func (data *TemplateData[R, T]) Receiver() R {
 	return data.receiver
 }
@@ -317,10 +342,48 @@ func (data *TemplateData[R, T]) RedirectSeeOther(url string) (*TemplateData[R, T
 	return data.Redirect(url, http.StatusSeeOther)
 }
 
+func (data *TemplateData[R, T]) Form() url.Values {
+	if data.request == nil {
+		return nil
+	}
+	return data.request.Form
+}
+
+func (data *TemplateData[R, T]) FormValue(name string) string {
+	return data.Form().Get(name)
+}
+
+type TemplateDataForm[R, T, F any] struct {
+	*TemplateData[R, T]
+}
+
+func (data *TemplateDataForm[R, T, F]) FormData() F {
+	form, _ := data.formData.(F)
+	return form
+}
+
+func newTemplateDataForm[F, R, T any](data *TemplateData[R, T]) *TemplateDataForm[R, T, F] {
+	return &TemplateDataForm[R, T, F]{TemplateData: data}
+}
+
 func (data *TemplateData[R, T]) String() string {
 	return ""
 }
 
+type TemplateDataFieldError struct {
+	Name       string
+	Constraint string
+	Err        error
+}
+
+func (e TemplateDataFieldError) Error() string {
+	return e.Err.Error()
+}
+
+func (e TemplateDataFieldError) Unwrap() error {
+	return e.Err
+}
+
 func (data *TemplateData[R, T]) HXLocation(link string) *TemplateData[R, T] {
 	return data.Header("HX-Location", link)
 }
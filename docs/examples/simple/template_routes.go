@@ -11,6 +11,7 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
+	"net/url"
 	"path"
 	"strconv"
 	"sync"
@@ -27,155 +28,137 @@ func TemplateRoutes(mux *http.ServeMux, receiver RoutesReceiver) TemplateRoutePa
 	bytesBufferPool := sync.Pool{New: func() any {
 		return bytes.NewBuffer(nil)
 	}}
-	mux.HandleFunc("PATCH /fruits/{id}", func(response http.ResponseWriter, request *http.Request) {
-		var td = TemplateData[RoutesReceiver, Row]{receiver: receiver, response: response, request: request, pathsPrefix: pathsPrefix}
-		idParsed, err := strconv.Atoi(request.PathValue("id"))
-		if err != nil {
-			td.errList = append(td.errList, err)
-			td.errStatusCode = http.StatusBadRequest
+	{
+		routeTemplate := templates.Lookup("PATCH /fruits/{id} SubmitFormEditRow(id, form)")
+		if routeTemplate == nil {
+			panic("template \"PATCH /fruits/{id} SubmitFormEditRow(id, form)\" not found in templates")
 		}
-		id := idParsed
-		request.ParseForm()
-		var form EditRow
-		{
-			value, err := strconv.Atoi(request.FormValue("count"))
+		mux.HandleFunc("PATCH /fruits/{id}", func(response http.ResponseWriter, request *http.Request) {
+			var td = TemplateData[RoutesReceiver, Row]{receiver: receiver, response: response, request: request, pathsPrefix: pathsPrefix}
+			idParsed, err := strconv.Atoi(request.PathValue("id"))
 			if err != nil {
 				td.errList = append(td.errList, err)
 				td.errStatusCode = http.StatusBadRequest
-			} else {
-				if value < 0 {
-					td.errList = append(td.errList, errors.New("count must not be less than 0"))
+			}
+			id := idParsed
+			request.ParseForm()
+			var form EditRow
+			{
+				value, err := strconv.Atoi(request.FormValue("count"))
+				if err != nil {
+					td.appendFieldError("count", "parse", err)
 					td.errStatusCode = http.StatusBadRequest
+				} else {
+					if value < 0 {
+						td.appendFieldError("count", "min", errors.New("count must not be less than 0"))
+						td.errStatusCode = http.StatusBadRequest
+					}
 				}
+				form.Value = value
 			}
-			form.Value = value
-		}
-		buf := bytesBufferPool.Get().(*bytes.Buffer)
-		buf.Reset()
-		defer bytesBufferPool.Put(buf)
-		if len(td.errList) == 0 {
-			var err error
-			td.result, err = receiver.SubmitFormEditRow(id, form)
-			if err != nil {
-				td.errList = append(td.errList, err)
-				td.errStatusCode = http.StatusInternalServerError
+			td.formData = form
+			buf := bytesBufferPool.Get().(*bytes.Buffer)
+			buf.Reset()
+			defer bytesBufferPool.Put(buf)
+			if len(td.errList) == 0 {
+				var err error
+				td.result, err = receiver.SubmitFormEditRow(id, form)
+				if err != nil {
+					td.errList = append(td.errList, err)
+					td.errStatusCode = http.StatusInternalServerError
+				}
 			}
+			if err := routeTemplate.Execute(buf, newTemplateDataForm[EditRow](&td)); err != nil {
+				td.executeTemplateFailed(err)
+				return
+			}
+			statusCode := td.responseStatusCode(buf, 0, http.StatusOK)
+			if td.writeRedirect(statusCode) {
+				return
+			}
+			td.writeResponse(buf, statusCode)
+		})
+	}
+	{
+		routeTemplate := templates.Lookup("GET /fruits/{id}/edit GetFormEditRow(id)")
+		if routeTemplate == nil {
+			panic("template \"GET /fruits/{id}/edit GetFormEditRow(id)\" not found in templates")
 		}
-		if err := templates.ExecuteTemplate(buf, "PATCH /fruits/{id} SubmitFormEditRow(id, form)", &td); err != nil {
-			slog.ErrorContext(request.Context(), "failed to render page", slog.String("path", request.URL.Path), slog.String("pattern", request.Pattern), slog.String("error", err.Error()))
-			http.Error(response, "failed to render page", http.StatusInternalServerError)
-			return
-		}
-		defaultStatusCode := http.StatusOK
-		if buf.Len() == 0 {
-			defaultStatusCode = http.StatusNoContent
-		}
-		statusCode := cmp.Or(td.statusCode, td.errStatusCode, defaultStatusCode)
-		if td.redirectURL != "" {
-			http.Redirect(response, request, td.redirectURL, statusCode)
-			return
-		}
-		if contentType := response.Header().Get("content-type"); contentType == "" {
-			response.Header().Set("content-type", "text/html; charset=utf-8")
-		}
-		response.Header().Set("content-length", strconv.Itoa(buf.Len()))
-		response.WriteHeader(statusCode)
-		_, _ = buf.WriteTo(response)
-	})
-	mux.HandleFunc("GET /fruits/{id}/edit", func(response http.ResponseWriter, request *http.Request) {
-		var td = TemplateData[RoutesReceiver, Row]{receiver: receiver, response: response, request: request, pathsPrefix: pathsPrefix}
-		idParsed, err := strconv.Atoi(request.PathValue("id"))
-		if err != nil {
-			td.errList = append(td.errList, err)
-			td.errStatusCode = http.StatusBadRequest
-		}
-		id := idParsed
-		buf := bytesBufferPool.Get().(*bytes.Buffer)
-		buf.Reset()
-		defer bytesBufferPool.Put(buf)
-		if len(td.errList) == 0 {
-			var err error
-			td.result, err = receiver.GetFormEditRow(id)
+		mux.HandleFunc("GET /fruits/{id}/edit", func(response http.ResponseWriter, request *http.Request) {
+			var td = TemplateData[RoutesReceiver, Row]{receiver: receiver, response: response, request: request, pathsPrefix: pathsPrefix}
+			idParsed, err := strconv.Atoi(request.PathValue("id"))
 			if err != nil {
 				td.errList = append(td.errList, err)
-				td.errStatusCode = http.StatusInternalServerError
+				td.errStatusCode = http.StatusBadRequest
 			}
+			id := idParsed
+			buf := bytesBufferPool.Get().(*bytes.Buffer)
+			buf.Reset()
+			defer bytesBufferPool.Put(buf)
+			if len(td.errList) == 0 {
+				var err error
+				td.result, err = receiver.GetFormEditRow(id)
+				if err != nil {
+					td.errList = append(td.errList, err)
+					td.errStatusCode = http.StatusInternalServerError
+				}
+			}
+			if err := routeTemplate.Execute(buf, &td); err != nil {
+				td.executeTemplateFailed(err)
+				return
+			}
+			statusCode := td.responseStatusCode(buf, 0, http.StatusOK)
+			if td.writeRedirect(statusCode) {
+				return
+			}
+			td.writeResponse(buf, statusCode)
+		})
+	}
+	{
+		routeTemplate := templates.Lookup("GET /help")
+		if routeTemplate == nil {
+			panic("template \"GET /help\" not found in templates")
 		}
-		if err := templates.ExecuteTemplate(buf, "GET /fruits/{id}/edit GetFormEditRow(id)", &td); err != nil {
-			slog.ErrorContext(request.Context(), "failed to render page", slog.String("path", request.URL.Path), slog.String("pattern", request.Pattern), slog.String("error", err.Error()))
-			http.Error(response, "failed to render page", http.StatusInternalServerError)
-			return
-		}
-		defaultStatusCode := http.StatusOK
-		if buf.Len() == 0 {
-			defaultStatusCode = http.StatusNoContent
-		}
-		statusCode := cmp.Or(td.statusCode, td.errStatusCode, defaultStatusCode)
-		if td.redirectURL != "" {
-			http.Redirect(response, request, td.redirectURL, statusCode)
-			return
-		}
-		if contentType := response.Header().Get("content-type"); contentType == "" {
-			response.Header().Set("content-type", "text/html; charset=utf-8")
-		}
-		response.Header().Set("content-length", strconv.Itoa(buf.Len()))
-		response.WriteHeader(statusCode)
-		_, _ = buf.WriteTo(response)
-	})
-	mux.HandleFunc("GET /help", func(response http.ResponseWriter, request *http.Request) {
-		var td = TemplateData[RoutesReceiver, struct {
-		}]{receiver: receiver, response: response, request: request, pathsPrefix: pathsPrefix}
-		buf := bytesBufferPool.Get().(*bytes.Buffer)
-		buf.Reset()
-		defer bytesBufferPool.Put(buf)
-		if err := templates.ExecuteTemplate(buf, "GET /help", &td); err != nil {
-			slog.ErrorContext(request.Context(), "failed to render page", slog.String("path", request.URL.Path), slog.String("pattern", request.Pattern), slog.String("error", err.Error()))
-			http.Error(response, "failed to render page", http.StatusInternalServerError)
-			return
-		}
-		defaultStatusCode := http.StatusOK
-		if buf.Len() == 0 {
-			defaultStatusCode = http.StatusNoContent
-		}
-		statusCode := cmp.Or(td.statusCode, td.errStatusCode, defaultStatusCode)
-		if contentType := response.Header().Get("content-type"); contentType == "" {
-			response.Header().Set("content-type", "text/html; charset=utf-8")
-		}
-		response.Header().Set("content-length", strconv.Itoa(buf.Len()))
-		response.WriteHeader(statusCode)
-		_, _ = buf.WriteTo(response)
-	})
-	mux.HandleFunc("GET /{$}", func(response http.ResponseWriter, request *http.Request) {
-		var td = TemplateData[RoutesReceiver, []Row]{receiver: receiver, response: response, request: request, pathsPrefix: pathsPrefix}
-		ctx := request.Context()
-		buf := bytesBufferPool.Get().(*bytes.Buffer)
-		buf.Reset()
-		defer bytesBufferPool.Put(buf)
-		if len(td.errList) == 0 {
-			td.result = receiver.List(ctx)
-			td.okay = true
-		}
-		if err := templates.ExecuteTemplate(buf, "GET /{$} List(ctx)", &td); err != nil {
-			slog.ErrorContext(request.Context(), "failed to render page", slog.String("path", request.URL.Path), slog.String("pattern", request.Pattern), slog.String("error", err.Error()))
-			http.Error(response, "failed to render page", http.StatusInternalServerError)
-			return
-		}
-		defaultStatusCode := http.StatusOK
-		if buf.Len() == 0 {
-			defaultStatusCode = http.StatusNoContent
-		}
-		statusCode := cmp.Or(td.statusCode, td.errStatusCode, defaultStatusCode)
-		if td.redirectURL != "" {
-			http.Redirect(response, request, td.redirectURL, statusCode)
-			return
-		}
-		if contentType := response.Header().Get("content-type"); contentType == "" {
-			response.Header().Set("content-type", "text/html; charset=utf-8")
+		mux.HandleFunc("GET /help", func(response http.ResponseWriter, request *http.Request) {
+			var td = TemplateData[RoutesReceiver, struct {
+			}]{receiver: receiver, response: response, request: request, pathsPrefix: pathsPrefix}
+			buf := bytesBufferPool.Get().(*bytes.Buffer)
+			buf.Reset()
+			defer bytesBufferPool.Put(buf)
+			if err := routeTemplate.Execute(buf, &td); err != nil {
+				td.executeTemplateFailed(err)
+				return
+			}
+			statusCode := td.responseStatusCode(buf, 0, http.StatusOK)
+			td.writeResponse(buf, statusCode)
+		})
+	}
+	{
+		routeTemplate := templates.Lookup("GET /{$} List(ctx)")
+		if routeTemplate == nil {
+			panic("template \"GET /{$} List(ctx)\" not found in templates")
 		}
-		response.Header().Set("content-length", strconv.Itoa(buf.Len()))
-		response.WriteHeader(statusCode)
-		_, _ = buf.WriteTo(response)
-	})
+		mux.HandleFunc("GET /{$}", func(response http.ResponseWriter, request *http.Request) {
+			var td = TemplateData[RoutesReceiver, []Row]{receiver: receiver, response: response, request: request, pathsPrefix: pathsPrefix}
+			ctx := request.Context()
+			buf := bytesBufferPool.Get().(*bytes.Buffer)
+			buf.Reset()
+			defer bytesBufferPool.Put(buf)
+			if len(td.errList) == 0 {
+				td.result = receiver.List(ctx)
+				td.okay = true
+			}
+			if err := routeTemplate.Execute(buf, &td); err != nil {
+				td.executeTemplateFailed(err)
+				return
+			}
+			statusCode := td.responseStatusCode(buf, 0, http.StatusOK)
+			if td.writeRedirect(statusCode) {
+				return
+			}
+			td.writeResponse(buf, statusCode)
+		})
+	}
 	return TemplateRoutePaths{pathsPrefix: pathsPrefix}
 }
 
@@ -188,6 +171,8 @@ type TemplateData[R any, T any] struct {
 	errStatusCode int
 	okay          bool
 	errList       []error
+	fieldErrors   map[string]TemplateDataFieldError
+	formData      any
 	redirectURL   string
 	pathsPrefix   string
 }
@@ -227,6 +212,56 @@ func (data *TemplateData[R, T]) Err() error {
 	return errors.Join(data.errList...)
 }
 
+func (data *TemplateData[R, T]) FieldErrors() map[string]TemplateDataFieldError {
+	return data.fieldErrors
+}
+
+func (data *TemplateData[R, T]) FieldError(name string) *TemplateDataFieldError {
+	if fe, ok := data.fieldErrors[name]; ok {
+		return &fe
+	}
+	return nil
+}
+
+func (data *TemplateData[R, T]) appendFieldError(name, constraint string, err error) {
+	if data.fieldErrors == nil {
+		data.fieldErrors = make(map[string]TemplateDataFieldError)
+	}
+	if _, ok := data.fieldErrors[name]; !ok {
+		data.fieldErrors[name] = TemplateDataFieldError{Name: name, Constraint: constraint, Err: err}
+	}
+	data.errList = append(data.errList, err)
+}
+
+func (data *TemplateData[R, T]) responseStatusCode(buf *bytes.Buffer, resultStatusCode, defaultStatusCode int) int {
+	if defaultStatusCode == http.StatusOK && buf.Len() == 0 {
+		defaultStatusCode = http.StatusNoContent
+	}
+	return cmp.Or(data.statusCode, data.errStatusCode, resultStatusCode, defaultStatusCode)
+}
+
+func (data *TemplateData[R, T]) writeResponse(buf *bytes.Buffer, statusCode int) {
+	if contentType := data.response.Header().Get("content-type"); contentType == "" {
+		data.response.Header().Set("content-type", "text/html; charset=utf-8")
+	}
+	data.response.Header().Set("content-length", strconv.Itoa(buf.Len()))
+	data.response.WriteHeader(statusCode)
+	_, _ = buf.WriteTo(data.response)
+}
+
+func (data *TemplateData[R, T]) writeRedirect(statusCode int) bool {
+	if data.redirectURL == "" {
+		return false
+	}
+	http.Redirect(data.response, data.request, data.redirectURL, statusCode)
+	return true
+}
+
+func (data *TemplateData[R, T]) executeTemplateFailed(err error) {
+	slog.ErrorContext(data.request.Context(), "failed to render page", slog.String("path", data.request.URL.Path), slog.String("pattern", data.request.Pattern), slog.String("error", err.Error()))
+	http.Error(data.response, "failed to render page", http.StatusInternalServerError)
+}
+
 func (data *TemplateData[R, T]) Receiver() R {
 	return data.receiver
 }
@@ -255,10 +290,48 @@ func (data *TemplateData[R, T]) RedirectSeeOther(url string) (*TemplateData[R, T
 	return data.Redirect(url, http.StatusSeeOther)
 }
 
+func (data *TemplateData[R, T]) Form() url.Values {
+	if data.request == nil {
+		return nil
+	}
+	return data.request.Form
+}
+
+func (data *TemplateData[R, T]) FormValue(name string) string {
+	return data.Form().Get(name)
+}
+
+type TemplateDataForm[R, T, F any] struct {
+	*TemplateData[R, T]
+}
+
+func (data *TemplateDataForm[R, T, F]) FormData() F {
+	form, _ := data.formData.(F)
+	return form
+}
+
+func newTemplateDataForm[F, R, T any](data *TemplateData[R, T]) *TemplateDataForm[R, T, F] {
+	return &TemplateDataForm[R, T, F]{TemplateData: data}
+}
+
 func (data *TemplateData[R, T]) String() string {
 	return ""
 }
 
+type TemplateDataFieldError struct {
+	Name       string
+	Constraint string
+	Err        error
+}
+
+func (e TemplateDataFieldError) Error() string {
+	return e.Err.Error()
+}
+
+func (e TemplateDataFieldError) Unwrap() error {
+	return e.Err
+}
+
 type TemplateRoutePaths struct {
 	pathsPrefix string
 }
@@ -44,9 +44,10 @@ func Check(config CheckConfiguration, wd string, log *log.Logger, fileSet *token
 
 		executedTemplates := make(map[string][]TemplateExecution)
 
+		lookups := asteval.TemplateLookups(routesPkg.Syntax, routesPkg.TypesInfo, tv)
 		for _, file := range routesPkg.Syntax {
 			for node := range ast.Preorder(file) {
-				templateName, dataType, ok := asteval.ExecuteTemplateArguments(node, routesPkg.TypesInfo, tv)
+				templateName, dataType, ok := asteval.ExecuteTemplateArguments(node, routesPkg.TypesInfo, tv, lookups)
 				if !ok {
 					continue
 				}
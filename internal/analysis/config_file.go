@@ -0,0 +1,201 @@
+package analysis
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+
+	"github.com/BurntSushi/toml"
+	"github.com/spf13/pflag"
+)
+
+// ConfigFileNames are the project configuration files muxt reads, in the
+// package directory and at the module root.
+var ConfigFileNames = []string{"muxt.json", "muxt.toml"}
+
+// ConfigFile holds flag values read from muxt.json or muxt.toml files. Keys
+// are flag names without the leading dashes:
+//
+//	{
+//		"use-receiver-type": "Server",
+//		"use-templates-variable": ["templates", "pages"],
+//		"output-routes-func-with-logger-param": true
+//	}
+type ConfigFile struct {
+	// Paths are the files read, module root first.
+	Paths  []string
+	values []configFileValue
+}
+
+type configFileValue struct {
+	path, name string
+	values     []string
+}
+
+// LoadConfigFile reads the configuration file at the module root containing
+// dir and then the one in dir, so values in the package directory replace
+// the module values. A directory may hold only one of ConfigFileNames. It
+// returns an empty ConfigFile when there is none.
+func LoadConfigFile(dir string) (ConfigFile, error) {
+	var config ConfigFile
+	dirs := []string{dir}
+	if root, ok := moduleRoot(dir); ok && root != dir {
+		dirs = []string{root, dir}
+	}
+	for _, d := range dirs {
+		var found []string
+		for _, name := range ConfigFileNames {
+			path := filepath.Join(d, name)
+			if _, err := os.Stat(path); err == nil {
+				found = append(found, path)
+			} else if !errors.Is(err, fs.ErrNotExist) {
+				return config, err
+			}
+		}
+		switch len(found) {
+		case 0:
+			continue
+		case 1:
+		default:
+			return config, fmt.Errorf("found both %s and %s: use one configuration file", found[0], found[1])
+		}
+		values, err := readConfigFile(found[0])
+		if err != nil {
+			return config, err
+		}
+		config.Paths = append(config.Paths, found[0])
+		config.values = append(config.values, values...)
+	}
+	return config, nil
+}
+
+// Apply sets the flags in flagSet from the configuration file and returns
+// the names of the flags it set. Flags set on the command line keep their
+// value. With ignoreUnknown, keys that are not flags of flagSet are skipped
+// instead of reported.
+func (config ConfigFile) Apply(flagSet *pflag.FlagSet, ignoreUnknown bool) ([]string, error) {
+	var (
+		errs    []error
+		names   []string
+		fromCLI = make(map[string]bool)
+		applied = make(map[string]bool)
+	)
+	flagSet.Visit(func(flag *pflag.Flag) { fromCLI[flag.Name] = true })
+	for _, v := range config.values {
+		flag := flagSet.Lookup(v.name)
+		if flag == nil {
+			if !ignoreUnknown {
+				errs = append(errs, fmt.Errorf("%s: unknown key %q", v.path, v.name))
+			}
+			continue
+		}
+		if fromCLI[v.name] {
+			continue
+		}
+		if applied[v.name] {
+			// A package value replaces the module value instead of
+			// appending to it.
+			if err := resetFlag(flag); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %s: %w", v.path, v.name, err))
+				continue
+			}
+		}
+		if !applied[v.name] {
+			names = append(names, v.name)
+		}
+		applied[v.name] = true
+		for _, value := range v.values {
+			if err := flagSet.Set(v.name, value); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %s: %w", v.path, v.name, err))
+			}
+		}
+	}
+	return names, errors.Join(errs...)
+}
+
+func resetFlag(flag *pflag.Flag) error {
+	if slice, ok := flag.Value.(pflag.SliceValue); ok {
+		return slice.Replace(nil)
+	}
+	return flag.Value.Set(flag.DefValue)
+}
+
+func readConfigFile(path string) ([]configFileValue, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var raw map[string]any
+	switch filepath.Ext(path) {
+	case ".toml":
+		err = toml.Unmarshal(buf, &raw)
+	default:
+		err = json.Unmarshal(buf, &raw)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	names := make([]string, 0, len(raw))
+	for name := range raw {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	var result []configFileValue
+	for _, name := range names {
+		values, err := configFileFlagValues(raw[name])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s: %w", path, name, err)
+		}
+		result = append(result, configFileValue{path: path, name: name, values: values})
+	}
+	return result, nil
+}
+
+// configFileFlagValues converts a JSON or TOML value to the arguments passed
+// to pflag.FlagSet.Set; an array sets a repeatable flag once per element.
+func configFileFlagValues(value any) ([]string, error) {
+	switch v := value.(type) {
+	case string:
+		return []string{v}, nil
+	case bool:
+		return []string{strconv.FormatBool(v)}, nil
+	case float64:
+		return []string{strconv.FormatFloat(v, 'f', -1, 64)}, nil
+	case int64:
+		return []string{strconv.FormatInt(v, 10)}, nil
+	case []any:
+		var values []string
+		for _, elem := range v {
+			more, err := configFileFlagValues(elem)
+			if err != nil {
+				return nil, err
+			}
+			if _, nested := elem.([]any); nested {
+				return nil, fmt.Errorf("nested arrays are not supported")
+			}
+			values = append(values, more...)
+		}
+		return values, nil
+	default:
+		return nil, fmt.Errorf("unsupported value type %T", value)
+	}
+}
+
+// moduleRoot returns the closest directory containing dir with a go.mod file.
+func moduleRoot(dir string) (string, bool) {
+	for d := dir; ; {
+		if _, err := os.Stat(filepath.Join(d, "go.mod")); err == nil {
+			return d, true
+		}
+		parent := filepath.Dir(d)
+		if parent == d {
+			return "", false
+		}
+		d = parent
+	}
+}
@@ -30,6 +30,10 @@ type PackageInfo struct {
 	Config         PackageConfig   `json:"config"`
 	Commands       PackageCommands `json:"commands"`
 	ExternalAssets []ExternalAsset `json:"externalAssets,omitempty"`
+	ConfigFiles    []string        `json:"configFiles,omitempty"`
+
+	// Args are the muxt generate flags recorded in the generated file header.
+	Args []string `json:"-"`
 }
 
 type PackageConfig struct {
@@ -170,6 +174,13 @@ func NewModule(workingDirectory string, addFlags func(*pflag.FlagSet, *generate.
 		if err := set.Parse(entry.args); err != nil {
 			continue
 		}
+		configFile, err := LoadConfigFile(entry.dir)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := configFile.Apply(set, false); err != nil {
+			return nil, err
+		}
 
 		// Apply defaults for empty fields
 		routesFunction := config.RoutesFunction
@@ -221,6 +232,8 @@ func NewModule(workingDirectory string, addFlags func(*pflag.FlagSet, *generate.
 			},
 			Commands:       commands,
 			ExternalAssets: assets,
+			ConfigFiles:    configFile.Paths,
+			Args:           entry.args,
 		})
 	}
 
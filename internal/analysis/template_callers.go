@@ -51,9 +51,10 @@ func NewTemplateCallers(config TemplateCallersConfiguration, fileSet *token.File
 		})
 	}
 
+	lookups := asteval.TemplateLookups(pkg.Syntax, pkg.TypesInfo, config.TemplatesVariable)
 	for _, file := range pkg.Syntax {
 		for node := range ast.Preorder(file) {
-			templateName, dataType, ok := asteval.ExecuteTemplateArguments(node, pkg.TypesInfo, config.TemplatesVariable)
+			templateName, dataType, ok := asteval.ExecuteTemplateArguments(node, pkg.TypesInfo, config.TemplatesVariable, lookups)
 			if !ok {
 				continue
 			}
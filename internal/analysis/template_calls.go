@@ -49,10 +49,12 @@ func NewTemplateCalls(config TemplateCallsConfiguration, pkg *packages.Package,
 		})
 	}
 
+	lookups := asteval.TemplateLookups(pkg.Syntax, pkg.TypesInfo, config.TemplatesVariable)
+
 	// Analyze all templates
 	for _, file := range pkg.Syntax {
 		for node := range ast.Preorder(file) {
-			templateName, dataType, ok := asteval.ExecuteTemplateArguments(node, pkg.TypesInfo, config.TemplatesVariable)
+			templateName, dataType, ok := asteval.ExecuteTemplateArguments(node, pkg.TypesInfo, config.TemplatesVariable, lookups)
 			if !ok {
 				continue
 			}
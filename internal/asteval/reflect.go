@@ -71,6 +71,18 @@ func ParseWithType(val string, tp types.Type) (reflect.Value, error) {
 			return reflect.Value{}, err
 		}
 		return reflect.ValueOf(n), nil
+	case reflect.Float32.String():
+		n, err := strconv.ParseFloat(val, 32)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		return reflect.ValueOf(float32(n)), nil
+	case reflect.Float64.String():
+		n, err := strconv.ParseFloat(val, 64)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		return reflect.ValueOf(n), nil
 	default:
 		return reflect.Value{}, fmt.Errorf("type %s unknown", tp.String())
 	}
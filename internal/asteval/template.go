@@ -25,7 +25,9 @@ import (
 )
 
 const (
-	TemplateExecuteFunc = "ExecuteTemplate"
+	TemplateExecuteFunc   = "ExecuteTemplate"
+	TemplateExecuteMethod = "Execute"
+	TemplateLookupMethod  = "Lookup"
 )
 
 func Templates(workingDirectory, templatesVariable string, pkg *packages.Package) (*template.Template, TemplateFunctions, error) {
@@ -508,18 +510,33 @@ func (functions TemplateFunctions) FindFunction(name string) (*types.Signature,
 	return fn, true
 }
 
-func ExecuteTemplateArguments(node ast.Node, info *types.Info, templatesVariableName string) (string, types.Type, bool) {
+// ExecuteTemplateArguments returns the template name and data type of
+// templatesVariableName.ExecuteTemplate(w, "name", data) or of
+// t.Execute(w, data) where lookups records t as a template looked up by name
+// (see TemplateLookups).
+func ExecuteTemplateArguments(node ast.Node, info *types.Info, templatesVariableName string, lookups map[types.Object]string) (string, types.Type, bool) {
 	call, ok := node.(*ast.CallExpr)
 	if !ok {
 		return "", nil, false
 	}
-	if len(call.Args) != 3 {
-		return "", nil, false
-	}
 	sel, ok := call.Fun.(*ast.SelectorExpr)
 	if !ok {
 		return "", nil, false
 	}
+	if sel.Sel.Name == TemplateExecuteMethod && len(call.Args) == 2 {
+		templateIdent, ok := sel.X.(*ast.Ident)
+		if !ok {
+			return "", nil, false
+		}
+		templateName, ok := lookups[info.Uses[templateIdent]]
+		if !ok {
+			return "", nil, false
+		}
+		return templateName, info.TypeOf(call.Args[1]), true
+	}
+	if len(call.Args) != 3 {
+		return "", nil, false
+	}
 	if sel.Sel.Name != TemplateExecuteFunc {
 		return "", nil, false
 	}
@@ -538,6 +555,57 @@ func ExecuteTemplateArguments(node ast.Node, info *types.Info, templatesVariable
 	return templateName, dataVar, true
 }
 
+// TemplateLookups maps each variable assigned templatesVariableName.Lookup("name")
+// in files to the looked up template name.
+func TemplateLookups(files []*ast.File, info *types.Info, templatesVariableName string) map[types.Object]string {
+	lookups := make(map[types.Object]string)
+	record := func(lhs []*ast.Ident, rhs []ast.Expr) {
+		if len(lhs) != len(rhs) {
+			return
+		}
+		for i, expr := range rhs {
+			templateName, ok := lookupArgument(expr, templatesVariableName)
+			if !ok || lhs[i] == nil {
+				continue
+			}
+			if obj := info.ObjectOf(lhs[i]); obj != nil {
+				lookups[obj] = templateName
+			}
+		}
+	}
+	for _, file := range files {
+		for node := range ast.Preorder(file) {
+			switch node := node.(type) {
+			case *ast.AssignStmt:
+				idents := make([]*ast.Ident, len(node.Lhs))
+				for i, expr := range node.Lhs {
+					idents[i], _ = expr.(*ast.Ident)
+				}
+				record(idents, node.Rhs)
+			case *ast.ValueSpec:
+				record(node.Names, node.Values)
+			}
+		}
+	}
+	return lookups
+}
+
+func lookupArgument(expr ast.Expr, templatesVariableName string) (string, bool) {
+	call, ok := expr.(*ast.CallExpr)
+	if !ok || len(call.Args) != 1 {
+		return "", false
+	}
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != TemplateLookupMethod {
+		return "", false
+	}
+	templatesIdent, ok := sel.X.(*ast.Ident)
+	if !ok || templatesIdent.Name != templatesVariableName {
+		return "", false
+	}
+	return basicLiteralString(call.Args[0])
+}
+
 func basicLiteralString(node ast.Node) (string, bool) {
 	name, ok := node.(*ast.BasicLit)
 	if !ok {
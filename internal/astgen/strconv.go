@@ -3,6 +3,7 @@ package astgen
 import (
 	"fmt"
 	"go/ast"
+	"go/token"
 	"go/types"
 )
 
@@ -31,6 +32,10 @@ func ConvertToString(im ImportManager, variable ast.Expr, kind types.BasicKind)
 		return FormatUint32(im, variable), nil
 	case types.Uint64:
 		return FormatUint64(im, variable), nil
+	case types.Float32:
+		return FormatFloat32(im, variable), nil
+	case types.Float64, types.UntypedFloat:
+		return FormatFloat64(im, variable), nil
 	case types.String:
 		return variable, nil
 	default:
@@ -163,6 +168,16 @@ func FormatUint64(im ImportManager, in ast.Expr) *ast.CallExpr {
 	return Call(im, "", "strconv", "FormatUint", in, Int(10))
 }
 
+// FormatFloat32 creates a strconv.FormatFloat call for float32
+func FormatFloat32(im ImportManager, in ast.Expr) *ast.CallExpr {
+	return Call(im, "", "strconv", "FormatFloat", ConvertIdent("float64", in), &ast.BasicLit{Kind: token.CHAR, Value: "'g'"}, Int(-1), Int(32))
+}
+
+// FormatFloat64 creates a strconv.FormatFloat call for float64
+func FormatFloat64(im ImportManager, in ast.Expr) *ast.CallExpr {
+	return Call(im, "", "strconv", "FormatFloat", ConvertIdent("float64", in), &ast.BasicLit{Kind: token.CHAR, Value: "'g'"}, Int(-1), Int(64))
+}
+
 // FormatBool creates a strconv.FormatBool call expression
 func FormatBool(im ImportManager, in ast.Expr) *ast.CallExpr {
 	return Call(im, "", "strconv", "FormatBool", ConvertIdent("bool", in))
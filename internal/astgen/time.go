@@ -2,9 +2,25 @@ package astgen
 
 import (
 	"go/ast"
+	"time"
 )
 
 // TimeParseCall creates a time.Parse call expression
 func TimeParseCall(im ImportManager, layout string, expr ast.Expr) *ast.CallExpr {
 	return Call(im, "", "time", "Parse", String(layout), expr)
 }
+
+// TimeParseDurationCall creates a time.ParseDuration call expression
+func TimeParseDurationCall(im ImportManager, expr ast.Expr) *ast.CallExpr {
+	return Call(im, "", "time", "ParseDuration", expr)
+}
+
+// TimeDateCall creates a time.Date call expression for t in UTC
+func TimeDateCall(im ImportManager, t time.Time) *ast.CallExpr {
+	t = t.UTC()
+	return Call(im, "", "time", "Date",
+		Int(t.Year()), Int(int(t.Month())), Int(t.Day()),
+		Int(t.Hour()), Int(t.Minute()), Int(t.Second()), Int(t.Nanosecond()),
+		ExportedIdentifier(im, "", "time", "UTC"),
+	)
+}
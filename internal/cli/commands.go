@@ -1,7 +1,6 @@
 package cli
 
 import (
-	"bytes"
 	"cmp"
 	_ "embed"
 	"encoding/json"
@@ -9,17 +8,23 @@ import (
 	"fmt"
 	"go/token"
 	"io"
+	"io/fs"
 	"log"
+	"maps"
 	"math"
 	"os"
 	"path/filepath"
 	"regexp"
+	"runtime"
 	"runtime/debug"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/dustin/go-humanize"
 	"github.com/ettle/strcase"
+	"github.com/pmezard/go-difflib/difflib"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 
@@ -100,7 +105,7 @@ func Commands(wd string, args []string, getEnv func(string) string, stdout, stde
 	rootCmd.SetErr(stderr)
 
 	rootCmd.AddCommand(
-		generateCommand(workingDirectory),
+		generateCommand(workingDirectory, getEnv),
 		versionCommand(),
 		checkCommand(workingDirectory),
 		listTemplateCallersCommand(workingDirectory),
@@ -128,10 +133,29 @@ func checkCommand(workingDirectory *string) *cobra.Command {
 	)
 
 	cmd := &cobra.Command{
-		Use:     checkCommandName,
+		Use:     checkCommandName + " [./...]",
 		Aliases: []string{"c"},
 		Short:   "Check templates for errors",
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 0 {
+				if err := checkModulePattern(cmd.Flags(), args, "verbose"); err != nil {
+					return err
+				}
+				cmd.SilenceUsage = true
+				if err := checkModule(*workingDirectory, config.Verbose, log.New(cmd.ErrOrStderr(), "", 0)); err != nil {
+					return fmt.Errorf("fail: %s", err)
+				}
+				return nil
+			}
+			// The configuration file also holds generate flags check
+			// does not have.
+			configFile, err := analysis.LoadConfigFile(*workingDirectory)
+			if err != nil {
+				return err
+			}
+			if _, err := configFile.Apply(cmd.Flags(), true); err != nil {
+				return err
+			}
 			if err := fixTemplateVariables(&config.TemplatesVariables, deprecatedTemplatesVar); err != nil {
 				return err
 			}
@@ -168,6 +192,7 @@ func addGenerateFlags(flagSet *pflag.FlagSet, config *generate.RoutesFileConfigu
 	addUseTemplatesVarToFlagSet(flagSet, &config.TemplatesVariables, deprecatedTemplatesVar)
 	addUseReceiverTypeVarToFlagSet(flagSet, &config.ReceiverType)
 	adUseReceiverTypePackageVarToFlagSet(flagSet, &config.ReceiverPackage)
+	addUseParserVarToFlagSet(flagSet, &config.Parsers)
 	addVerboseFlagToFlagSet(flagSet, &config.Verbose)
 
 	addOutputFlagsToFlagSet(flagSet, config)
@@ -183,53 +208,50 @@ func addGenerateFlagsForModule(flagSet *pflag.FlagSet, config *generate.RoutesFi
 	addGenerateFlags(flagSet, config, &deprecatedTemplatesVar)
 }
 
-func generateCommand(workingDirectory *string) *cobra.Command {
+func generateCommand(workingDirectory *string, getEnv func(string) string) *cobra.Command {
 	var (
 		config                 generate.RoutesFileConfiguration
 		deprecatedTemplatesVar string
+		options                generateOptions
 	)
 
 	cmd := &cobra.Command{
-		Use:     generateCommandName,
+		Use:     generateCommandName + " [./...]",
 		Aliases: []string{"gen", "g"},
 		Short:   "Generate HTTP routes from templates",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if err := fixTemplateVariables(&config.TemplatesVariables, deprecatedTemplatesVar); err != nil {
-				return err
-			}
 			stdout := cmd.OutOrStdout()
-			for _, tv := range config.TemplatesVariables {
-				if tv != "" && !token.IsIdentifier(tv) {
-					return fmt.Errorf("variable %s%s", tv, errIdentSuffix)
+			if len(args) > 0 {
+				if err := checkModulePattern(cmd.Flags(), args, "verbose", generateForce, generateDryRun, generateVerify); err != nil {
+					return err
 				}
+				cmd.SilenceUsage = true
+				return generateModule(*workingDirectory, getEnv, options, config.Verbose, stdout)
 			}
-			if config.RoutesFunction != "" && !token.IsIdentifier(config.RoutesFunction) {
-				return fmt.Errorf(outputRoutesFunc + errIdentSuffix)
-			}
-			if config.ReceiverType != "" && !token.IsIdentifier(config.ReceiverType) {
-				return fmt.Errorf(useReceiverType + errIdentSuffix)
-			}
-			if config.ReceiverInterface != "" && !token.IsIdentifier(config.ReceiverInterface) {
-				return fmt.Errorf(outputReceiverInterface + errIdentSuffix)
-			}
-			if config.TemplateDataType != "" && !token.IsIdentifier(config.TemplateDataType) {
-				return fmt.Errorf(outputTemplateDataType + errIdentSuffix)
-			}
-			if config.SSETemplateDataType != "" && !token.IsIdentifier(config.SSETemplateDataType) {
-				return fmt.Errorf(outputSSETemplateDataType + errIdentSuffix)
+			configFile, err := analysis.LoadConfigFile(*workingDirectory)
+			if err != nil {
+				return err
 			}
-			if config.TemplateRoutePathsTypeName != "" && !token.IsIdentifier(config.TemplateRoutePathsTypeName) {
-				return fmt.Errorf(outputTemplateRoutePathsType + errIdentSuffix)
+			fromFile, err := configFile.Apply(cmd.Flags(), false)
+			if err != nil {
+				return err
 			}
-			if config.OutputFileName != "" && filepath.Ext(config.OutputFileName) != ".go" {
-				return fmt.Errorf("output filename must use .go extension")
+			if err := fixTemplateVariables(&config.TemplatesVariables, deprecatedTemplatesVar); err != nil {
+				return err
 			}
-
-			if v, ok := cliVersion(); ok {
-				config.MuxtVersion = v
+			if err := prepareGenerateConfig(&config, cmd.Flags()); err != nil {
+				return err
 			}
-			applyDefaults(&config, cmd.Flags())
 			cmd.SilenceUsage = true
+			headerArgs := generatedFileArgs(configToArgs(config), fromFile)
+			cache, useCache := generationCache(getEnv, config.MuxtVersion)
+			cacheKey := slices.Concat(headerArgs, []string{"--"}, configToArgs(config))
+			if useCache && !options.force && cache.Unchanged(*workingDirectory, cacheKey) {
+				if config.Verbose {
+					log.New(stdout, "", 0).Printf("generated files are up to date (use --%s to regenerate)", generateForce)
+				}
+				return nil
+			}
 			fileSet, pl, err := asteval.LoadPackages(*workingDirectory, config.ReceiverPackage)
 			if err != nil {
 				return err
@@ -238,86 +260,472 @@ func generateCommand(workingDirectory *string) *cobra.Command {
 			if err != nil {
 				return err
 			}
-
-			// CLEANUP HEURISTIC:
-			// We automatically delete muxt-generated files that are no longer needed to avoid
-			// manual cleanup when template files are renamed or generation modes change.
-			//
-			// Files are identified by:
-			// 1. Presence of "// Code generated by muxt generate" comment
-			// 2. Matching --output-routes-func value (to differentiate multiple route sets)
-			//
-			// Cleanup scenarios:
-			// - Template renamed: old_template_routes_gen.go deleted when template renamed to new.gohtml
-			// - Switch to single-file: all per-file *_template_routes_gen.go files deleted
-			// - Switch to multi-file: old single template_routes.go overwritten (if same filename)
-			// - Routes function unchanged: only deletes files matching current routes function
-			//
-			// IMPORTANT: If you change --output-routes-func value, old files with the previous
-			// routes function name will NOT be deleted (to allow multiple route sets to coexist).
-			// To clean up after changing routes function name, manually delete old files or
-			// temporarily use the old --output-routes-func value with current templates.
-
-			// Find existing generated files for cleanup
-			oldGeneratedFiles, err := generate.FileArguments(*workingDirectory, config.RoutesFunction)
-			if err != nil {
+			if options.dryRun || options.verify {
+				return reportGeneratedFiles(stdout, *workingDirectory, []packageGeneratedFiles{{dir: *workingDirectory, config: config, args: headerArgs, files: files}}, options)
+			}
+			if err := writeGeneratedFiles(*workingDirectory, config, headerArgs, files); err != nil {
 				return err
 			}
 
-			for oldFilePath, oldArgs := range oldGeneratedFiles {
-				var (
-					oldConfig                 generate.RoutesFileConfiguration
-					oldDeprecatedTemplatesVar string
-				)
-				set := pflag.NewFlagSet("parse-old", pflag.ContinueOnError)
-				addGenerateFlags(set, &oldConfig, &oldDeprecatedTemplatesVar)
-				set.SetOutput(io.Discard)
-				if err := set.Parse(oldArgs); err != nil {
-					log.Printf("WARNING: ignored generated file %s because arguments failed to parse: %s", oldFilePath, err)
-					continue
-				}
-				if oldConfig.RoutesFunction != config.RoutesFunction {
-					delete(oldGeneratedFiles, oldFilePath)
-				}
-				if oldDeprecatedTemplatesVar != "" {
-					oldConfig.TemplatesVariables = []string{oldDeprecatedTemplatesVar}
+			// The cache only saves work, so failing to write it does not fail
+			// generation.
+			if useCache {
+				if err := cache.Store(*workingDirectory, cacheKey, pl, files); err != nil && config.Verbose {
+					log.New(stdout, "", 0).Printf("WARNING: failed to update generation cache: %s", err)
 				}
 			}
 
-			// Write new files
-			newGeneratedFiles := make(map[string]bool)
-			for i, file := range files {
-				var sb bytes.Buffer
-				writeCodeGenerationComment(&sb, configToArgs(config))
-				sb.WriteString(file.Content)
-				if err := os.WriteFile(file.Path, sb.Bytes(), 0o644); err != nil {
-					for _, f := range files[:i] {
-						if rmErr := os.Remove(f.Path); rmErr != nil {
-							err = errors.Join(err, rmErr)
-						}
-					}
-					return err
+			return nil
+		},
+	}
+
+	addGenerateFlags(cmd.Flags(), &config, &deprecatedTemplatesVar)
+	cmd.Flags().BoolVar(&options.force, generateForce, false, generateForceHelp)
+	cmd.Flags().BoolVar(&options.dryRun, generateDryRun, false, generateDryRunHelp)
+	cmd.Flags().BoolVar(&options.verify, generateVerify, false, generateVerifyHelp)
+
+	return cmd
+}
+
+// generateOptions are the muxt generate flags that change how the generated
+// files are written rather than what they contain.
+type generateOptions struct {
+	force, dryRun, verify bool
+}
+
+// packageGeneratedFiles are the files TemplateRoutesFiles returned for the
+// package in dir. The generated file headers record args.
+type packageGeneratedFiles struct {
+	dir    string
+	config generate.RoutesFileConfiguration
+	args   []string
+	files  []generate.GeneratedFile
+}
+
+// reportGeneratedFiles handles --dry-run and --verify without writing: with
+// --dry-run it writes the diff for each package to w and with --verify it
+// fails when a generated file is stale. File names are relative to wd.
+func reportGeneratedFiles(w io.Writer, wd string, list []packageGeneratedFiles, options generateOptions) error {
+	diffOutput := io.Discard
+	if options.dryRun {
+		diffOutput = w
+	}
+	var stale []string
+	for _, pkg := range list {
+		names, err := diffGeneratedFiles(diffOutput, wd, pkg.dir, pkg.config, pkg.args, pkg.files)
+		if err != nil {
+			return err
+		}
+		stale = append(stale, names...)
+	}
+	if options.verify && len(stale) > 0 {
+		return fmt.Errorf("generated files are out of date, run muxt generate: %s", strings.Join(stale, ", "))
+	}
+	return nil
+}
+
+// prepareGenerateConfig validates the identifiers in config, sets the muxt
+// version, and applies the defaults for flags not set in flagSet.
+func prepareGenerateConfig(config *generate.RoutesFileConfiguration, flagSet *pflag.FlagSet) error {
+	for _, tv := range config.TemplatesVariables {
+		if tv != "" && !token.IsIdentifier(tv) {
+			return fmt.Errorf("variable %s%s", tv, errIdentSuffix)
+		}
+	}
+	if config.RoutesFunction != "" && !token.IsIdentifier(config.RoutesFunction) {
+		return fmt.Errorf(outputRoutesFunc + errIdentSuffix)
+	}
+	if config.ReceiverType != "" && !token.IsIdentifier(config.ReceiverType) {
+		return fmt.Errorf(useReceiverType + errIdentSuffix)
+	}
+	if config.ReceiverInterface != "" && !token.IsIdentifier(config.ReceiverInterface) {
+		return fmt.Errorf(outputReceiverInterface + errIdentSuffix)
+	}
+	if config.TemplateDataType != "" && !token.IsIdentifier(config.TemplateDataType) {
+		return fmt.Errorf(outputTemplateDataType + errIdentSuffix)
+	}
+	if config.SSETemplateDataType != "" && !token.IsIdentifier(config.SSETemplateDataType) {
+		return fmt.Errorf(outputSSETemplateDataType + errIdentSuffix)
+	}
+	if config.WebSocketTemplateDataType != "" && !token.IsIdentifier(config.WebSocketTemplateDataType) {
+		return fmt.Errorf(outputWebSocketTemplateDataType + errIdentSuffix)
+	}
+	if config.TemplateRoutePathsTypeName != "" && !token.IsIdentifier(config.TemplateRoutePathsTypeName) {
+		return fmt.Errorf(outputTemplateRoutePathsType + errIdentSuffix)
+	}
+	if config.OutputFileName != "" && filepath.Ext(config.OutputFileName) != ".go" {
+		return fmt.Errorf("output filename must use .go extension")
+	}
+
+	if v, ok := cliVersion(); ok {
+		config.MuxtVersion = v
+	}
+	applyDefaults(config, flagSet)
+	return nil
+}
+
+// writeGeneratedFiles writes files with the code generation comment for args
+// and removes generated files in wd the run for config no longer produces.
+func writeGeneratedFiles(wd string, config generate.RoutesFileConfiguration, args []string, files []generate.GeneratedFile) error {
+	orphans, err := orphanedGeneratedFiles(wd, config, files)
+	if err != nil {
+		return err
+	}
+
+	// Write new files
+	files = generatedFileContents(args, files)
+	for i, file := range files {
+		if err := os.WriteFile(file.Path, []byte(file.Content), 0o644); err != nil {
+			for _, f := range files[:i] {
+				if rmErr := os.Remove(f.Path); rmErr != nil {
+					err = errors.Join(err, rmErr)
 				}
-				newGeneratedFiles[file.Path] = true
 			}
+			return err
+		}
+	}
 
-			// Clean up orphaned files
-			// Only deletes files that match the current routes function name but weren't regenerated
-			for oldFile := range oldGeneratedFiles {
-				if !newGeneratedFiles[oldFile] {
-					if err := os.Remove(oldFile); err != nil && !os.IsNotExist(err) {
-						return fmt.Errorf("failed to remove orphaned file %s: %w", oldFile, err)
-					}
-				}
+	// Clean up orphaned files
+	for _, oldFile := range orphans {
+		if err := os.Remove(oldFile); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove orphaned file %s: %w", oldFile, err)
+		}
+	}
+
+	return nil
+}
+
+// generatedFileContents returns files with the code generation comment for
+// args prepended, as writeGeneratedFiles writes them.
+func generatedFileContents(args []string, files []generate.GeneratedFile) []generate.GeneratedFile {
+	result := make([]generate.GeneratedFile, 0, len(files))
+	for _, file := range files {
+		var sb strings.Builder
+		writeCodeGenerationComment(&sb, args)
+		sb.WriteString(file.Content)
+		result = append(result, generate.GeneratedFile{Path: file.Path, Content: sb.String()})
+	}
+	return result
+}
+
+// generatedFileArgs returns the flags in args that were not set from a
+// configuration file. The generated file header records only these, so the
+// header and the configuration file together reproduce the run.
+func generatedFileArgs(args, fromFile []string) []string {
+	var result []string
+	for _, arg := range args {
+		name, _, _ := strings.Cut(strings.TrimPrefix(arg, "--"), "=")
+		if !slices.Contains(fromFile, name) {
+			result = append(result, arg)
+		}
+	}
+	return result
+}
+
+// orphanedGeneratedFiles returns the generated files in wd that writing files
+// for config leaves behind, sorted by path.
+func orphanedGeneratedFiles(wd string, config generate.RoutesFileConfiguration, files []generate.GeneratedFile) ([]string, error) {
+	// CLEANUP HEURISTIC:
+	// We automatically delete muxt-generated files that are no longer needed to avoid
+	// manual cleanup when template files are renamed or generation modes change.
+	//
+	// Files are identified by:
+	// 1. Presence of "// Code generated by muxt generate" comment
+	// 2. Matching --output-routes-func value (to differentiate multiple route sets)
+	//
+	// Cleanup scenarios:
+	// - Template renamed: old_template_routes_gen.go deleted when template renamed to new.gohtml
+	// - Switch to single-file: all per-file *_template_routes_gen.go files deleted
+	// - Switch to multi-file: old single template_routes.go overwritten (if same filename)
+	// - Routes function unchanged: only deletes files matching current routes function
+	//
+	// IMPORTANT: If you change --output-routes-func value, old files with the previous
+	// routes function name will NOT be deleted (to allow multiple route sets to coexist).
+	// To clean up after changing routes function name, manually delete old files or
+	// temporarily use the old --output-routes-func value with current templates.
+
+	// Find existing generated files for cleanup
+	oldGeneratedFiles, err := generate.FileArguments(wd, config.RoutesFunction)
+	if err != nil {
+		return nil, err
+	}
+	// Old headers omit the flags set from the configuration file.
+	configFile, err := analysis.LoadConfigFile(wd)
+	if err != nil {
+		return nil, err
+	}
+
+	for oldFilePath, oldArgs := range oldGeneratedFiles {
+		var (
+			oldConfig                 generate.RoutesFileConfiguration
+			oldDeprecatedTemplatesVar string
+		)
+		set := pflag.NewFlagSet("parse-old", pflag.ContinueOnError)
+		addGenerateFlags(set, &oldConfig, &oldDeprecatedTemplatesVar)
+		set.SetOutput(io.Discard)
+		if err := set.Parse(oldArgs); err != nil {
+			log.Printf("WARNING: ignored generated file %s because arguments failed to parse: %s", oldFilePath, err)
+			continue
+		}
+		if _, err := configFile.Apply(set, true); err != nil {
+			log.Printf("WARNING: ignored generated file %s because the configuration file failed to apply: %s", oldFilePath, err)
+			continue
+		}
+		if oldConfig.RoutesFunction != config.RoutesFunction {
+			delete(oldGeneratedFiles, oldFilePath)
+		}
+	}
+
+	// Only files matching the current routes function name that are not
+	// regenerated are orphaned.
+	for _, file := range files {
+		delete(oldGeneratedFiles, file.Path)
+	}
+	return slices.Sorted(maps.Keys(oldGeneratedFiles)), nil
+}
+
+// diffGeneratedFiles writes a unified diff from the generated files in wd to
+// the files writeGeneratedFiles would write and remove. File names are
+// relative to base. It returns the paths that differ and changes nothing on
+// disk.
+func diffGeneratedFiles(w io.Writer, base, wd string, config generate.RoutesFileConfiguration, args []string, files []generate.GeneratedFile) ([]string, error) {
+	orphans, err := orphanedGeneratedFiles(wd, config, files)
+	if err != nil {
+		return nil, err
+	}
+	changes := generatedFileContents(args, files)
+	for _, orphan := range orphans {
+		changes = append(changes, generate.GeneratedFile{Path: orphan})
+	}
+	var stale []string
+	for i, change := range changes {
+		removed := i >= len(files)
+		old, err := os.ReadFile(change.Path)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return stale, err
+		}
+		if err == nil && !removed && string(old) == change.Content {
+			continue
+		}
+		name, err := filepath.Rel(base, change.Path)
+		if err != nil {
+			return stale, err
+		}
+		name = filepath.ToSlash(name)
+		fromFile, toFile := "a/"+name, "b/"+name
+		if old == nil {
+			fromFile = "/dev/null"
+		}
+		if removed {
+			toFile = "/dev/null"
+		}
+		if err := difflib.WriteUnifiedDiff(w, difflib.UnifiedDiff{
+			A:        diffLines(string(old)),
+			B:        diffLines(change.Content),
+			FromFile: fromFile,
+			ToFile:   toFile,
+			Context:  3,
+		}); err != nil {
+			return stale, err
+		}
+		stale = append(stale, name)
+	}
+	return stale, nil
+}
+
+// diffLines splits s after each newline. Unlike difflib.SplitLines it does
+// not add an empty last line, so a new or removed file has no extra line.
+func diffLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// checkModulePattern accepts ./... as the only package pattern. Each package
+// uses the flags recorded in its generated files, so only the flags named in
+// allowed may be set along with it.
+func checkModulePattern(flagSet *pflag.FlagSet, args []string, allowed ...string) error {
+	if len(args) != 1 || args[0] != modulePattern {
+		return fmt.Errorf("unsupported arguments %q: the only supported package pattern is %s", args, modulePattern)
+	}
+	var err error
+	flagSet.Visit(func(flag *pflag.Flag) {
+		if flag.Name != "change-directory" && !slices.Contains(allowed, flag.Name) {
+			err = errors.Join(err, fmt.Errorf("flag --%s can not be used with %s: each package uses the flags recorded in its generated files", flag.Name, modulePattern))
+		}
+	})
+	return err
+}
+
+// modulePackages returns the muxt packages in the module containing wd that
+// are in wd or one of its subdirectories.
+func modulePackages(wd string) ([]analysis.PackageInfo, error) {
+	module, err := analysis.NewModule(wd, addGenerateFlagsForModule)
+	if err != nil {
+		return nil, err
+	}
+	var list []analysis.PackageInfo
+	for _, pkg := range module.Packages {
+		if rel, err := filepath.Rel(wd, pkg.Dir); err == nil && filepath.IsLocal(rel) {
+			list = append(list, pkg)
+		}
+	}
+	return list, nil
+}
+
+// packageGenerateConfig parses the flags recorded in a package's generated
+// files and its configuration file the way muxt generate parses its command
+// line. It also returns the flags to record in the regenerated files.
+func packageGenerateConfig(pkg analysis.PackageInfo) (generate.RoutesFileConfiguration, []string, error) {
+	var (
+		config                 generate.RoutesFileConfiguration
+		deprecatedTemplatesVar string
+	)
+	flagSet := pflag.NewFlagSet(pkg.Path, pflag.ContinueOnError)
+	flagSet.SetOutput(io.Discard)
+	addGenerateFlags(flagSet, &config, &deprecatedTemplatesVar)
+	if err := flagSet.Parse(pkg.Args); err != nil {
+		return config, nil, err
+	}
+	configFile, err := analysis.LoadConfigFile(pkg.Dir)
+	if err != nil {
+		return config, nil, err
+	}
+	fromFile, err := configFile.Apply(flagSet, false)
+	if err != nil {
+		return config, nil, err
+	}
+	if err := fixTemplateVariables(&config.TemplatesVariables, deprecatedTemplatesVar); err != nil {
+		return config, nil, err
+	}
+	if err := prepareGenerateConfig(&config, flagSet); err != nil {
+		return config, nil, err
+	}
+	return config, generatedFileArgs(configToArgs(config), fromFile), nil
+}
+
+// generateModule runs muxt generate for every muxt package under wd with the
+// flags recorded in its generated files. It loads the packages with one
+// packages.Load call and generates them concurrently.
+func generateModule(wd string, getEnv func(string) string, options generateOptions, verbose bool, stdout io.Writer) error {
+	list, err := modulePackages(wd)
+	if err != nil {
+		return err
+	}
+	logger := log.New(stdout, "", 0)
+	version, _ := cliVersion()
+	cache, useCache := generationCache(getEnv, version)
+
+	type packageGeneration struct {
+		pkg      analysis.PackageInfo
+		config   generate.RoutesFileConfiguration
+		args     []string
+		cacheKey []string
+	}
+	var (
+		generations []packageGeneration
+		patterns    []string
+	)
+	for _, pkg := range list {
+		config, args, err := packageGenerateConfig(pkg)
+		if err != nil {
+			return fmt.Errorf("%s: %w", pkg.Path, err)
+		}
+		config.Verbose = verbose
+		cacheKey := slices.Concat(args, []string{"--"}, configToArgs(config))
+		if useCache && !options.force && cache.Unchanged(pkg.Dir, cacheKey) {
+			if verbose {
+				logger.Printf("%s: generated files are up to date", pkg.Path)
 			}
+			continue
+		}
+		generations = append(generations, packageGeneration{pkg: pkg, config: config, args: args, cacheKey: cacheKey})
+		patterns = append(patterns, pkg.Dir, config.ReceiverPackage)
+	}
+	if len(generations) == 0 {
+		return nil
+	}
 
-			return nil
-		},
+	fileSet, pl, err := asteval.LoadPackages(patterns[0], patterns[1:]...)
+	if err != nil {
+		return err
 	}
+	var (
+		errs    = make([]error, len(generations))
+		results = make([]packageGeneratedFiles, len(generations))
+		wg      sync.WaitGroup
+	)
+	for i, g := range generations {
+		wg.Go(func() {
+			if verbose {
+				logger.Printf("generating %s", g.pkg.Path)
+			}
+			files, err := generate.TemplateRoutesFiles(g.pkg.Dir, g.config, fileSet, pl, logger)
+			results[i] = packageGeneratedFiles{dir: g.pkg.Dir, config: g.config, args: g.args, files: files}
+			if err == nil && !options.dryRun && !options.verify {
+				err = writeGeneratedFiles(g.pkg.Dir, g.config, g.args, files)
+			}
+			if err != nil {
+				errs[i] = fmt.Errorf("%s: %w", g.pkg.Path, err)
+				return
+			}
+			if useCache && !options.dryRun && !options.verify {
+				if err := cache.Store(g.pkg.Dir, g.cacheKey, pl, files); err != nil && verbose {
+					logger.Printf("WARNING: failed to update generation cache for %s: %s", g.pkg.Path, err)
+				}
+			}
+		})
+	}
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+	if options.dryRun || options.verify {
+		// Report in package order after the concurrent generation.
+		return reportGeneratedFiles(stdout, wd, results, options)
+	}
+	return nil
+}
 
-	addGenerateFlags(cmd.Flags(), &config, &deprecatedTemplatesVar)
+// checkModule runs muxt check for every muxt package under wd with the
+// templates variables recorded in its generated files, loading the packages
+// with one packages.Load call and checking them concurrently.
+func checkModule(wd string, verbose bool, logger *log.Logger) error {
+	list, err := modulePackages(wd)
+	if err != nil {
+		return err
+	}
+	if len(list) == 0 {
+		return nil
+	}
+	configs := make([]analysis.CheckConfiguration, len(list))
+	patterns := make([]string, 0, len(list))
+	for i, pkg := range list {
+		config, _, err := packageGenerateConfig(pkg)
+		if err != nil {
+			return fmt.Errorf("%s: %w", pkg.Path, err)
+		}
+		configs[i] = analysis.CheckConfiguration{TemplatesVariables: config.TemplatesVariables, Verbose: verbose}
+		patterns = append(patterns, pkg.Dir)
+	}
 
-	return cmd
+	fileSet, pl, err := asteval.LoadPackages(patterns[0], patterns[1:]...)
+	if err != nil {
+		return err
+	}
+	errs := make([]error, len(list))
+	var wg sync.WaitGroup
+	for i, pkg := range list {
+		wg.Go(func() {
+			if err := analysis.Check(configs[i], pkg.Dir, logger, fileSet, pl); err != nil {
+				errs[i] = fmt.Errorf("%s: %w", pkg.Path, err)
+			}
+		})
+	}
+	wg.Wait()
+	return errors.Join(errs...)
 }
 
 func configToArgs(config generate.RoutesFileConfiguration) []string {
@@ -334,6 +742,9 @@ func configToArgs(config generate.RoutesFileConfiguration) []string {
 	if config.ReceiverPackage != "" {
 		args = append(args, "--"+useReceiverTypePackage+"="+config.ReceiverPackage)
 	}
+	for _, parser := range config.Parsers {
+		args = append(args, "--"+useParser+"="+parser)
+	}
 	if config.OutputFileName != defaultOutputFileName {
 		args = append(args, "--"+outputFile+"="+config.OutputFileName)
 	}
@@ -349,6 +760,9 @@ func configToArgs(config generate.RoutesFileConfiguration) []string {
 	if config.SSETemplateDataType != defaultSSETemplateDataTypeName {
 		args = append(args, "--"+outputSSETemplateDataType+"="+config.SSETemplateDataType)
 	}
+	if config.WebSocketTemplateDataType != defaultWebSocketTemplateDataTypeName {
+		args = append(args, "--"+outputWebSocketTemplateDataType+"="+config.WebSocketTemplateDataType)
+	}
 	if config.TemplateRoutePathsTypeName != defaultTemplateRoutePathsTypeName {
 		args = append(args, "--"+outputTemplateRoutePathsType+"="+config.TemplateRoutePathsTypeName)
 	}
@@ -369,6 +783,21 @@ func configToArgs(config generate.RoutesFileConfiguration) []string {
 	if config.HTMXHelpers {
 		args = append(args, "--"+outputHTMXHelpers)
 	}
+	if config.PanicRecovery {
+		args = append(args, "--"+outputPanicRecovery)
+	}
+	if config.PrerenderStaticRoutes {
+		args = append(args, "--"+outputPrerenderStaticRoutes)
+	}
+	if config.ResponseCache {
+		args = append(args, "--"+outputRoutesFuncWithCacheParam)
+	}
+	if config.RouteTable {
+		args = append(args, "--"+outputRouteTable)
+	}
+	if config.WebSocketAllowCrossOrigin {
+		args = append(args, "--"+outputWebSocketAllowCrossOrigin)
+	}
 
 	// Add output-exported-default-identifiers flag if false (true is the default)
 	if !config.OutputExportedDefaultIdentifiers {
@@ -533,6 +962,43 @@ func versionCommand() *cobra.Command {
 	return cmd
 }
 
+// generationCache returns the cache muxt generate uses to skip unchanged
+// packages. It is stored in the user cache directory (os.UserCacheDir, read
+// through getEnv) and is disabled when that directory is unknown or when
+// MUXTCACHE is "off". A non-empty MUXTCACHE replaces the directory.
+func generationCache(getEnv func(string) string, version string) (generate.GenerationCache, bool) {
+	dir := getEnv("MUXTCACHE")
+	switch dir {
+	case "off":
+		return generate.GenerationCache{}, false
+	case "":
+		switch runtime.GOOS {
+		case "windows":
+			dir = getEnv("LocalAppData")
+		case "darwin", "ios":
+			if home := getEnv("HOME"); home != "" {
+				dir = filepath.Join(home, "Library", "Caches")
+			}
+		case "plan9":
+			if home := getEnv("home"); home != "" {
+				dir = filepath.Join(home, "lib", "cache")
+			}
+		default:
+			dir = getEnv("XDG_CACHE_HOME")
+			if dir == "" {
+				if home := getEnv("HOME"); home != "" {
+					dir = filepath.Join(home, ".cache")
+				}
+			}
+		}
+		if dir == "" || !filepath.IsAbs(dir) {
+			return generate.GenerationCache{}, false
+		}
+		dir = filepath.Join(dir, "muxt", "generate")
+	}
+	return generate.GenerationCache{Dir: dir, Version: version}, true
+}
+
 func cliVersion() (string, bool) {
 	bi, ok := debug.ReadBuildInfo()
 	if !ok || bi.Main.Version == "" {
@@ -546,11 +1012,13 @@ const (
 	useTemplatesVariable                = "use-templates-variable"
 	useReceiverType                     = "use-receiver-type"
 	useReceiverTypePackage              = "use-receiver-type-package"
+	useParser                           = "use-parser"
 	outputFile                          = "output-file"
 	outputReceiverInterface             = "output-receiver-interface"
 	outputRoutesFunc                    = "output-routes-func"
 	outputTemplateDataType              = "output-template-data-type"
 	outputSSETemplateDataType           = "output-sse-template-data-type"
+	outputWebSocketTemplateDataType     = "output-websocket-template-data-type"
 	outputTemplateRoutePathsType        = "output-template-route-paths-type"
 	outputRoutesFuncWithLoggerParam     = "output-routes-func-with-logger-param"
 	outputRoutesFuncWithPathPrefix      = "output-routes-func-with-path-prefix-param"
@@ -559,6 +1027,15 @@ const (
 	outputHTMXHelpers                   = "output-htmx-helpers"
 	outputExportedDefaultIdentifiers    = "output-exported-default-identifiers"
 	outputMultipartMaxMemory            = "output-multipart-max-memory"
+	outputPanicRecovery                 = "output-panic-recovery"
+	outputPrerenderStaticRoutes         = "output-prerender-static-routes"
+	outputRoutesFuncWithCacheParam      = "output-routes-func-with-cache-param"
+	outputRouteTable                    = "output-route-table"
+	outputWebSocketAllowCrossOrigin     = "output-websocket-allow-cross-origin"
+	generateForce                       = "force"
+	generateDryRun                      = "dry-run"
+	generateVerify                      = "verify"
+	modulePattern                       = "./..."
 
 	// Deprecated feature flag names
 	deprecatedPathPrefix = "path-prefix"
@@ -580,14 +1057,17 @@ const (
 	useTemplatesVariableHelp   = `the name of the global variable with type *"html/template".Template in the working directory package.`
 	useReceiverTypeHelp        = `The type name for a named type to use for looking up method signatures. If not set, all methods added to the receiver interface will have inferred signatures with argument types based on the argument identifier names. The inferred method signatures always return a single result of type any.`
 	useReceiverTypePackageHelp = `The package path to use when looking for use-receiver-type. If not set, the package in the current directory is used.`
+	useParserHelp              = `A function with signature func(string) (T, error) used to parse path values, lastEventID, and form fields of type T (can specify multiple).
+Use "Func" for a function in the current directory package or "import/path.Func" for one in another package imported by it. A parser takes precedence over UnmarshalText and strconv parsing.`
 
 	outputFileHelp              = `The generated file name containing the routes function and receiver interface.`
 	outputReceiverInterfaceHelp = `The interface name in the generated output file listing the methods used by handler routes in the routes function.`
 	outputRoutesFuncHelp        = `The function name for the package registering handler functions on an *"net/http".ServeMux.
 This function also receives an argument with a type matching the name given by output-receiver-interface.`
-	outputTemplateDataTypeHelp       = `The type name for the template data passed to root route templates.`
-	outputSSETemplateDataTypeHelp    = `The type name for the template data passed to Server-Sent Events route templates.`
-	outputTemplateRoutePathsTypeHelp = `The type name for the type with path constructor helper methods.`
+	outputTemplateDataTypeHelp          = `The type name for the template data passed to root route templates.`
+	outputSSETemplateDataTypeHelp       = `The type name for the template data passed to Server-Sent Events route templates.`
+	outputWebSocketTemplateDataTypeHelp = `The type name for the template data passed to WebSocket route templates.`
+	outputTemplateRoutePathsTypeHelp    = `The type name for the type with path constructor helper methods.`
 
 	outputRoutesFuncWithLoggerParamHelp     = `Adds a *slog.Logger parameter to the generated routes function and uses it to log ExecuteTemplate errors and debug information in handlers.`
 	outputRoutesFuncWithPathPrefixHelp      = `Adds a pathPrefix string parameter to the generated routes function and uses it in each path generator method.`
@@ -595,20 +1075,29 @@ This function also receives an argument with a type matching the name given by o
 	outputMultipleFilesHelp                 = `Split generated routes into separate files per template source file. By default, all routes are written to a single file.`
 	outputHTMXHelpersHelp                   = `Adds HTMX helper methods to TemplateData for setting response headers (HX-Location, HX-Redirect, etc.) and reading request headers (HX-Request, HX-Boosted, etc.).`
 	outputExportedDefaultIdentifiersHelp    = `When false, default generated identifiers (functions, types, interfaces) use lowercase/private names. Does not affect explicit --output-* flag values. Defaults to true.`
+	outputPanicRecoveryHelp                 = `Recovers panics in generated handlers. A recovered panic is logged with the route pattern (through the logger parameter when output-routes-func-with-logger-param is set) and the route template renders with the panic in .Err and status 500.`
+	generateDryRunHelp                      = `Print a unified diff from the generated files on disk to the files muxt generate would write, including orphaned files it would remove, without changing any file.`
+	generateVerifyHelp                      = `Exit with an error listing the generated files that are out of date without changing any file. Combine with --dry-run to also print the diff.`
+	generateForceHelp                       = `Regenerate even when the generation cache reports that no template, Go file, or flag changed since the last run.`
+	outputRoutesFuncWithCacheParamHelp      = `Adds a ResponseCache parameter to the generated routes function and declares ResponseCache, CachedResponse, and NewLRUResponseCache. GET handlers whose result has a CacheKey() string method (and optionally TTL() time.Duration) store the rendered status, headers, and body and skip rendering on a hit. A nil cache disables caching.`
+	outputRouteTableHelp                    = `Declares an exported handler constructor per route (named after its TemplateRoutePaths method with a Handler suffix), a Route type, and a function named after the routes function with a Table suffix returning a Route (pattern, method, path, identifier, source file, and handler) for each route. The routes function then accepts any RouteMux, an interface with a Handle(pattern string, handler http.Handler) method. The patterns are Go 1.22 http.ServeMux patterns and the handlers read path parameters with request.PathValue, so the RouteMux must be an http.ServeMux or behave like one.`
+	outputPrerenderStaticRoutesHelp         = `Renders call-less GET routes whose templates do not read request dependent TemplateData (.Request, .Form, .Receiver, ...) once when the routes function runs, and serves the bytes with an ETag.`
+	outputWebSocketAllowCrossOriginHelp     = `Upgrades ws(...) routes without checking the Origin header. By default the handshake responds 403 when the Origin host does not match the request host, which prevents cross-site WebSocket hijacking; set this flag only when non-browser clients or other origins must connect and the receiver authenticates them.`
 	outputMultipartMaxMemoryHelp            = `Maximum memory used by request.ParseMultipartForm in generated handlers. Accepts a human-readable byte size (e.g. 32MB, 64MiB, 1GB).`
 
 	errIdentSuffix = " value must be a well-formed Go identifier"
 )
 
 const (
-	defaultTemplatesVariableName      = "templates"
-	defaultRoutesFunctionName         = generate.DefaultRoutesFunctionName
-	defaultOutputFileName             = "template_routes.go"
-	defaultReceiverInterfaceName      = generate.DefaultReceiverInterfaceName
-	defaultTemplateRoutePathsTypeName = generate.DefaultTemplateRoutePathsTypeName
-	defaultTemplateDataTypeName       = "TemplateData"
-	defaultSSETemplateDataTypeName    = "SSETemplateData"
-	defaultPackageName                = "main"
+	defaultTemplatesVariableName         = "templates"
+	defaultRoutesFunctionName            = generate.DefaultRoutesFunctionName
+	defaultOutputFileName                = "template_routes.go"
+	defaultReceiverInterfaceName         = generate.DefaultReceiverInterfaceName
+	defaultTemplateRoutePathsTypeName    = generate.DefaultTemplateRoutePathsTypeName
+	defaultTemplateDataTypeName          = "TemplateData"
+	defaultSSETemplateDataTypeName       = "SSETemplateData"
+	defaultWebSocketTemplateDataTypeName = "WebSocketTemplateData"
+	defaultPackageName                   = "main"
 )
 
 func isDefaultTemplatesVariable(in *[]string) bool {
@@ -632,6 +1121,9 @@ func applyDefaults(config *generate.RoutesFileConfiguration, flagSet *pflag.Flag
 		if !flagSet.Changed(outputSSETemplateDataType) {
 			config.SSETemplateDataType = strcase.ToGoCamel(defaultSSETemplateDataTypeName)
 		}
+		if !flagSet.Changed(outputWebSocketTemplateDataType) {
+			config.WebSocketTemplateDataType = strcase.ToGoCamel(defaultWebSocketTemplateDataTypeName)
+		}
 		if !flagSet.Changed(outputTemplateRoutePathsType) {
 			config.TemplateRoutePathsTypeName = strcase.ToGoCamel(defaultTemplateRoutePathsTypeName)
 		}
@@ -641,6 +1133,7 @@ func applyDefaults(config *generate.RoutesFileConfiguration, flagSet *pflag.Flag
 		config.ReceiverInterface = cmp.Or(config.ReceiverInterface, defaultReceiverInterfaceName)
 		config.TemplateDataType = cmp.Or(config.TemplateDataType, defaultTemplateDataTypeName)
 		config.SSETemplateDataType = cmp.Or(config.SSETemplateDataType, defaultSSETemplateDataTypeName)
+		config.WebSocketTemplateDataType = cmp.Or(config.WebSocketTemplateDataType, defaultWebSocketTemplateDataTypeName)
 		config.TemplateRoutePathsTypeName = cmp.Or(config.TemplateRoutePathsTypeName, defaultTemplateRoutePathsTypeName)
 	}
 }
@@ -661,12 +1154,17 @@ func adUseReceiverTypePackageVarToFlagSet(flagSet *pflag.FlagSet, out *string) {
 	flagSet.StringVar(out, useReceiverTypePackage, "", useReceiverTypePackageHelp)
 }
 
+func addUseParserVarToFlagSet(flagSet *pflag.FlagSet, out *[]string) {
+	flagSet.StringArrayVar(out, useParser, nil, useParserHelp)
+}
+
 func addOutputFlagsToFlagSet(flagSet *pflag.FlagSet, g *generate.RoutesFileConfiguration) {
 	flagSet.StringVar(&g.OutputFileName, outputFile, defaultOutputFileName, outputFileHelp)
 	flagSet.StringVar(&g.ReceiverInterface, outputReceiverInterface, defaultReceiverInterfaceName, outputReceiverInterfaceHelp)
 	flagSet.StringVar(&g.RoutesFunction, outputRoutesFunc, defaultRoutesFunctionName, outputRoutesFuncHelp)
 	flagSet.StringVar(&g.TemplateDataType, outputTemplateDataType, defaultTemplateDataTypeName, outputTemplateDataTypeHelp)
 	flagSet.StringVar(&g.SSETemplateDataType, outputSSETemplateDataType, defaultSSETemplateDataTypeName, outputSSETemplateDataTypeHelp)
+	flagSet.StringVar(&g.WebSocketTemplateDataType, outputWebSocketTemplateDataType, defaultWebSocketTemplateDataTypeName, outputWebSocketTemplateDataTypeHelp)
 	flagSet.StringVar(&g.TemplateRoutePathsTypeName, outputTemplateRoutePathsType, defaultTemplateRoutePathsTypeName, outputTemplateRoutePathsTypeHelp)
 	flagSet.BoolVar(&g.Logger, outputRoutesFuncWithLoggerParam, false, outputRoutesFuncWithLoggerParamHelp)
 	flagSet.BoolVar(&g.PathPrefix, outputRoutesFuncWithPathPrefix, false, outputRoutesFuncWithPathPrefixHelp)
@@ -675,6 +1173,11 @@ func addOutputFlagsToFlagSet(flagSet *pflag.FlagSet, g *generate.RoutesFileConfi
 	flagSet.BoolVar(&g.HTMXHelpers, outputHTMXHelpers, false, outputHTMXHelpersHelp)
 	flagSet.BoolVar(&g.OutputExportedDefaultIdentifiers, outputExportedDefaultIdentifiers, true, outputExportedDefaultIdentifiersHelp)
 	flagSet.Var(&multipartMaxMemoryFlag{cfg: g}, outputMultipartMaxMemory, outputMultipartMaxMemoryHelp)
+	flagSet.BoolVar(&g.PanicRecovery, outputPanicRecovery, false, outputPanicRecoveryHelp)
+	flagSet.BoolVar(&g.PrerenderStaticRoutes, outputPrerenderStaticRoutes, false, outputPrerenderStaticRoutesHelp)
+	flagSet.BoolVar(&g.ResponseCache, outputRoutesFuncWithCacheParam, false, outputRoutesFuncWithCacheParamHelp)
+	flagSet.BoolVar(&g.RouteTable, outputRouteTable, false, outputRouteTableHelp)
+	flagSet.BoolVar(&g.WebSocketAllowCrossOrigin, outputWebSocketAllowCrossOrigin, false, outputWebSocketAllowCrossOriginHelp)
 }
 
 // multipartMaxMemoryFlag implements pflag.Value to parse human-readable byte
@@ -0,0 +1,474 @@
+package generate
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"net/http"
+
+	"github.com/typelate/muxt/internal/astgen"
+	"github.com/typelate/muxt/internal/muxt"
+)
+
+const (
+	responseCacheParamName      = "cache"
+	responseCacheTypeName       = "ResponseCache"
+	cachedResponseTypeName      = "CachedResponse"
+	lruResponseCacheTypeName    = "LRUResponseCache"
+	lruResponseCacheEntryName   = "lruResponseCacheEntry"
+	newLRUResponseCacheFuncName = "NewLRUResponseCache"
+	responseCacheHeaderFuncName = "responseCacheHeader"
+	responseCacheableFuncName   = "responseCacheable"
+
+	cacheKeyIdent    = "cacheKey"
+	cacheHeaderIdent = "cacheHeader"
+)
+
+func responseCacheField() *ast.Field {
+	return &ast.Field{Names: []*ast.Ident{ast.NewIdent(responseCacheParamName)}, Type: ast.NewIdent(responseCacheTypeName)}
+}
+
+// responseCacheKey returns the cache key and TTL expressions for a GET route
+// whose result has a CacheKey() string method (and optionally a
+// TTL() time.Duration method). It reports false when the route is not cached.
+// A template reading request data (.Request, .FormValue, $.Request, ...) is
+// not cached since the key only covers the result, so one visitor's page
+// would be served to another.
+func responseCacheKey(file *File, config RoutesFileConfiguration, def muxt.Definition, resultType types.Type, rdIdent string) (ast.Expr, ast.Expr, bool) {
+	if !config.ResponseCache || def.HTTPMethod() != http.MethodGet || def.HasResponseWriterArg() || def.ResultSequence() != muxt.ResultSequenceNone || def.ReadsRequestData() {
+		return nil, nil, false
+	}
+	result := func() ast.Expr {
+		return &ast.SelectorExpr{X: ast.NewIdent(rdIdent), Sel: ast.NewIdent(TemplateDataFieldIdentifierResult)}
+	}
+	key, ok := resultMember(file, resultType, "CacheKey", result, isStringType)
+	if !ok {
+		return nil, nil, false
+	}
+	ttl, ok := resultMember(file, resultType, "TTL", result, func(tp types.Type) bool {
+		return muxt.IsNamedType(tp, "time", "Duration")
+	})
+	if !ok {
+		ttl = astgen.Int(0)
+	}
+	return &ast.BinaryExpr{X: astgen.String(def.Pattern() + " "), Op: token.ADD, Y: key}, ttl, true
+}
+
+// cacheLookupStatements serves a cached response after the method call and
+// skips rendering:
+//
+//	var cacheKey string
+//	var cacheHeader http.Header
+//	if cache != nil && len(td.errList) == 0 {
+//		cacheKey = "GET /pattern " + td.result.CacheKey()
+//		cacheHeader = response.Header().Clone()
+//		if cached, ok := cache.Get(cacheKey); ok {
+//			maps.Copy(response.Header(), cached.Header.Clone())
+//			td.writeResponse(bytes.NewBuffer(cached.Body), cached.StatusCode)
+//			return
+//		}
+//	}
+//
+// The key is prefixed with the route pattern so two routes with the same
+// result type do not share entries. cacheHeader holds the headers set before
+// the handler ran (by middleware, for example) so they are not stored.
+func cacheLookupStatements(file *File, key ast.Expr, rdIdent string) []ast.Stmt {
+	const (
+		cachedIdent = "cached"
+		okIdent     = "ok"
+	)
+	cached := func(name string) ast.Expr {
+		return &ast.SelectorExpr{X: ast.NewIdent(cachedIdent), Sel: ast.NewIdent(name)}
+	}
+	return []ast.Stmt{
+		&ast.DeclStmt{Decl: &ast.GenDecl{Tok: token.VAR, Specs: []ast.Spec{&ast.ValueSpec{
+			Names: []*ast.Ident{ast.NewIdent(cacheKeyIdent)},
+			Type:  ast.NewIdent("string"),
+		}}}},
+		&ast.DeclStmt{Decl: &ast.GenDecl{Tok: token.VAR, Specs: []ast.Spec{&ast.ValueSpec{
+			Names: []*ast.Ident{ast.NewIdent(cacheHeaderIdent)},
+			Type:  astgen.HTTPHeader(file),
+		}}}},
+		&ast.IfStmt{
+			Cond: &ast.BinaryExpr{
+				X:  &ast.BinaryExpr{X: ast.NewIdent(responseCacheParamName), Op: token.NEQ, Y: astgen.Nil()},
+				Op: token.LAND,
+				Y: &ast.BinaryExpr{
+					X:  astgen.CallBuiltinLen(&ast.SelectorExpr{X: ast.NewIdent(rdIdent), Sel: ast.NewIdent(TemplateDataFieldIdentifierError)}),
+					Op: token.EQL,
+					Y:  astgen.Int(0),
+				},
+			},
+			Body: &ast.BlockStmt{List: []ast.Stmt{
+				singleAssignment(token.ASSIGN, ast.NewIdent(cacheKeyIdent))(key),
+				singleAssignment(token.ASSIGN, ast.NewIdent(cacheHeaderIdent))(methodCall(methodCall(ast.NewIdent(muxt.TemplateNameScopeIdentifierHTTPResponse), "Header"), "Clone")),
+				&ast.IfStmt{
+					Init: &ast.AssignStmt{
+						Lhs: []ast.Expr{ast.NewIdent(cachedIdent), ast.NewIdent(okIdent)},
+						Tok: token.DEFINE,
+						Rhs: []ast.Expr{methodCall(ast.NewIdent(responseCacheParamName), "Get", ast.NewIdent(cacheKeyIdent))},
+					},
+					Cond: ast.NewIdent(okIdent),
+					Body: &ast.BlockStmt{List: []ast.Stmt{
+						&ast.ExprStmt{X: astgen.Call(file, "", "maps", "Copy",
+							methodCall(ast.NewIdent(muxt.TemplateNameScopeIdentifierHTTPResponse), "Header"),
+							methodCall(cached("Header"), "Clone"),
+						)},
+						&ast.ExprStmt{X: methodCall(ast.NewIdent(rdIdent), templateDataWriteResponseMethodName,
+							astgen.BytesNewBuffer(file, cached("Body")),
+							cached("StatusCode"),
+						)},
+						&ast.ReturnStmt{},
+					}},
+				},
+			}},
+		},
+	}
+}
+
+// cacheStoreStatement stores the rendered response before it is written:
+//
+//	if cacheKey != "" && responseCacheable(response.Header()) {
+//		cache.Set(cacheKey, CachedResponse{StatusCode: statusCode, Header: responseCacheHeader(cacheHeader, response.Header()), Body: bytes.Clone(buf.Bytes())}, td.result.TTL())
+//	}
+//
+// Only the headers the result and template set are stored; see
+// responseCacheDecls for the helpers.
+func cacheStoreStatement(file *File, ttl ast.Expr, bufIdent, statusCodeIdent string) ast.Stmt {
+	header := func() ast.Expr {
+		return methodCall(ast.NewIdent(muxt.TemplateNameScopeIdentifierHTTPResponse), "Header")
+	}
+	return &ast.IfStmt{
+		Cond: &ast.BinaryExpr{
+			X:  &ast.BinaryExpr{X: ast.NewIdent(cacheKeyIdent), Op: token.NEQ, Y: astgen.String("")},
+			Op: token.LAND,
+			Y:  &ast.CallExpr{Fun: ast.NewIdent(responseCacheableFuncName), Args: []ast.Expr{header()}},
+		},
+		Body: &ast.BlockStmt{List: []ast.Stmt{&ast.ExprStmt{X: methodCall(ast.NewIdent(responseCacheParamName), "Set",
+			ast.NewIdent(cacheKeyIdent),
+			&ast.CompositeLit{Type: ast.NewIdent(cachedResponseTypeName), Elts: []ast.Expr{
+				&ast.KeyValueExpr{Key: ast.NewIdent("StatusCode"), Value: ast.NewIdent(statusCodeIdent)},
+				&ast.KeyValueExpr{Key: ast.NewIdent("Header"), Value: &ast.CallExpr{Fun: ast.NewIdent(responseCacheHeaderFuncName), Args: []ast.Expr{ast.NewIdent(cacheHeaderIdent), header()}}},
+				&ast.KeyValueExpr{Key: ast.NewIdent("Body"), Value: astgen.Call(file, "", "bytes", "Clone", methodCall(ast.NewIdent(bufIdent), "Bytes"))},
+			}},
+			ttl,
+		)}}},
+	}
+}
+
+// responseCacheDecls declares the ResponseCache interface passed to the
+// routes function, the CachedResponse it stores, and an in-memory LRU
+// implementation:
+//
+//	type ResponseCache interface {
+//		Get(key string) (CachedResponse, bool)
+//		Set(key string, response CachedResponse, ttl time.Duration)
+//	}
+//
+//	type CachedResponse struct {
+//		StatusCode int
+//		Header     http.Header
+//		Body       []byte
+//	}
+//
+//	func NewLRUResponseCache(size int) *LRUResponseCache
+//
+// A zero ttl means the entry does not expire; the LRU evicts the least
+// recently used entry once it holds more than size entries.
+//
+// It also declares the helpers cacheStoreStatement calls. responseCacheHeader
+// keeps the headers that changed after cacheHeader was cloned, so headers set
+// by middleware (a Vary, a request ID, a CSRF token) are never replayed to
+// another visitor. responseCacheable reports false for a response that sets a
+// cookie or has a Cache-Control private or no-store directive:
+//
+//	func responseCacheHeader(before, after http.Header) http.Header {
+//		header := make(http.Header)
+//		for key, values := range after {
+//			if !slices.Equal(before[key], values) {
+//				header[key] = slices.Clone(values)
+//			}
+//		}
+//		return header
+//	}
+//
+//	func responseCacheable(header http.Header) bool {
+//		if header.Get("set-cookie") != "" {
+//			return false
+//		}
+//		for _, value := range header.Values("cache-control") {
+//			for _, directive := range strings.Split(value, ",") {
+//				name, _, _ := strings.Cut(strings.TrimSpace(directive), "=")
+//				switch strings.ToLower(name) {
+//				case "private", "no-store":
+//					return false
+//				}
+//			}
+//		}
+//		return true
+//	}
+func responseCacheDecls(file *File) []ast.Decl {
+	const (
+		receiverName  = "cache"
+		keyIdent      = "key"
+		responseIdent = "response"
+		ttlIdent      = "ttl"
+		sizeIdent     = "size"
+		elementIdent  = "element"
+		entryIdent    = "entry"
+		oldestIdent   = "oldest"
+		mutexField    = "mutex"
+		sizeField     = "size"
+		entriesField  = "entries"
+		orderField    = "order"
+		expiresField  = "expires"
+	)
+	field := func(name string, tp ast.Expr) *ast.Field {
+		return &ast.Field{Names: []*ast.Ident{ast.NewIdent(name)}, Type: tp}
+	}
+	recv := func(name string) ast.Expr {
+		return &ast.SelectorExpr{X: ast.NewIdent(receiverName), Sel: ast.NewIdent(name)}
+	}
+	typeDecl := func(name string, tp ast.Expr) ast.Decl {
+		return &ast.GenDecl{Tok: token.TYPE, Specs: []ast.Spec{&ast.TypeSpec{Name: ast.NewIdent(name), Type: tp}}}
+	}
+	listElement := func() ast.Expr {
+		return &ast.StarExpr{X: astgen.ExportedIdentifier(file, "", "container/list", "Element")}
+	}
+	duration := func() ast.Expr { return astgen.ExportedIdentifier(file, "", "time", "Duration") }
+	entryPtr := func() ast.Expr { return &ast.StarExpr{X: ast.NewIdent(lruResponseCacheEntryName)} }
+	getParams := &ast.FieldList{List: []*ast.Field{field(keyIdent, ast.NewIdent("string"))}}
+	getResults := &ast.FieldList{List: []*ast.Field{{Type: ast.NewIdent(cachedResponseTypeName)}, {Type: ast.NewIdent("bool")}}}
+	setParams := &ast.FieldList{List: []*ast.Field{
+		field(keyIdent, ast.NewIdent("string")),
+		field(responseIdent, ast.NewIdent(cachedResponseTypeName)),
+		field(ttlIdent, duration()),
+	}}
+	method := func(name string, params, results *ast.FieldList, body []ast.Stmt) ast.Decl {
+		return &ast.FuncDecl{
+			Recv: &ast.FieldList{List: []*ast.Field{field(receiverName, &ast.StarExpr{X: ast.NewIdent(lruResponseCacheTypeName)})}},
+			Name: ast.NewIdent(name),
+			Type: &ast.FuncType{Params: params, Results: results},
+			Body: &ast.BlockStmt{List: body},
+		}
+	}
+	lockStatements := func() []ast.Stmt {
+		return []ast.Stmt{
+			&ast.ExprStmt{X: methodCall(recv(mutexField), "Lock")},
+			&ast.DeferStmt{Call: methodCall(recv(mutexField), "Unlock")},
+		}
+	}
+	lookupElement := &ast.AssignStmt{
+		Lhs: []ast.Expr{ast.NewIdent(elementIdent), ast.NewIdent("ok")},
+		Tok: token.DEFINE,
+		Rhs: []ast.Expr{&ast.IndexExpr{X: recv(entriesField), Index: ast.NewIdent(keyIdent)}},
+	}
+	miss := func() ast.Stmt {
+		return &ast.ReturnStmt{Results: []ast.Expr{&ast.CompositeLit{Type: ast.NewIdent(cachedResponseTypeName)}, astgen.Bool(false)}}
+	}
+	entryField := func(name string) ast.Expr {
+		return &ast.SelectorExpr{X: ast.NewIdent(entryIdent), Sel: ast.NewIdent(name)}
+	}
+	elementEntry := func(element ast.Expr) ast.Expr {
+		return &ast.TypeAssertExpr{X: &ast.SelectorExpr{X: element, Sel: ast.NewIdent("Value")}, Type: entryPtr()}
+	}
+
+	getBody := append(lockStatements(),
+		lookupElement,
+		&ast.IfStmt{Cond: &ast.UnaryExpr{Op: token.NOT, X: ast.NewIdent("ok")}, Body: &ast.BlockStmt{List: []ast.Stmt{miss()}}},
+		singleAssignment(token.DEFINE, ast.NewIdent(entryIdent))(elementEntry(ast.NewIdent(elementIdent))),
+		&ast.IfStmt{
+			Cond: &ast.BinaryExpr{
+				X:  &ast.UnaryExpr{Op: token.NOT, X: methodCall(entryField(expiresField), "IsZero")},
+				Op: token.LAND,
+				Y:  methodCall(astgen.Call(file, "", "time", "Now"), "After", entryField(expiresField)),
+			},
+			Body: &ast.BlockStmt{List: []ast.Stmt{
+				&ast.ExprStmt{X: methodCall(recv(orderField), "Remove", ast.NewIdent(elementIdent))},
+				&ast.ExprStmt{X: astgen.CallBuiltin("delete", recv(entriesField), ast.NewIdent(keyIdent))},
+				miss(),
+			}},
+		},
+		&ast.ExprStmt{X: methodCall(recv(orderField), "MoveToFront", ast.NewIdent(elementIdent))},
+		&ast.ReturnStmt{Results: []ast.Expr{entryField(responseIdent), astgen.Bool(true)}},
+	)
+
+	setBody := []ast.Stmt{
+		singleAssignment(token.DEFINE, ast.NewIdent(entryIdent))(&ast.UnaryExpr{Op: token.AND, X: &ast.CompositeLit{
+			Type: ast.NewIdent(lruResponseCacheEntryName),
+			Elts: []ast.Expr{
+				&ast.KeyValueExpr{Key: ast.NewIdent(keyIdent), Value: ast.NewIdent(keyIdent)},
+				&ast.KeyValueExpr{Key: ast.NewIdent(responseIdent), Value: ast.NewIdent(responseIdent)},
+			},
+		}}),
+		&ast.IfStmt{
+			Cond: &ast.BinaryExpr{X: ast.NewIdent(ttlIdent), Op: token.GTR, Y: astgen.Int(0)},
+			Body: &ast.BlockStmt{List: []ast.Stmt{singleAssignment(token.ASSIGN, entryField(expiresField))(
+				methodCall(astgen.Call(file, "", "time", "Now"), "Add", ast.NewIdent(ttlIdent)),
+			)}},
+		},
+	}
+	setBody = append(setBody, lockStatements()...)
+	setBody = append(setBody,
+		&ast.IfStmt{
+			Init: lookupElement,
+			Cond: ast.NewIdent("ok"),
+			Body: &ast.BlockStmt{List: []ast.Stmt{
+				singleAssignment(token.ASSIGN, &ast.SelectorExpr{X: ast.NewIdent(elementIdent), Sel: ast.NewIdent("Value")})(ast.NewIdent(entryIdent)),
+				&ast.ExprStmt{X: methodCall(recv(orderField), "MoveToFront", ast.NewIdent(elementIdent))},
+				&ast.ReturnStmt{},
+			}},
+		},
+		singleAssignment(token.ASSIGN, &ast.IndexExpr{X: recv(entriesField), Index: ast.NewIdent(keyIdent)})(
+			methodCall(recv(orderField), "PushFront", ast.NewIdent(entryIdent)),
+		),
+		&ast.ForStmt{
+			Cond: &ast.BinaryExpr{X: methodCall(recv(orderField), "Len"), Op: token.GTR, Y: recv(sizeField)},
+			Body: &ast.BlockStmt{List: []ast.Stmt{
+				singleAssignment(token.DEFINE, ast.NewIdent(oldestIdent))(methodCall(recv(orderField), "Back")),
+				&ast.ExprStmt{X: methodCall(recv(orderField), "Remove", ast.NewIdent(oldestIdent))},
+				&ast.ExprStmt{X: astgen.CallBuiltin("delete", recv(entriesField), &ast.SelectorExpr{X: elementEntry(ast.NewIdent(oldestIdent)), Sel: ast.NewIdent(keyIdent)})},
+			}},
+		},
+	)
+
+	return []ast.Decl{
+		typeDecl(responseCacheTypeName, &ast.InterfaceType{Methods: &ast.FieldList{List: []*ast.Field{
+			{Names: []*ast.Ident{ast.NewIdent("Get")}, Type: &ast.FuncType{Params: getParams, Results: getResults}},
+			{Names: []*ast.Ident{ast.NewIdent("Set")}, Type: &ast.FuncType{Params: setParams}},
+		}}}),
+		typeDecl(cachedResponseTypeName, &ast.StructType{Fields: &ast.FieldList{List: []*ast.Field{
+			field("StatusCode", ast.NewIdent("int")),
+			field("Header", astgen.HTTPHeader(file)),
+			field("Body", &ast.ArrayType{Elt: ast.NewIdent("byte")}),
+		}}}),
+		typeDecl(lruResponseCacheTypeName, &ast.StructType{Fields: &ast.FieldList{List: []*ast.Field{
+			field(mutexField, astgen.ExportedIdentifier(file, "", "sync", "Mutex")),
+			field(sizeField, ast.NewIdent("int")),
+			field(entriesField, &ast.MapType{Key: ast.NewIdent("string"), Value: listElement()}),
+			field(orderField, &ast.StarExpr{X: astgen.ExportedIdentifier(file, "", "container/list", "List")}),
+		}}}),
+		typeDecl(lruResponseCacheEntryName, &ast.StructType{Fields: &ast.FieldList{List: []*ast.Field{
+			field(keyIdent, ast.NewIdent("string")),
+			field(responseIdent, ast.NewIdent(cachedResponseTypeName)),
+			field(expiresField, astgen.ExportedIdentifier(file, "", "time", "Time")),
+		}}}),
+		&ast.FuncDecl{
+			Name: ast.NewIdent(newLRUResponseCacheFuncName),
+			Type: &ast.FuncType{
+				Params:  &ast.FieldList{List: []*ast.Field{field(sizeIdent, ast.NewIdent("int"))}},
+				Results: &ast.FieldList{List: []*ast.Field{{Type: &ast.StarExpr{X: ast.NewIdent(lruResponseCacheTypeName)}}}},
+			},
+			Body: &ast.BlockStmt{List: []ast.Stmt{&ast.ReturnStmt{Results: []ast.Expr{&ast.UnaryExpr{Op: token.AND, X: &ast.CompositeLit{
+				Type: ast.NewIdent(lruResponseCacheTypeName),
+				Elts: []ast.Expr{
+					&ast.KeyValueExpr{Key: ast.NewIdent(sizeField), Value: ast.NewIdent(sizeIdent)},
+					&ast.KeyValueExpr{Key: ast.NewIdent(entriesField), Value: astgen.CallBuiltin("make", &ast.MapType{Key: ast.NewIdent("string"), Value: listElement()})},
+					&ast.KeyValueExpr{Key: ast.NewIdent(orderField), Value: astgen.Call(file, "", "container/list", "New")},
+				},
+			}}}}}},
+		},
+		method("Get", getParams, getResults, getBody),
+		method("Set", setParams, nil, setBody),
+		responseCacheHeaderFunc(file),
+		responseCacheableFunc(file),
+	}
+}
+
+func responseCacheHeaderFunc(file *File) *ast.FuncDecl {
+	const (
+		beforeIdent = "before"
+		afterIdent  = "after"
+		headerIdent = "header"
+		keyIdent    = "key"
+		valuesIdent = "values"
+	)
+	return &ast.FuncDecl{
+		Name: ast.NewIdent(responseCacheHeaderFuncName),
+		Type: &ast.FuncType{
+			Params: &ast.FieldList{List: []*ast.Field{{
+				Names: []*ast.Ident{ast.NewIdent(beforeIdent), ast.NewIdent(afterIdent)},
+				Type:  astgen.HTTPHeader(file),
+			}}},
+			Results: &ast.FieldList{List: []*ast.Field{{Type: astgen.HTTPHeader(file)}}},
+		},
+		Body: &ast.BlockStmt{List: []ast.Stmt{
+			singleAssignment(token.DEFINE, ast.NewIdent(headerIdent))(astgen.CallBuiltin("make", astgen.HTTPHeader(file))),
+			&ast.RangeStmt{
+				Key:   ast.NewIdent(keyIdent),
+				Value: ast.NewIdent(valuesIdent),
+				Tok:   token.DEFINE,
+				X:     ast.NewIdent(afterIdent),
+				Body: &ast.BlockStmt{List: []ast.Stmt{&ast.IfStmt{
+					Cond: &ast.UnaryExpr{Op: token.NOT, X: astgen.Call(file, "", "slices", "Equal",
+						&ast.IndexExpr{X: ast.NewIdent(beforeIdent), Index: ast.NewIdent(keyIdent)},
+						ast.NewIdent(valuesIdent),
+					)},
+					Body: &ast.BlockStmt{List: []ast.Stmt{
+						singleAssignment(token.ASSIGN, &ast.IndexExpr{X: ast.NewIdent(headerIdent), Index: ast.NewIdent(keyIdent)})(
+							astgen.Call(file, "", "slices", "Clone", ast.NewIdent(valuesIdent)),
+						),
+					}},
+				}}},
+			},
+			&ast.ReturnStmt{Results: []ast.Expr{ast.NewIdent(headerIdent)}},
+		}},
+	}
+}
+
+func responseCacheableFunc(file *File) *ast.FuncDecl {
+	const (
+		headerIdent    = "header"
+		valueIdent     = "value"
+		directiveIdent = "directive"
+		nameIdent      = "name"
+	)
+	returnFalse := func() ast.Stmt { return &ast.ReturnStmt{Results: []ast.Expr{astgen.Bool(false)}} }
+	return &ast.FuncDecl{
+		Name: ast.NewIdent(responseCacheableFuncName),
+		Type: &ast.FuncType{
+			Params:  &ast.FieldList{List: []*ast.Field{{Names: []*ast.Ident{ast.NewIdent(headerIdent)}, Type: astgen.HTTPHeader(file)}}},
+			Results: &ast.FieldList{List: []*ast.Field{{Type: ast.NewIdent("bool")}}},
+		},
+		Body: &ast.BlockStmt{List: []ast.Stmt{
+			&ast.IfStmt{
+				Cond: &ast.BinaryExpr{X: methodCall(ast.NewIdent(headerIdent), "Get", astgen.String("set-cookie")), Op: token.NEQ, Y: astgen.String("")},
+				Body: &ast.BlockStmt{List: []ast.Stmt{returnFalse()}},
+			},
+			&ast.RangeStmt{
+				Key:   ast.NewIdent("_"),
+				Value: ast.NewIdent(valueIdent),
+				Tok:   token.DEFINE,
+				X:     methodCall(ast.NewIdent(headerIdent), "Values", astgen.String("cache-control")),
+				Body: &ast.BlockStmt{List: []ast.Stmt{&ast.RangeStmt{
+					Key:   ast.NewIdent("_"),
+					Value: ast.NewIdent(directiveIdent),
+					Tok:   token.DEFINE,
+					X:     astgen.Call(file, "", "strings", "Split", ast.NewIdent(valueIdent), astgen.String(",")),
+					Body: &ast.BlockStmt{List: []ast.Stmt{
+						&ast.AssignStmt{
+							Lhs: []ast.Expr{ast.NewIdent(nameIdent), ast.NewIdent("_"), ast.NewIdent("_")},
+							Tok: token.DEFINE,
+							Rhs: []ast.Expr{astgen.Call(file, "", "strings", "Cut",
+								astgen.Call(file, "", "strings", "TrimSpace", ast.NewIdent(directiveIdent)),
+								astgen.String("="),
+							)},
+						},
+						&ast.SwitchStmt{
+							Tag: astgen.Call(file, "", "strings", "ToLower", ast.NewIdent(nameIdent)),
+							Body: &ast.BlockStmt{List: []ast.Stmt{&ast.CaseClause{
+								List: []ast.Expr{astgen.String("private"), astgen.String("no-store")},
+								Body: []ast.Stmt{returnFalse()},
+							}}},
+						},
+					}},
+				}}},
+			},
+			&ast.ReturnStmt{Results: []ast.Expr{astgen.Bool(true)}},
+		}},
+	}
+}
+
+func methodCall(x ast.Expr, method string, args ...ast.Expr) *ast.CallExpr {
+	return &ast.CallExpr{Fun: &ast.SelectorExpr{X: x, Sel: ast.NewIdent(method)}, Args: args}
+}
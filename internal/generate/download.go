@@ -0,0 +1,288 @@
+package generate
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"github.com/typelate/muxt/internal/astgen"
+	"github.com/typelate/muxt/internal/muxt"
+)
+
+// downloadResultStatements streams a result that implements io.WriterTo,
+// io.Reader, or fs.File instead of executing the route template:
+//
+//	if len(td.errList) == 0 && td.okay && td.result != nil {
+//		defer td.result.Close()
+//		if contentType := td.result.ContentType(); contentType != "" {
+//			response.Header().Set("content-type", contentType)
+//		}
+//		filename := td.result.Filename()
+//		if filename != "" {
+//			response.Header().Set("content-disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+//		}
+//		var modTime time.Time
+//		if info, err := td.result.Stat(); err == nil {
+//			modTime = info.ModTime()
+//		}
+//		http.ServeContent(response, request, filename, modTime, td.result)
+//		return
+//	}
+//
+// An interface result without a Close method is closed when it is an
+// io.Closer at runtime:
+//
+//	if closer, ok := td.result.(io.Closer); ok {
+//		defer closer.Close()
+//	}
+//
+// Seekable results, and interface results that are an io.ReadSeeker at
+// runtime, go through http.ServeContent so Range and conditional requests
+// work; otherwise the default status is written and WriteTo is preferred over
+// io.Copy. The td.okay check is added for a method returning (T, bool) and
+// the nil check for an interface or pointer result; when the method fails,
+// reports false, or returns nil the template renders as it would for any
+// other result, with the error in .Err. It returns nil when the result is
+// not a stream.
+func downloadResultStatements(file *File, def muxt.Definition, sig *types.Signature, resultType types.Type, rdIdent string) []ast.Stmt {
+	var (
+		isReader   = hasResultMethod(file, resultType, "Read", isReadSignature)
+		isSeeker   = hasResultMethod(file, resultType, "Seek", isSeekSignature)
+		isWriterTo = hasResultMethod(file, resultType, "WriteTo", isWriteToSignature)
+	)
+	if !isReader && !isWriterTo {
+		return nil
+	}
+	const (
+		contentTypeIdent = "contentType"
+		filenameIdent    = "filename"
+		modTimeIdent     = "modTime"
+		infoIdent        = "info"
+		seekerIdent      = "seeker"
+	)
+	result := func() ast.Expr {
+		return &ast.SelectorExpr{X: ast.NewIdent(rdIdent), Sel: ast.NewIdent(TemplateDataFieldIdentifierResult)}
+	}
+	response := ast.NewIdent(muxt.TemplateNameScopeIdentifierHTTPResponse)
+	setHeader := func(key string, value ast.Expr) ast.Stmt {
+		return &ast.ExprStmt{X: &ast.CallExpr{
+			Fun:  &ast.SelectorExpr{X: &ast.CallExpr{Fun: &ast.SelectorExpr{X: response, Sel: ast.NewIdent("Header")}}, Sel: ast.NewIdent("Set")},
+			Args: []ast.Expr{astgen.String(key), value},
+		}}
+	}
+	notEmpty := func(ident string) ast.Expr {
+		return &ast.BinaryExpr{X: ast.NewIdent(ident), Op: token.NEQ, Y: astgen.String("")}
+	}
+
+	var body []ast.Stmt
+	if hasResultMethod(file, resultType, "Close", isCloseSignature) {
+		body = append(body, &ast.DeferStmt{Call: &ast.CallExpr{Fun: &ast.SelectorExpr{X: result(), Sel: ast.NewIdent("Close")}}})
+	} else if types.IsInterface(resultType) {
+		// if closer, ok := td.result.(io.Closer); ok { defer closer.Close() }
+		const closerIdent = "closer"
+		body = append(body, &ast.IfStmt{
+			Init: &ast.AssignStmt{
+				Lhs: []ast.Expr{ast.NewIdent(closerIdent), ast.NewIdent("ok")},
+				Tok: token.DEFINE,
+				Rhs: []ast.Expr{&ast.TypeAssertExpr{X: result(), Type: astgen.ExportedIdentifier(file, "io", "io", "Closer")}},
+			},
+			Cond: ast.NewIdent("ok"),
+			Body: &ast.BlockStmt{List: []ast.Stmt{&ast.DeferStmt{Call: &ast.CallExpr{Fun: &ast.SelectorExpr{X: ast.NewIdent(closerIdent), Sel: ast.NewIdent("Close")}}}}},
+		})
+	}
+	body = append(body, resultHeaderStatements(file, resultType, result)...)
+	if contentType, ok := resultMember(file, resultType, "ContentType", result, isStringType); ok {
+		body = append(body, &ast.IfStmt{
+			Init: singleAssignment(token.DEFINE, ast.NewIdent(contentTypeIdent))(contentType),
+			Cond: notEmpty(contentTypeIdent),
+			Body: &ast.BlockStmt{List: []ast.Stmt{setHeader("content-type", ast.NewIdent(contentTypeIdent))}},
+		})
+	}
+	var filename ast.Expr = astgen.String("")
+	fn, hasFilename := resultMember(file, resultType, "Filename", result, isStringType)
+	if hasFilename {
+		filename = ast.NewIdent(filenameIdent)
+		body = append(body,
+			singleAssignment(token.DEFINE, ast.NewIdent(filenameIdent))(fn),
+			&ast.IfStmt{
+				Cond: notEmpty(filenameIdent),
+				Body: &ast.BlockStmt{List: []ast.Stmt{setHeader("content-disposition", astgen.Call(file, "mime", "mime", "FormatMediaType",
+					astgen.String("attachment"),
+					&ast.CompositeLit{
+						Type: &ast.MapType{Key: ast.NewIdent("string"), Value: ast.NewIdent("string")},
+						Elts: []ast.Expr{&ast.KeyValueExpr{Key: astgen.String("filename"), Value: ast.NewIdent(filenameIdent)}},
+					},
+				))}},
+			},
+		)
+	}
+
+	serveContent := func(content ast.Expr) []ast.Stmt {
+		var list []ast.Stmt
+		name := filename
+		var modTime ast.Expr = &ast.CompositeLit{Type: astgen.ExportedIdentifier(file, "time", "time", "Time")}
+		if hasResultMethod(file, resultType, "Stat", isStatSignature) {
+			modTime = ast.NewIdent(modTimeIdent)
+			statBody := []ast.Stmt{
+				singleAssignment(token.ASSIGN, ast.NewIdent(modTimeIdent))(&ast.CallExpr{Fun: &ast.SelectorExpr{X: ast.NewIdent(infoIdent), Sel: ast.NewIdent("ModTime")}}),
+			}
+			list = append(list, &ast.DeclStmt{Decl: &ast.GenDecl{Tok: token.VAR, Specs: []ast.Spec{&ast.ValueSpec{
+				Names: []*ast.Ident{ast.NewIdent(modTimeIdent)},
+				Type:  astgen.ExportedIdentifier(file, "time", "time", "Time"),
+			}}}})
+			if !hasFilename {
+				// Without a Filename method the file name from Stat lets
+				// http.ServeContent pick the content type from the extension.
+				name = ast.NewIdent(filenameIdent)
+				statBody = append(statBody, singleAssignment(token.ASSIGN, ast.NewIdent(filenameIdent))(&ast.CallExpr{Fun: &ast.SelectorExpr{X: ast.NewIdent(infoIdent), Sel: ast.NewIdent("Name")}}))
+				list = append(list, &ast.DeclStmt{Decl: &ast.GenDecl{Tok: token.VAR, Specs: []ast.Spec{&ast.ValueSpec{
+					Names: []*ast.Ident{ast.NewIdent(filenameIdent)},
+					Type:  ast.NewIdent("string"),
+				}}}})
+			}
+			list = append(list,
+				&ast.IfStmt{
+					Init: &ast.AssignStmt{
+						Lhs: []ast.Expr{ast.NewIdent(infoIdent), ast.NewIdent(errIdent)},
+						Tok: token.DEFINE,
+						Rhs: []ast.Expr{&ast.CallExpr{Fun: &ast.SelectorExpr{X: result(), Sel: ast.NewIdent("Stat")}}},
+					},
+					Cond: &ast.BinaryExpr{X: ast.NewIdent(errIdent), Op: token.EQL, Y: astgen.Nil()},
+					Body: &ast.BlockStmt{List: statBody},
+				},
+			)
+		}
+		return append(list, &ast.ExprStmt{X: astgen.Call(file, "http", "net/http", "ServeContent",
+			response,
+			ast.NewIdent(muxt.TemplateNameScopeIdentifierHTTPRequest),
+			name,
+			modTime,
+			content,
+		)})
+	}
+
+	if isReader && isSeeker {
+		body = append(body, serveContent(result())...)
+		body = append(body, &ast.ReturnStmt{})
+		return []ast.Stmt{ifStreamable(sig, resultType, rdIdent, body)}
+	}
+	if isReader && types.IsInterface(resultType) {
+		// An fs.File or io.Reader result is often seekable at runtime (os.File,
+		// embed.FS files, bytes.Reader); check before falling back to a copy.
+		body = append(body, &ast.IfStmt{
+			Init: &ast.AssignStmt{
+				Lhs: []ast.Expr{ast.NewIdent(seekerIdent), ast.NewIdent("ok")},
+				Tok: token.DEFINE,
+				Rhs: []ast.Expr{&ast.TypeAssertExpr{X: result(), Type: astgen.ExportedIdentifier(file, "io", "io", "ReadSeeker")}},
+			},
+			Cond: ast.NewIdent("ok"),
+			Body: &ast.BlockStmt{List: append(serveContent(ast.NewIdent(seekerIdent)), &ast.ReturnStmt{})},
+		})
+	}
+	var statusCode ast.Expr = astgen.HTTPStatusCode(file, def.DefaultStatusCode())
+	if code, ok := resultMember(file, resultType, "StatusCode", result, isIntType); ok {
+		statusCode = astgen.CmpOr(file, code, statusCode)
+	}
+	body = append(body, &ast.ExprStmt{X: &ast.CallExpr{
+		Fun:  &ast.SelectorExpr{X: response, Sel: ast.NewIdent("WriteHeader")},
+		Args: []ast.Expr{statusCode},
+	}})
+	var write ast.Expr
+	if isWriterTo {
+		write = &ast.CallExpr{Fun: &ast.SelectorExpr{X: result(), Sel: ast.NewIdent("WriteTo")}, Args: []ast.Expr{response}}
+	} else {
+		write = astgen.Call(file, "io", "io", "Copy", response, result())
+	}
+	body = append(body, &ast.AssignStmt{Lhs: []ast.Expr{ast.NewIdent("_"), ast.NewIdent("_")}, Tok: token.ASSIGN, Rhs: []ast.Expr{write}})
+	body = append(body, &ast.ReturnStmt{})
+	return []ast.Stmt{ifStreamable(sig, resultType, rdIdent, body)}
+}
+
+func ifNoErrors(rdIdent string, body []ast.Stmt) ast.Stmt {
+	return &ast.IfStmt{
+		Cond: &ast.BinaryExpr{
+			X:  astgen.CallBuiltinLen(&ast.SelectorExpr{X: ast.NewIdent(rdIdent), Sel: ast.NewIdent(TemplateDataFieldIdentifierError)}),
+			Op: token.EQL,
+			Y:  astgen.Int(0),
+		},
+		Body: &ast.BlockStmt{List: body},
+	}
+}
+
+// ifStreamable extends ifNoErrors with td.okay for a method returning
+// (T, bool) and td.result != nil for an interface or pointer result.
+func ifStreamable(sig *types.Signature, resultType types.Type, rdIdent string, body []ast.Stmt) ast.Stmt {
+	stmt := ifNoErrors(rdIdent, body).(*ast.IfStmt)
+	field := func(name string) ast.Expr {
+		return &ast.SelectorExpr{X: ast.NewIdent(rdIdent), Sel: ast.NewIdent(name)}
+	}
+	if results := sig.Results(); results.Len() == 2 && types.Identical(results.At(1).Type(), types.Typ[types.Bool]) {
+		stmt.Cond = &ast.BinaryExpr{X: stmt.Cond, Op: token.LAND, Y: field(TemplateDataFieldIdentifierOkay)}
+	}
+	if _, isPointer := resultType.Underlying().(*types.Pointer); isPointer || types.IsInterface(resultType) {
+		stmt.Cond = &ast.BinaryExpr{X: stmt.Cond, Op: token.LAND, Y: &ast.BinaryExpr{X: field(TemplateDataFieldIdentifierResult), Op: token.NEQ, Y: astgen.Nil()}}
+	}
+	return stmt
+}
+
+// hasResultMethod reports whether the method set of the result value (not its
+// address) has a method name with a signature matching isSignature. The
+// result is passed by value to http.ServeContent and io.Copy so pointer
+// receiver methods on a non-pointer result do not count.
+func hasResultMethod(file *File, resultType types.Type, name string, isSignature func(*types.Signature) bool) bool {
+	obj, _, _ := types.LookupFieldOrMethod(resultType, false, file.OutputPackage().Types, name)
+	fn, ok := obj.(*types.Func)
+	return ok && isSignature(fn.Type().(*types.Signature))
+}
+
+// isReadSignature matches Read([]byte) (int, error).
+func isReadSignature(sig *types.Signature) bool {
+	if sig.Params().Len() != 1 || sig.Results().Len() != 2 {
+		return false
+	}
+	slice, ok := types.Unalias(sig.Params().At(0).Type()).(*types.Slice)
+	return ok && types.Identical(slice.Elem(), types.Typ[types.Byte]) &&
+		types.Identical(sig.Results().At(0).Type(), types.Typ[types.Int]) &&
+		isErrorType(sig.Results().At(1).Type())
+}
+
+// isSeekSignature matches Seek(int64, int) (int64, error).
+func isSeekSignature(sig *types.Signature) bool {
+	return sig.Params().Len() == 2 && sig.Results().Len() == 2 &&
+		types.Identical(sig.Params().At(0).Type(), types.Typ[types.Int64]) &&
+		types.Identical(sig.Params().At(1).Type(), types.Typ[types.Int]) &&
+		types.Identical(sig.Results().At(0).Type(), types.Typ[types.Int64]) &&
+		isErrorType(sig.Results().At(1).Type())
+}
+
+// isWriteToSignature matches WriteTo(io.Writer) (int64, error).
+func isWriteToSignature(sig *types.Signature) bool {
+	return sig.Params().Len() == 1 && sig.Results().Len() == 2 &&
+		muxt.IsNamedType(sig.Params().At(0).Type(), "io", "Writer") &&
+		types.Identical(sig.Results().At(0).Type(), types.Typ[types.Int64]) &&
+		isErrorType(sig.Results().At(1).Type())
+}
+
+// isCloseSignature matches Close() error.
+func isCloseSignature(sig *types.Signature) bool {
+	return sig.Params().Len() == 0 && sig.Results().Len() == 1 && isErrorType(sig.Results().At(0).Type())
+}
+
+// isStatSignature matches Stat() (fs.FileInfo, error).
+func isStatSignature(sig *types.Signature) bool {
+	return sig.Params().Len() == 0 && sig.Results().Len() == 2 &&
+		muxt.IsNamedType(sig.Results().At(0).Type(), "io/fs", "FileInfo") &&
+		isErrorType(sig.Results().At(1).Type())
+}
+
+func isErrorType(tp types.Type) bool {
+	return types.Identical(tp, types.Universe.Lookup("error").Type())
+}
+
+func isStringType(tp types.Type) bool {
+	return types.Identical(tp, types.Typ[types.String])
+}
+
+func isIntType(tp types.Type) bool {
+	return types.Identical(tp, types.Typ[types.Int])
+}
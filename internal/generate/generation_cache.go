@@ -0,0 +1,261 @@
+package generate
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/tools/go/packages"
+
+	"github.com/typelate/muxt/internal/asteval"
+)
+
+// GenerationCache records, for each working directory, flag set, and muxt
+// build, the content hashes of the files a generate run read and wrote. When
+// none of them changed a later run can skip packages.Load and
+// TemplateRoutesFiles.
+//
+// The inputs are the files of the package (Go files, embedded templates, and
+// the directory listings so an added file or subdirectory is noticed), the
+// Go files of packages it or its generated files import from the same
+// module, transitively, and go.mod and go.sum. Edits outside of these (a replaced
+// module directory or a new Go toolchain) are not detected.
+type GenerationCache struct {
+	// Dir holds one entry per working directory and flag set.
+	Dir string
+	// Version is the muxt version. A development build ("", "(devel)", or a
+	// +dirty version) also keys entries by the hash of its executable.
+	Version string
+}
+
+type generationCacheEntry struct {
+	Files map[string]string `json:"files"`
+	Dirs  map[string]string `json:"dirs"`
+}
+
+// Unchanged reports whether the last run stored for wd and args read and wrote
+// files that still have the same content.
+func (cache GenerationCache) Unchanged(wd string, args []string) bool {
+	buf, err := os.ReadFile(cache.entryPath(wd, args))
+	if err != nil {
+		return false
+	}
+	var entry generationCacheEntry
+	if err := json.Unmarshal(buf, &entry); err != nil || len(entry.Files) == 0 {
+		return false
+	}
+	for path, sum := range entry.Files {
+		if got, err := fileHash(path); err != nil || got != sum {
+			return false
+		}
+	}
+	for dir, sum := range entry.Dirs {
+		if got, err := dirHash(dir); err != nil || got != sum {
+			return false
+		}
+	}
+	return true
+}
+
+// Store records the inputs of the package at wd and the generated files for
+// wd and args. Call it after the generated files are written.
+func (cache GenerationCache) Store(wd string, args []string, pl []*packages.Package, files []GeneratedFile) error {
+	// Create the cache directory before hashing directory listings, in case
+	// it is inside the package.
+	path := cache.entryPath(wd, args)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	entry := generationCacheEntry{
+		Files: make(map[string]string),
+		Dirs:  make(map[string]string),
+	}
+	addFile := func(path string) error {
+		if _, ok := entry.Files[path]; ok {
+			return nil
+		}
+		sum, err := fileHash(path)
+		if err != nil {
+			return err
+		}
+		entry.Files[path] = sum
+		return nil
+	}
+	addDir := func(dir string) error {
+		if _, ok := entry.Dirs[dir]; ok {
+			return nil
+		}
+		sum, err := dirHash(dir)
+		if err != nil {
+			return err
+		}
+		entry.Dirs[dir] = sum
+		return nil
+	}
+
+	pkg, ok := asteval.PackageAtFilepath(pl, wd)
+	if !ok || pkg.Module == nil {
+		return fmt.Errorf("package not found at %s", wd)
+	}
+	for _, path := range slices.Concat(pkg.GoFiles, pkg.OtherFiles, pkg.EmbedFiles) {
+		if err := errors.Join(addFile(path), addDir(filepath.Dir(path))); err != nil {
+			return err
+		}
+	}
+	// An embedded directory tree may gain a subdirectory the embed pattern
+	// matches, so list every directory between an embedded file and wd.
+	for _, path := range pkg.EmbedFiles {
+		for dir := filepath.Dir(filepath.Dir(path)); dir != wd && strings.HasPrefix(dir, wd+string(filepath.Separator)); dir = filepath.Dir(dir) {
+			if err := addDir(dir); err != nil {
+				return err
+			}
+		}
+	}
+	for _, path := range []string{pkg.Module.GoMod, filepath.Join(pkg.Module.Dir, "go.sum")} {
+		if err := addFile(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	goFiles := slices.Clone(pkg.GoFiles)
+	for _, file := range files {
+		if err := addFile(file.Path); err != nil {
+			return err
+		}
+		goFiles = append(goFiles, file.Path)
+	}
+	// The generated files import the receiver package, which may not be
+	// imported by the package at wd before the first run.
+	if err := addModuleImports(pkg.Module, goFiles, addFile, addDir); err != nil {
+		return err
+	}
+
+	buf, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf, 0o644)
+}
+
+// addModuleImports adds the Go files of the packages in module that goFiles
+// import and of the packages they import from module.
+func addModuleImports(module *packages.Module, goFiles []string, addFile, addDir func(string) error) error {
+	imports, err := fileImports(goFiles...)
+	if err != nil {
+		return err
+	}
+	seen := make(map[string]bool)
+	for len(imports) > 0 {
+		importPath := imports[len(imports)-1]
+		imports = imports[:len(imports)-1]
+		rel, ok := strings.CutPrefix(importPath, module.Path)
+		if seen[importPath] || !ok || (rel != "" && !strings.HasPrefix(rel, "/")) {
+			continue
+		}
+		seen[importPath] = true
+		dir := filepath.Join(module.Dir, filepath.FromSlash(rel))
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return err
+		}
+		if err := addDir(dir); err != nil {
+			return err
+		}
+		for _, e := range entries {
+			if e.IsDir() || filepath.Ext(e.Name()) != ".go" || strings.HasSuffix(e.Name(), "_test.go") {
+				continue
+			}
+			path := filepath.Join(dir, e.Name())
+			if err := addFile(path); err != nil {
+				return err
+			}
+			more, err := fileImports(path)
+			if err != nil {
+				return err
+			}
+			imports = append(imports, more...)
+		}
+	}
+	return nil
+}
+
+func fileImports(paths ...string) ([]string, error) {
+	var imports []string
+	for _, path := range paths {
+		file, err := parser.ParseFile(token.NewFileSet(), path, nil, parser.ImportsOnly)
+		if err != nil {
+			return nil, err
+		}
+		for _, spec := range file.Imports {
+			if p, err := strconv.Unquote(spec.Path.Value); err == nil {
+				imports = append(imports, p)
+			}
+		}
+	}
+	return imports, nil
+}
+
+func (cache GenerationCache) entryPath(wd string, args []string) string {
+	version := cache.Version
+	if version == "" || version == "(devel)" || strings.HasSuffix(version, "+dirty") {
+		// Development builds share a version, so a rebuilt muxt must not
+		// reuse entries written by the previous binary.
+		if sum, err := executableHash(); err == nil {
+			version += " " + sum
+		}
+	}
+	h := sha256.New()
+	for _, s := range slices.Concat([]string{version, wd}, args) {
+		h.Write([]byte(s))
+		h.Write([]byte{0})
+	}
+	return filepath.Join(cache.Dir, hex.EncodeToString(h.Sum(nil))+".json")
+}
+
+// executableHash is the hash of the running muxt binary. It is computed once
+// because ./... looks up an entry for every package.
+var executableHash = sync.OnceValues(func() (string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	return fileHash(exe)
+})
+
+func fileHash(path string) (string, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(buf)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// dirHash hashes the names of the entries in dir, so adding, removing, or
+// renaming a file or subdirectory changes it. Subdirectory names end in a
+// slash; their contents are hashed by their own entries.
+func dirHash(dir string) (string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	for _, e := range entries {
+		h.Write([]byte(e.Name()))
+		if e.IsDir() {
+			h.Write([]byte{'/'})
+		}
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
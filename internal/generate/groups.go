@@ -46,11 +46,17 @@ func groupTemplates(wd string, config RoutesFileConfiguration, routesPkg *packag
 	delete(result.byFile, "")
 
 	for sourceFile := range result.byFile {
-		baseName := filepath.Base(sourceFile)
-		if strings.ContainsAny(baseName, " /\\()") {
+		if !isTemplateSourceFile(sourceFile) {
 			result.noFile = append(result.noFile, result.byFile[sourceFile]...)
 			delete(result.byFile, sourceFile)
 		}
 	}
 	return result, nil
 }
+
+// isTemplateSourceFile reports whether name, a template's ParseName, is a
+// file name. Templates added with Parse have their template name instead,
+// such as "GET /{$}".
+func isTemplateSourceFile(name string) bool {
+	return name != "" && !strings.ContainsAny(filepath.Base(name), " /\\()")
+}
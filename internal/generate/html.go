@@ -6,13 +6,12 @@ import (
 	"go/types"
 	"net/http"
 	"slices"
-	"strconv"
 
 	"github.com/typelate/muxt/internal/astgen"
 	"github.com/typelate/muxt/internal/muxt"
 )
 
-func executeHTMLTemplateHandler(file *File, config RoutesFileConfiguration, def muxt.Definition, sig *types.Signature, resultDataIdent string, receiverInterfaceName string, bufIdent string, statusCodeIdent string) (*ast.FuncLit, error) {
+func executeHTMLTemplateHandler(file *File, config RoutesFileConfiguration, def muxt.Definition, sig *types.Signature, resultDataIdent string, receiverInterfaceName string, bufIdent string) (*ast.FuncLit, error) {
 	var callFun ast.Expr
 	isMethodCall := sig.Recv() != nil
 	if isMethodCall {
@@ -24,6 +23,7 @@ func executeHTMLTemplateHandler(file *File, config RoutesFileConfiguration, def
 		callFun = ast.NewIdent(def.FunctionIdentifier().Name)
 	}
 
+	var cacheStore ast.Stmt
 	execIdx, hasExecute := -1, false
 	var resultType types.Type
 	var execHasArg bool
@@ -70,19 +70,21 @@ func executeHTMLTemplateHandler(file *File, config RoutesFileConfiguration, def
 		},
 	}
 
-	if handlerFunc.Body.List, err = appendParseArgumentStatements(handlerFunc.Body.List, def, file, resultType, sig, def.Arguments, nil, resultDataIdent, config, def.CallExpression(), func(s string) *ast.BlockStmt {
-		errBlock := appendTemplateDataError(file, resultDataIdent, astgen.ErrorsNew(file, astgen.String(s)))
-		errBlock.List = append(errBlock.List, assignTemplateDataErrStatusCode(file, resultDataIdent, http.StatusBadRequest))
-		return errBlock
+	handlerFunc.Body.List = append(routeOptionStatements(file, def), handlerFunc.Body.List...)
+	handlerFunc.Body.List = append(handlerFunc.Body.List, recoverPanicStatements(file, config, def, resultDataIdent, resultType)...)
+
+	if handlerFunc.Body.List, err = appendParseArgumentStatements(handlerFunc.Body.List, def, file, resultType, sig, def.Arguments, nil, resultDataIdent, config, def.CallExpression(), func(name, constraint, message string) *ast.BlockStmt {
+		return appendTemplateDataFieldError(file, resultDataIdent, astgen.String(name), constraint, astgen.ErrorsNew(file, astgen.String(message)))
 	}, nil); err != nil {
 		return nil, err
 	}
+	handlerFunc.Body.List = append(handlerFunc.Body.List, authorizeStatements(file, def, resultDataIdent)...)
 
 	handlerFunc.Body.List = append(handlerFunc.Body.List, astgen.GetBufferFromPool(file, bufferPoolIdent, bufIdent)...)
 
 	if hasExecute {
 		const guardIdent = "executed"
-		closure, err := executeClosure(file, def, resultDataIdent, bufIdent, guardIdent, resultType, execHasArg)
+		closure, err := executeClosure(file, config, def, resultDataIdent, bufIdent, guardIdent, resultType, execHasArg)
 		if err != nil {
 			return nil, err
 		}
@@ -98,7 +100,7 @@ func executeHTMLTemplateHandler(file *File, config RoutesFileConfiguration, def
 		if config.Logger {
 			handlerFunc.Body.List = append(handlerFunc.Body.List, logDebugStatement(file, "handling request", def.RawPattern()))
 		}
-		renderCheck := checkExecuteTemplateError(file, config.Logger, def.RawPattern())
+		renderCheck := checkExecuteTemplateError(config.Logger, def.RawPattern(), resultDataIdent)
 		renderCheck.Init = &ast.AssignStmt{
 			Lhs: []ast.Expr{ast.NewIdent(errIdent)},
 			Tok: token.DEFINE,
@@ -117,6 +119,7 @@ func executeHTMLTemplateHandler(file *File, config RoutesFileConfiguration, def
 			},
 			Body: &ast.BlockStmt{List: []ast.Stmt{renderCheck, setOkay}},
 		})
+		handlerFunc.Body.List = append(handlerFunc.Body.List, deadlineExceededStatements(file, def, resultDataIdent)...)
 	} else {
 		errBody := appendTemplateDataError(file, resultDataIdent, ast.NewIdent(errIdent))
 		errBody.List = append(errBody.List, assignTemplateDataErrStatusCode(file, resultDataIdent, http.StatusInternalServerError))
@@ -143,14 +146,35 @@ func executeHTMLTemplateHandler(file *File, config RoutesFileConfiguration, def
 				List: receiverCall.Stmts(),
 			},
 		})
+		handlerFunc.Body.List = append(handlerFunc.Body.List, deadlineExceededStatements(file, def, resultDataIdent)...)
+		var download []ast.Stmt
+		if !def.HasResponseWriterArg() {
+			download = downloadResultStatements(file, def, sig, resultType, resultDataIdent)
+			handlerFunc.Body.List = append(handlerFunc.Body.List, download...)
+			sequence, err := sequenceResultStatements(file, config, def, resultType, resultDataIdent)
+			if err != nil {
+				return nil, err
+			}
+			handlerFunc.Body.List = append(handlerFunc.Body.List, sequence...)
+		}
+		if key, ttl, ok := responseCacheKey(file, config, def, resultType, resultDataIdent); ok && len(download) == 0 {
+			handlerFunc.Body.List = append(handlerFunc.Body.List, cacheLookupStatements(file, key, resultDataIdent)...)
+			cacheStore = cacheStoreStatement(file, ttl, bufIdent, "statusCode")
+		}
 
 		callExecuteTemplate(file, config, def, handlerFunc, bufIdent, resultDataIdent)
 	}
 
 	if !def.HasResponseWriterArg() {
-		handlerFunc.Body.List = append(handlerFunc.Body.List, writeStatusAndHeaders(file, def, resultType, def.DefaultStatusCode(), statusCodeIdent, bufIdent, resultDataIdent, func() ast.Expr {
+		writeResponse := writeStatusAndHeaders(file, def, resultType, bufIdent, resultDataIdent, func() ast.Expr {
 			return &ast.SelectorExpr{X: ast.NewIdent(resultDataIdent), Sel: ast.NewIdent(TemplateDataFieldIdentifierResult)}
-		})...)
+		})
+		if cacheStore != nil {
+			// Store the response after a redirect returns and before
+			// td.writeResponse drains the buffer.
+			writeResponse = slices.Insert(writeResponse, len(writeResponse)-1, cacheStore)
+		}
+		handlerFunc.Body.List = append(handlerFunc.Body.List, writeResponse...)
 	} else {
 		handlerFunc.Body.List = append(handlerFunc.Body.List, callWriteOnResponse(bufIdent))
 	}
@@ -162,16 +186,13 @@ func callExecuteTemplate(file *File, config RoutesFileConfiguration, def muxt.De
 		handlerFunc.Body.List = append(handlerFunc.Body.List, logDebugStatement(file, "handling request", def.RawPattern()))
 	}
 
-	execTemplates := checkExecuteTemplateError(file, config.Logger, def.RawPattern())
+	execTemplates := checkExecuteTemplateError(config.Logger, def.RawPattern(), dataIdent)
 	execTemplates.Init = &ast.AssignStmt{
 		Lhs: []ast.Expr{
 			ast.NewIdent(errIdent),
 		},
 		Tok: token.DEFINE,
-		Rhs: []ast.Expr{&ast.CallExpr{
-			Fun:  &ast.SelectorExpr{X: ast.NewIdent(def.TemplatesVariable()), Sel: ast.NewIdent("ExecuteTemplate")},
-			Args: []ast.Expr{ast.NewIdent(bufIdent), &ast.BasicLit{Kind: token.STRING, Value: strconv.Quote(def.Name())}, &ast.UnaryExpr{Op: token.AND, X: ast.NewIdent(dataIdent)}},
-		}},
+		Rhs: []ast.Expr{executeTemplateCall(def, def.Name(), ast.NewIdent(bufIdent), templateDataArgument(file, config, def, dataIdent))},
 	}
 
 	handlerFunc.Body.List = append(handlerFunc.Body.List, execTemplates)
@@ -184,16 +205,16 @@ func callExecuteTemplate(file *File, config RoutesFileConfiguration, def muxt.De
 //			return errors.New("execute callback called more than once")
 //		}
 //		td.result = data
-//		return templates.ExecuteTemplate(buf, name, &td)
+//		return routeTemplate.Execute(buf, &td)
 //	}
 //
 // For the zero-arg form it omits the parameter and the td.result assignment. The
-// guard renders at most once: ExecuteTemplate mutates the shared template data
+// guard renders at most once: Execute mutates the shared template data
 // (status code, response headers), so a method that invokes the callback more
 // than once gets an error on the later calls rather than a second render. The
 // guard is an atomic.Bool compared-and-swapped so a callback invoked from
 // another goroutine still renders exactly once.
-func executeClosure(file *File, def muxt.Definition, tdIdent, bufIdent, guardIdent string, resultType types.Type, hasArg bool) (*ast.FuncLit, error) {
+func executeClosure(file *File, config RoutesFileConfiguration, def muxt.Definition, tdIdent, bufIdent, guardIdent string, resultType types.Type, hasArg bool) (*ast.FuncLit, error) {
 	const dataIdent = "data"
 	var params []*ast.Field
 	body := []ast.Stmt{
@@ -219,10 +240,7 @@ func executeClosure(file *File, def muxt.Definition, tdIdent, bufIdent, guardIde
 			Rhs: []ast.Expr{ast.NewIdent(dataIdent)},
 		})
 	}
-	body = append(body, &ast.ReturnStmt{Results: []ast.Expr{&ast.CallExpr{
-		Fun:  &ast.SelectorExpr{X: ast.NewIdent(def.TemplatesVariable()), Sel: ast.NewIdent("ExecuteTemplate")},
-		Args: []ast.Expr{ast.NewIdent(bufIdent), &ast.BasicLit{Kind: token.STRING, Value: strconv.Quote(def.Name())}, &ast.UnaryExpr{Op: token.AND, X: ast.NewIdent(tdIdent)}},
-	}}})
+	body = append(body, &ast.ReturnStmt{Results: []ast.Expr{executeTemplateCall(def, def.Name(), ast.NewIdent(bufIdent), templateDataArgument(file, config, def, tdIdent))}})
 	return &ast.FuncLit{
 		Type: &ast.FuncType{
 			Params:  &ast.FieldList{List: params},
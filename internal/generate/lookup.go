@@ -0,0 +1,80 @@
+package generate
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"slices"
+
+	"github.com/typelate/muxt/internal/astgen"
+	"github.com/typelate/muxt/internal/muxt"
+)
+
+const routeTemplateIdent = "routeTemplate"
+
+// templateIdent returns the variable holding the resolved template with the
+// given name: routeTemplate for the route template and the callback argument
+// name with a Template suffix (sseClockTemplate) for a callback template.
+func templateIdent(def muxt.Definition, templateName string) string {
+	if templateName == def.Name() {
+		return routeTemplateIdent
+	}
+	return templateName + "Template"
+}
+
+// executeTemplateCall builds templateIdent.Execute(writer, data) for the
+// template resolved by lookupTemplateStatements.
+func executeTemplateCall(def muxt.Definition, templateName string, writer, data ast.Expr) *ast.CallExpr {
+	return &ast.CallExpr{
+		Fun:  &ast.SelectorExpr{X: ast.NewIdent(templateIdent(def, templateName)), Sel: ast.NewIdent("Execute")},
+		Args: []ast.Expr{writer, data},
+	}
+}
+
+// lookupTemplateStatements resolves the templates a handler executes once,
+// when the routes function runs:
+//
+//	routeTemplate := templates.Lookup(name)
+//	if routeTemplate == nil {
+//		panic("template \"name\" not found in templates")
+//	}
+//
+// It only declares the variables handler references, so an sse route that
+// renders only its callback templates does not declare routeTemplate.
+func lookupTemplateStatements(def muxt.Definition, handler ast.Node) []ast.Stmt {
+	names := []string{def.Name()}
+	for _, arg := range def.Arguments {
+		if arg.Type != muxt.ArgumentTypeExecute || arg.Template() == nil || slices.Contains(names, arg.Template().Name()) {
+			continue
+		}
+		names = append(names, arg.Template().Name())
+	}
+	used := make(map[string]bool)
+	ast.Inspect(handler, func(node ast.Node) bool {
+		if ident, ok := node.(*ast.Ident); ok {
+			used[ident.Name] = true
+		}
+		return true
+	})
+	var list []ast.Stmt
+	for _, name := range names {
+		ident := templateIdent(def, name)
+		if !used[ident] {
+			continue
+		}
+		list = append(list,
+			singleAssignment(token.DEFINE, ast.NewIdent(ident))(&ast.CallExpr{
+				Fun:  &ast.SelectorExpr{X: ast.NewIdent(def.TemplatesVariable()), Sel: ast.NewIdent("Lookup")},
+				Args: []ast.Expr{astgen.String(name)},
+			}),
+			&ast.IfStmt{
+				Cond: &ast.BinaryExpr{X: ast.NewIdent(ident), Op: token.EQL, Y: astgen.Nil()},
+				Body: &ast.BlockStmt{List: []ast.Stmt{&ast.ExprStmt{X: &ast.CallExpr{
+					Fun:  ast.NewIdent("panic"),
+					Args: []ast.Expr{astgen.String(fmt.Sprintf("template %q not found in %s", name, def.TemplatesVariable()))},
+				}}}},
+			},
+		)
+	}
+	return list
+}
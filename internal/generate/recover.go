@@ -0,0 +1,92 @@
+package generate
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"net/http"
+
+	"github.com/typelate/muxt/internal/astgen"
+	"github.com/typelate/muxt/internal/muxt"
+)
+
+const recoveredPanicMessage = "recovered from panic"
+
+// recoverPanicStatements defers a recover when panic recovery is enabled:
+//
+//	defer func() {
+//		r := recover()
+//		if r == nil {
+//			return
+//		}
+//		if r == http.ErrAbortHandler {
+//			panic(r)
+//		}
+//		err := fmt.Errorf("panic: %v", r)
+//		slog.ErrorContext(request.Context(), "recovered from panic", ..., slog.String("stack", string(debug.Stack())))
+//		td.errList = append(td.errList, err)
+//		...
+//	}()
+//
+// http.ErrAbortHandler is panicked again so net/http still aborts the
+// response quietly. The stack is logged but kept out of .Err, so it is not
+// rendered into the page. With template data the route template is rendered again into a fresh
+// buffer with the panic in .Err and status 500. Template execution already
+// turns a panicking template function into an Execute error, so the
+// render does not need its own recover. With an empty rdIdent (sse and ws
+// routes) the panic is only logged; the stream or connection may already be
+// open.
+func recoverPanicStatements(file *File, config RoutesFileConfiguration, def muxt.Definition, rdIdent string, resultType types.Type) []ast.Stmt {
+	if !config.PanicRecovery {
+		return nil
+	}
+	const (
+		recoveredIdent = "r"
+		bufIdent       = "buf"
+	)
+	var logCall *ast.CallExpr
+	if config.Logger {
+		logCall = loggerErrorCall(file, recoveredPanicMessage, def.RawPattern(), errIdent)
+	} else {
+		logCall = executeTemplateFailedLogLine(file, recoveredPanicMessage, errIdent)
+	}
+	logCall.Args = append(logCall.Args, astgen.SlogString(file, "stack", &ast.CallExpr{
+		Fun:  ast.NewIdent("string"),
+		Args: []ast.Expr{astgen.Call(file, "debug", "runtime/debug", "Stack")},
+	}))
+	body := []ast.Stmt{
+		singleAssignment(token.DEFINE, ast.NewIdent(recoveredIdent))(&ast.CallExpr{Fun: ast.NewIdent("recover")}),
+		&ast.IfStmt{
+			Cond: &ast.BinaryExpr{X: ast.NewIdent(recoveredIdent), Op: token.EQL, Y: astgen.Nil()},
+			Body: &ast.BlockStmt{List: []ast.Stmt{&ast.ReturnStmt{}}},
+		},
+		&ast.IfStmt{
+			Cond: &ast.BinaryExpr{X: ast.NewIdent(recoveredIdent), Op: token.EQL, Y: astgen.ExportedIdentifier(file, "http", "net/http", "ErrAbortHandler")},
+			Body: &ast.BlockStmt{List: []ast.Stmt{&ast.ExprStmt{X: &ast.CallExpr{Fun: ast.NewIdent("panic"), Args: []ast.Expr{ast.NewIdent(recoveredIdent)}}}}},
+		},
+		singleAssignment(token.DEFINE, ast.NewIdent(errIdent))(astgen.Call(file, "fmt", "fmt", "Errorf", astgen.String("panic: %v"), ast.NewIdent(recoveredIdent))),
+		&ast.ExprStmt{X: logCall},
+	}
+	if rdIdent != "" {
+		execTemplate := checkExecuteTemplateError(config.Logger, def.RawPattern(), rdIdent)
+		execTemplate.Init = singleAssignment(token.DEFINE, ast.NewIdent(errIdent))(executeTemplateCall(def, def.Name(), ast.NewIdent(bufIdent), templateDataArgument(file, config, def, rdIdent)))
+		body = append(body, appendTemplateDataError(file, rdIdent, ast.NewIdent(errIdent)).List...)
+		body = append(body,
+			assignTemplateDataErrStatusCode(file, rdIdent, http.StatusInternalServerError),
+			singleAssignment(token.ASSIGN, &ast.SelectorExpr{X: ast.NewIdent(rdIdent), Sel: ast.NewIdent(templateDataFieldStatusCode)})(astgen.Int(0)),
+			singleAssignment(token.DEFINE, ast.NewIdent(bufIdent))(&ast.CallExpr{Fun: ast.NewIdent("new"), Args: []ast.Expr{astgen.ExportedIdentifier(file, "", "bytes", "Buffer")}}),
+			execTemplate,
+		)
+		if def.HasResponseWriterArg() {
+			body = append(body, callWriteOnResponse(bufIdent))
+		} else {
+			body = append(body, writeStatusAndHeaders(file, def, resultType, bufIdent, rdIdent, func() ast.Expr {
+				return &ast.SelectorExpr{X: ast.NewIdent(rdIdent), Sel: ast.NewIdent(TemplateDataFieldIdentifierResult)}
+			})...)
+		}
+	}
+	return []ast.Stmt{&ast.DeferStmt{Call: &ast.CallExpr{Fun: &ast.FuncLit{
+		Type: &ast.FuncType{Params: &ast.FieldList{}},
+		Body: &ast.BlockStmt{List: body},
+	}}}}
+}
@@ -0,0 +1,131 @@
+package generate
+
+import (
+	"go/ast"
+	"go/token"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/typelate/muxt/internal/astgen"
+	"github.com/typelate/muxt/internal/muxt"
+)
+
+// routeOptionStatements applies the timeout and maxbody route options at the
+// start of a handler, before the template data captures the request:
+//
+//	request.Body = http.MaxBytesReader(response, request.Body, 1048576)
+//	timeoutCtx, cancel := context.WithTimeout(request.Context(), 5*time.Second)
+//	defer cancel()
+//	request = request.WithContext(timeoutCtx)
+func routeOptionStatements(file *File, def muxt.Definition) []ast.Stmt {
+	const (
+		timeoutCtxIdent = "timeoutCtx"
+		cancelIdent     = "cancel"
+	)
+	request := ast.NewIdent(muxt.TemplateNameScopeIdentifierHTTPRequest)
+	var list []ast.Stmt
+	if n := def.MaxBodyBytes(); n > 0 {
+		body := &ast.SelectorExpr{X: request, Sel: ast.NewIdent("Body")}
+		list = append(list, singleAssignment(token.ASSIGN, body)(astgen.Call(file, "http", "net/http", "MaxBytesReader",
+			ast.NewIdent(muxt.TemplateNameScopeIdentifierHTTPResponse),
+			body,
+			&ast.BasicLit{Kind: token.INT, Value: strconv.FormatInt(n, 10)},
+		)))
+	}
+	if d := def.Timeout(); d > 0 {
+		list = append(list,
+			&ast.AssignStmt{
+				Lhs: []ast.Expr{ast.NewIdent(timeoutCtxIdent), ast.NewIdent(cancelIdent)},
+				Tok: token.DEFINE,
+				Rhs: []ast.Expr{astgen.Call(file, "context", "context", "WithTimeout",
+					&ast.CallExpr{Fun: &ast.SelectorExpr{X: request, Sel: ast.NewIdent("Context")}},
+					durationExpression(file, d),
+				)},
+			},
+			&ast.DeferStmt{Call: &ast.CallExpr{Fun: ast.NewIdent(cancelIdent)}},
+			singleAssignment(token.ASSIGN, request)(&ast.CallExpr{
+				Fun:  &ast.SelectorExpr{X: request, Sel: ast.NewIdent("WithContext")},
+				Args: []ast.Expr{ast.NewIdent(timeoutCtxIdent)},
+			}),
+		)
+	}
+	return list
+}
+
+// durationExpression formats d with the largest time unit that divides it,
+// for example 5*time.Second or 1500*time.Millisecond.
+func durationExpression(file *File, d time.Duration) ast.Expr {
+	for _, unit := range []struct {
+		name string
+		d    time.Duration
+	}{
+		{name: "Hour", d: time.Hour},
+		{name: "Minute", d: time.Minute},
+		{name: "Second", d: time.Second},
+		{name: "Millisecond", d: time.Millisecond},
+		{name: "Microsecond", d: time.Microsecond},
+	} {
+		if d%unit.d != 0 {
+			continue
+		}
+		u := astgen.ExportedIdentifier(file, "time", "time", unit.name)
+		if d == unit.d {
+			return u
+		}
+		return &ast.BinaryExpr{X: astgen.Int(int(d / unit.d)), Op: token.MUL, Y: u}
+	}
+	return &ast.CallExpr{Fun: astgen.ExportedIdentifier(file, "time", "time", "Duration"), Args: []ast.Expr{astgen.Int(int(d))}}
+}
+
+// deadlineExceededStatements reports a route timeout after the method call:
+//
+//	if err := request.Context().Err(); errors.Is(err, context.DeadlineExceeded) {
+//		if len(td.errList) == 0 {
+//			td.errList = append(td.errList, err)
+//		}
+//		td.errStatusCode = http.StatusServiceUnavailable
+//	}
+//
+// A method that returned the context error already added it to the list.
+func deadlineExceededStatements(file *File, def muxt.Definition, rdIdent string) []ast.Stmt {
+	if def.Timeout() <= 0 {
+		return nil
+	}
+	errList := &ast.SelectorExpr{X: ast.NewIdent(rdIdent), Sel: ast.NewIdent(TemplateDataFieldIdentifierError)}
+	return []ast.Stmt{&ast.IfStmt{
+		Init: singleAssignment(token.DEFINE, ast.NewIdent(errIdent))(&ast.CallExpr{Fun: &ast.SelectorExpr{
+			X:   &ast.CallExpr{Fun: &ast.SelectorExpr{X: ast.NewIdent(muxt.TemplateNameScopeIdentifierHTTPRequest), Sel: ast.NewIdent("Context")}},
+			Sel: ast.NewIdent("Err"),
+		}}),
+		Cond: astgen.Call(file, "errors", "errors", "Is", ast.NewIdent(errIdent), astgen.ExportedIdentifier(file, "context", "context", "DeadlineExceeded")),
+		Body: &ast.BlockStmt{List: []ast.Stmt{
+			&ast.IfStmt{
+				Cond: &ast.BinaryExpr{X: astgen.CallBuiltinLen(errList), Op: token.EQL, Y: astgen.Int(0)},
+				Body: appendTemplateDataError(file, rdIdent, ast.NewIdent(errIdent)),
+			},
+			assignTemplateDataErrStatusCode(file, rdIdent, http.StatusServiceUnavailable),
+		}},
+	}}
+}
+
+// maxBytesErrorStatements sets the status for a body that exceeded the
+// maxbody route option:
+//
+//	var maxBytesError *http.MaxBytesError
+//	if errors.As(err, &maxBytesError) {
+//		td.errStatusCode = http.StatusRequestEntityTooLarge
+//	}
+func maxBytesErrorStatements(file *File, statusCode ast.Expr) []ast.Stmt {
+	const maxBytesErrorIdent = "maxBytesError"
+	return []ast.Stmt{
+		&ast.DeclStmt{Decl: &ast.GenDecl{Tok: token.VAR, Specs: []ast.Spec{&ast.ValueSpec{
+			Names: []*ast.Ident{ast.NewIdent(maxBytesErrorIdent)},
+			Type:  &ast.StarExpr{X: astgen.ExportedIdentifier(file, "http", "net/http", "MaxBytesError")},
+		}}}},
+		&ast.IfStmt{
+			Cond: astgen.Call(file, "errors", "errors", "As", ast.NewIdent(errIdent), &ast.UnaryExpr{Op: token.AND, X: ast.NewIdent(maxBytesErrorIdent)}),
+			Body: &ast.BlockStmt{List: []ast.Stmt{singleAssignment(token.ASSIGN, statusCode)(astgen.HTTPStatusCode(file, http.StatusRequestEntityTooLarge))}},
+		},
+	}
+}
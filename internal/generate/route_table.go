@@ -0,0 +1,290 @@
+package generate
+
+import (
+	"go/ast"
+	"go/token"
+	"path/filepath"
+	"strings"
+
+	"github.com/typelate/muxt/internal/astgen"
+	"github.com/typelate/muxt/internal/muxt"
+)
+
+const (
+	routeTypeName         = "Route"
+	routeMuxTypeName      = "RouteMux"
+	routeTableFuncSuffix  = "Table"
+	routeHandlerSuffix    = "Handler"
+	routeTableRouteIdent  = "route"
+	routeTableRoutesIdent = "routes"
+	routeFieldPattern     = "Pattern"
+	routeFieldMethod      = "Method"
+	routeFieldPath        = "Path"
+	routeFieldIdentifier  = "Identifier"
+	routeFieldSourceFile  = "SourceFile"
+	routeFieldHandler     = "Handler"
+	routeMuxPatternParam  = "pattern"
+	routeMuxHandlerParam  = "handler"
+)
+
+// routeTableDecls declares the Route type and the RouteMux interface the
+// routes function registers the table on. Pattern is an http.ServeMux pattern
+// and the handlers read path parameters with request.PathValue, so a RouteMux
+// has to be a ServeMux or match its pattern syntax and set the path values;
+// Method and Path are the two halves of Pattern:
+//
+//	type RouteMux interface {
+//		Handle(pattern string, handler http.Handler)
+//	}
+//
+//	type Route struct {
+//		Pattern    string
+//		Method     string
+//		Path       string
+//		Identifier string
+//		SourceFile string
+//		Handler    http.Handler
+//	}
+func routeTableDecls(file *File) []ast.Decl {
+	field := func(name string, tp ast.Expr) *ast.Field {
+		return &ast.Field{Names: []*ast.Ident{ast.NewIdent(name)}, Type: tp}
+	}
+	typeDecl := func(name string, tp ast.Expr) ast.Decl {
+		return &ast.GenDecl{Tok: token.TYPE, Specs: []ast.Spec{&ast.TypeSpec{Name: ast.NewIdent(name), Type: tp}}}
+	}
+	return []ast.Decl{
+		typeDecl(routeMuxTypeName, &ast.InterfaceType{Methods: &ast.FieldList{List: []*ast.Field{
+			{Names: []*ast.Ident{ast.NewIdent(httpHandleIdent)}, Type: &ast.FuncType{Params: &ast.FieldList{List: []*ast.Field{
+				field(routeMuxPatternParam, ast.NewIdent("string")),
+				field(routeMuxHandlerParam, astgen.HTTPHandler(file)),
+			}}}},
+		}}}),
+		typeDecl(routeTypeName, &ast.StructType{Fields: &ast.FieldList{List: []*ast.Field{
+			field(routeFieldPattern, ast.NewIdent("string")),
+			field(routeFieldMethod, ast.NewIdent("string")),
+			field(routeFieldPath, ast.NewIdent("string")),
+			field(routeFieldIdentifier, ast.NewIdent("string")),
+			field(routeFieldSourceFile, ast.NewIdent("string")),
+			field(routeFieldHandler, astgen.HTTPHandler(file)),
+		}}}),
+	}
+}
+
+// routeMuxField is the routes function mux parameter with the route table:
+// a router with a Handle(pattern string, handler http.Handler) method that
+// understands http.ServeMux patterns.
+func routeMuxField() *ast.Field {
+	return &ast.Field{Names: []*ast.Ident{ast.NewIdent(muxParamName)}, Type: ast.NewIdent(routeMuxTypeName)}
+}
+
+// routeTableFuncName returns the name of the function returning the route
+// table, TemplateRoutesTable for the default routes function.
+func routeTableFuncName(config RoutesFileConfiguration) string {
+	return config.RoutesFunction + routeTableFuncSuffix
+}
+
+// routeTableParams returns the routes function parameters after mux, which
+// the route table function takes too.
+func routeTableParams(routesFunc *ast.FuncDecl) *ast.FieldList {
+	return &ast.FieldList{List: routesFunc.Type.Params.List[1:]}
+}
+
+// registerRouteTable is the routes function body with the route table:
+//
+//	for _, route := range TemplateRoutesTable(receiver, ...) {
+//		mux.Handle(route.Pattern, route.Handler)
+//	}
+func registerRouteTable(config RoutesFileConfiguration, routesFunc *ast.FuncDecl) ast.Stmt {
+	var args []ast.Expr
+	for _, param := range routesFunc.Type.Params.List[1:] {
+		for _, name := range param.Names {
+			args = append(args, ast.NewIdent(name.Name))
+		}
+	}
+	routeField := func(name string) ast.Expr {
+		return &ast.SelectorExpr{X: ast.NewIdent(routeTableRouteIdent), Sel: ast.NewIdent(name)}
+	}
+	return &ast.RangeStmt{
+		Key:   ast.NewIdent("_"),
+		Value: ast.NewIdent(routeTableRouteIdent),
+		Tok:   token.DEFINE,
+		X:     &ast.CallExpr{Fun: ast.NewIdent(routeTableFuncName(config)), Args: args},
+		Body: &ast.BlockStmt{List: []ast.Stmt{&ast.ExprStmt{X: methodCall(ast.NewIdent(muxVarIdent), httpHandleIdent,
+			routeField(routeFieldPattern),
+			routeField(routeFieldHandler),
+		)}}},
+	}
+}
+
+// routeTableFunc declares the function returning a Route for each definition,
+// in registration order. It takes the routes function parameters after mux:
+//
+//	func TemplateRoutesTable(receiver RoutesReceiver, ...) []Route {
+//		pathsPrefix := ""
+//		routes := make([]Route, 0, 1)
+//		routes = append(routes, Route{Pattern: "GET /{$}", Method: "GET", Path: "/{$}", Identifier: "ReadIndex", SourceFile: "index.gohtml", Handler: ReadIndexHandler(receiver, pathsPrefix)})
+//		return routes
+//	}
+//
+// With the middleware parameter each Handler is wrapped in middleware.
+func routeTableFunc(file *File, config RoutesFileConfiguration, params *ast.FieldList, defs []muxt.Definition) (*ast.FuncDecl, error) {
+	body := &ast.BlockStmt{}
+	if !config.PathPrefix {
+		body.List = append(body.List, singleAssignment(token.DEFINE, ast.NewIdent(pathPrefixPathsStructFieldName))(astgen.String("")))
+	}
+	if config.Middleware {
+		body.List = append(body.List, middlewareNilGuard(file))
+	}
+	body.List = append(body.List, singleAssignment(token.DEFINE, ast.NewIdent(routeTableRoutesIdent))(
+		astgen.CallBuiltin("make", &ast.ArrayType{Elt: ast.NewIdent(routeTypeName)}, astgen.Int(0), astgen.Int(len(defs))),
+	))
+	for _, def := range defs {
+		ident, err := def.ExportedPathIdentifier()
+		if err != nil {
+			return nil, err
+		}
+		pattern, pathExpr := routePatternExpressions(file, config, def)
+		handler := ast.Expr(&ast.CallExpr{Fun: ast.NewIdent(ident + routeHandlerSuffix), Args: routeHandlerArgs(config)})
+		if config.Middleware {
+			handler = &ast.CallExpr{Fun: ast.NewIdent(middlewareParamName), Args: []ast.Expr{handler}}
+		}
+		route := &ast.CompositeLit{Type: ast.NewIdent(routeTypeName), Elts: []ast.Expr{
+			&ast.KeyValueExpr{Key: ast.NewIdent(routeFieldPattern), Value: pattern},
+			&ast.KeyValueExpr{Key: ast.NewIdent(routeFieldMethod), Value: astgen.String(def.HTTPMethod())},
+			&ast.KeyValueExpr{Key: ast.NewIdent(routeFieldPath), Value: pathExpr},
+			&ast.KeyValueExpr{Key: ast.NewIdent(routeFieldIdentifier), Value: astgen.String(ident)},
+			&ast.KeyValueExpr{Key: ast.NewIdent(routeFieldSourceFile), Value: astgen.String(templateSourceFile(def))},
+			&ast.KeyValueExpr{Key: ast.NewIdent(routeFieldHandler), Value: handler},
+		}}
+		body.List = append(body.List, singleAssignment(token.ASSIGN, ast.NewIdent(routeTableRoutesIdent))(
+			astgen.CallBuiltinAppend(ast.NewIdent(routeTableRoutesIdent), route),
+		))
+	}
+	body.List = append(body.List, &ast.ReturnStmt{Results: []ast.Expr{ast.NewIdent(routeTableRoutesIdent)}})
+	return &ast.FuncDecl{
+		Name: ast.NewIdent(routeTableFuncName(config)),
+		Type: &ast.FuncType{
+			Params:  params,
+			Results: &ast.FieldList{List: []*ast.Field{{Type: &ast.ArrayType{Elt: ast.NewIdent(routeTypeName)}}}},
+		},
+		Body: body,
+	}, nil
+}
+
+// routeHandlerParams are the parameters of a handler constructor: the
+// receiver and the routes function parameters its handler reads.
+func routeHandlerParams(file *File, config RoutesFileConfiguration, receiverInterfaceName string) *ast.FieldList {
+	params := &ast.FieldList{List: []*ast.Field{
+		{Names: []*ast.Ident{ast.NewIdent(receiverIdent)}, Type: ast.NewIdent(receiverInterfaceName)},
+	}}
+	if config.Logger {
+		params.List = append(params.List, &ast.Field{Names: []*ast.Ident{ast.NewIdent("logger")}, Type: astgen.SlogLoggerPtr(file)})
+	}
+	params.List = append(params.List, &ast.Field{Names: []*ast.Ident{ast.NewIdent(pathPrefixPathsStructFieldName)}, Type: ast.NewIdent("string")})
+	if config.ResponseCache {
+		params.List = append(params.List, responseCacheField())
+	}
+	return params
+}
+
+func routeHandlerArgs(config RoutesFileConfiguration) []ast.Expr {
+	args := []ast.Expr{ast.NewIdent(receiverIdent)}
+	if config.Logger {
+		args = append(args, ast.NewIdent("logger"))
+	}
+	args = append(args, ast.NewIdent(pathPrefixPathsStructFieldName))
+	if config.ResponseCache {
+		args = append(args, ast.NewIdent(responseCacheParamName))
+	}
+	return args
+}
+
+// routeHandlerConstructor declares the exported function returning the
+// handler for def, named after its TemplateRoutePaths method:
+//
+//	func IndexHandler(receiver RoutesReceiver, pathsPrefix string) http.Handler {
+//		bytesBufferPool := sync.Pool{...}
+//		routeTemplate := templates.Lookup(name)
+//		...
+//		return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+//			...
+//		})
+//	}
+//
+// The templates are looked up, and a pre-rendered route rendered, when the
+// constructor runs.
+func routeHandlerConstructor(file *File, config RoutesFileConfiguration, def muxt.Definition, receiverInterfaceName string) (*ast.FuncDecl, error) {
+	ident, err := def.ExportedPathIdentifier()
+	if err != nil {
+		return nil, err
+	}
+	var (
+		setup   []ast.Stmt
+		handler *ast.FuncLit
+	)
+	switch {
+	case prerendered(config, def):
+		setup, handler = prerenderedHandler(file, config, def, receiverInterfaceName)
+	case def.FunctionIdentifier() == nil:
+		handler = noReceiverMethodCall(file, def, config, receiverInterfaceName)
+		setup = lookupTemplateStatements(def, handler)
+	default:
+		handler, err = callHandlerFunc(file, config, def, receiverInterfaceName)
+		if err != nil {
+			return nil, err
+		}
+		setup = lookupTemplateStatements(def, handler)
+	}
+	var body []ast.Stmt
+	if referencesIdent(handler, bufferPoolIdent) {
+		body = append(body, bytesBufferPoolDeclaration(file))
+	}
+	body = append(body, setup...)
+	body = append(body, &ast.ReturnStmt{Results: []ast.Expr{&ast.CallExpr{
+		Fun:  astgen.ExportedIdentifier(file, "http", "net/http", "HandlerFunc"),
+		Args: []ast.Expr{handler},
+	}}})
+	return &ast.FuncDecl{
+		Name: ast.NewIdent(ident + routeHandlerSuffix),
+		Type: &ast.FuncType{
+			Params:  routeHandlerParams(file, config, receiverInterfaceName),
+			Results: &ast.FieldList{List: []*ast.Field{{Type: astgen.HTTPHandler(file)}}},
+		},
+		Body: &ast.BlockStmt{List: body},
+	}, nil
+}
+
+// routePatternExpressions returns the expressions for the pattern a handler is
+// registered with and its path. With the path prefix parameter the path is
+// joined to pathsPrefix when the routes function runs.
+func routePatternExpressions(file *File, config RoutesFileConfiguration, def muxt.Definition) (ast.Expr, ast.Expr) {
+	normalized := def.Pattern()
+	i := strings.Index(normalized, "/")
+	if !config.PathPrefix {
+		return astgen.String(normalized), astgen.String(normalized[i:])
+	}
+	joined := func() ast.Expr {
+		return astgen.Call(file, "path", "path", "Join", ast.NewIdent(pathPrefixPathsStructFieldName), astgen.String(normalized[i:]))
+	}
+	return &ast.BinaryExpr{X: astgen.String(normalized[:i]), Op: token.ADD, Y: joined()}, joined()
+}
+
+// templateSourceFile returns the file name the definition's template was
+// parsed from, or "" for a template added with Parse.
+func templateSourceFile(def muxt.Definition) string {
+	if !isTemplateSourceFile(def.SourceFile()) {
+		return ""
+	}
+	return filepath.Base(def.SourceFile())
+}
+
+func referencesIdent(node ast.Node, name string) bool {
+	found := false
+	ast.Inspect(node, func(node ast.Node) bool {
+		if ident, ok := node.(*ast.Ident); ok && ident.Name == name {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
@@ -64,6 +64,7 @@ type RoutesFileConfiguration struct {
 	ReceiverInterface,
 	TemplateDataType,
 	SSETemplateDataType,
+	WebSocketTemplateDataType,
 	TemplateRoutePathsTypeName string
 	TemplatesVariables               []string
 	OutputFileName                   string
@@ -77,6 +78,28 @@ type RoutesFileConfiguration struct {
 	// MultipartMaxMemory is the maxMemory value passed to request.ParseMultipartForm.
 	// Defaults to 32 MiB when zero.
 	MultipartMaxMemory int64
+	// PanicRecovery makes each generated handler recover a panic, log it
+	// with the route pattern, and render the route template with a 500.
+	PanicRecovery bool
+	// PrerenderStaticRoutes renders static call-less routes once when the
+	// routes function runs and serves the bytes with an ETag.
+	PrerenderStaticRoutes bool
+	// ResponseCache adds a ResponseCache parameter to the routes function.
+	// GET handlers whose result has a CacheKey method store and serve the
+	// rendered response through it.
+	ResponseCache bool
+	// RouteTable declares a handler constructor per route and a function
+	// returning the Route table. The routes function registers the table on
+	// any RouteMux.
+	RouteTable bool
+	// WebSocketAllowCrossOrigin upgrades ws routes without checking the
+	// Origin header. By default the handshake rejects an Origin whose host
+	// is not the request host.
+	WebSocketAllowCrossOrigin bool
+	// Parsers are func(string) (T, error) references ("import/path.Func" or
+	// "Func" in the routes package) used to parse path values, lastEventID,
+	// and form fields of type T.
+	Parsers []string
 }
 
 // DefaultMultipartMaxMemory is the default maxMemory value passed to
@@ -97,6 +120,7 @@ func TemplateRoutesFiles(wd string, config RoutesFileConfiguration, fileSet *tok
 	config.PackagePath = routesPkg.PkgPath
 	config.PackageName = routesPkg.Name
 	config.SSETemplateDataType = cmp.Or(config.SSETemplateDataType, "SSETemplateData")
+	config.WebSocketTemplateDataType = cmp.Or(config.WebSocketTemplateDataType, "WebSocketTemplateData")
 
 	var receiver *types.Named
 	if config.ReceiverType == "" {
@@ -120,13 +144,18 @@ func TemplateRoutesFiles(wd string, config RoutesFileConfiguration, fileSet *tok
 	)
 	slices.Sort(templateSourceFiles)
 
+	muxField := httpServeMuxField(file)
+	if config.RouteTable {
+		muxField = routeMuxField()
+	}
+
 	// Build main routes function
 	routesFunc := &ast.FuncDecl{
 		Name: ast.NewIdent(config.RoutesFunction),
 		Type: &ast.FuncType{
 			Params: &ast.FieldList{
 				List: []*ast.Field{
-					httpServeMuxField(file),
+					muxField,
 					{
 						Names: []*ast.Ident{ast.NewIdent(receiverIdent)},
 						Type:  ast.NewIdent(config.ReceiverInterface),
@@ -163,12 +192,18 @@ func TemplateRoutesFiles(wd string, config RoutesFileConfiguration, fileSet *tok
 			Names: []*ast.Ident{ast.NewIdent(middlewareParamName)},
 			Type:  astgen.HTTPMiddlewareFuncType(file),
 		})
-		routesFunc.Body.List = append(routesFunc.Body.List, middlewareNilGuard(file))
+		if !config.RouteTable {
+			routesFunc.Body.List = append(routesFunc.Body.List, middlewareNilGuard(file))
+		}
+	}
+	if config.ResponseCache {
+		routesFunc.Type.Params.List = append(routesFunc.Type.Params.List, responseCacheField())
 	}
 
 	var (
 		topLevelTemplateRoutes []muxt.Definition
 		generatedFiles         []GeneratedFile
+		routeTableDefs         []muxt.Definition
 	)
 	if config.OutputMultipleFiles {
 		files, err := sourceFileRouteFunctionFiles(wd, config, templateSourceFiles, groups, logger, file, receiver, routesPkg, receiverInterface, routesFunc)
@@ -177,22 +212,39 @@ func TemplateRoutesFiles(wd string, config RoutesFileConfiguration, fileSet *tok
 		}
 		generatedFiles = append(generatedFiles, files...)
 		topLevelTemplateRoutes = groups.noFile
+		for _, sourceFile := range templateSourceFiles {
+			routeTableDefs = append(routeTableDefs, groups.byFile[sourceFile]...)
+		}
 	} else {
 		topLevelTemplateRoutes = groups.all
 	}
+	routeTableDefs = append(routeTableDefs, topLevelTemplateRoutes...)
 
-	if len(topLevelTemplateRoutes) > 0 {
+	if len(topLevelTemplateRoutes) > 0 && !config.RouteTable {
 		routesFunc.Body.List = append(routesFunc.Body.List, bytesBufferPoolDeclaration(file))
 	}
 
 	// Generate handlers for parse-based templates (empty sourceFile)
-	if err := hydrateGroup(topLevelTemplateRoutes, file, receiver, routesPkg.Types, receiverInterface); err != nil {
+	if err := hydrateGroup(topLevelTemplateRoutes, file, config, receiver, routesPkg.Types, receiverInterface); err != nil {
 		return nil, err
 	}
+	var routeHandlerDecls []ast.Decl
 	for _, def := range topLevelTemplateRoutes {
 		if config.Verbose {
 			logger.Printf("generating handler for pattern %s", def.RawPattern())
 		}
+		if config.RouteTable {
+			decl, err := routeHandlerConstructor(file, config, def, config.ReceiverInterface)
+			if err != nil {
+				return nil, err
+			}
+			routeHandlerDecls = append(routeHandlerDecls, decl)
+			continue
+		}
+		if prerendered(config, def) {
+			routesFunc.Body.List = append(routesFunc.Body.List, prerenderedRoute(file, config, def, config.ReceiverInterface))
+			continue
+		}
 		if def.FunctionIdentifier() == nil {
 			handlerFunc := noReceiverMethodCall(file, def, config, config.ReceiverInterface)
 			call := callHandleFunc(file, def, handlerFunc, config)
@@ -211,6 +263,14 @@ func TemplateRoutesFiles(wd string, config RoutesFileConfiguration, fileSet *tok
 	if err != nil {
 		return nil, err
 	}
+	var routeTableFuncDecl *ast.FuncDecl
+	if config.RouteTable {
+		routeTableFuncDecl, err = routeTableFunc(file, config, routeTableParams(routesFunc), routeTableDefs)
+		if err != nil {
+			return nil, err
+		}
+		routesFunc.Body.List = append(routesFunc.Body.List, registerRouteTable(config, routesFunc))
+	}
 	routesFunc.Body.List = append(routesFunc.Body.List, &ast.ReturnStmt{
 		Results: []ast.Expr{
 			&ast.CompositeLit{
@@ -244,6 +304,13 @@ func TemplateRoutesFiles(wd string, config RoutesFileConfiguration, fileSet *tok
 
 		// func routes
 		routesFunc,
+	}
+	if config.RouteTable {
+		decls = append(decls, routeTableDecls(file)...)
+		decls = append(decls, routeTableFuncDecl)
+		decls = append(decls, routeHandlerDecls...)
+	}
+	decls = append(decls,
 
 		templateDataType(file, config.TemplateDataType, ast.NewIdent(config.ReceiverInterface)),
 		templateDataMuxtVersionMethod(config),
@@ -254,13 +321,31 @@ func TemplateRoutesFiles(wd string, config RoutesFileConfiguration, fileSet *tok
 		templateDataHeaderMethod(config.TemplateDataType),
 		templateDataOkay(config.TemplateDataType),
 		templateDataError(file, config.TemplateDataType),
+		templateDataFieldErrorsMethod(config.TemplateDataType),
+		templateDataFieldErrorMethod(config.TemplateDataType),
+		templateDataAppendFieldErrorMethod(config.TemplateDataType),
+		templateDataResponseStatusCodeMethod(file, config.TemplateDataType),
+		templateDataWriteResponseMethod(file, config.TemplateDataType),
+		templateDataWriteRedirectMethod(file, config.TemplateDataType),
+		templateDataExecuteTemplateFailedMethod(file, config),
 		templateDataReceiver(ast.NewIdent(config.ReceiverInterface), config.TemplateDataType),
 		templateRedirect(file, config),
-	}
+	)
 	for _, method := range templateRedirectHelperMethods(file, config) {
 		decls = append(decls, method)
 	}
+	for _, method := range templateDataFormMethods(file, config.TemplateDataType) {
+		decls = append(decls, method)
+	}
+	// TemplateDataForm is only needed when a route template reads a parsed form.
+	if slices.ContainsFunc(groups.all, func(definition muxt.Definition) bool {
+		_, ok := templateDataFormArgument(definition.Arguments)
+		return ok && definition.Representation != muxt.RepresentationSSE && definition.Representation != muxt.RepresentationWebSocket
+	}) {
+		decls = append(decls, templateDataFormDecls(config.TemplateDataType)...)
+	}
 	decls = append(decls, templateDataStringMethod(config.TemplateDataType))
+	decls = append(decls, templateDataFieldErrorDecls(config.TemplateDataType)...)
 	if config.HTMXHelpers {
 		for _, method := range templateDataHTMXHelperMethods(config.TemplateDataType) {
 			decls = append(decls, method)
@@ -273,6 +358,14 @@ func TemplateRoutesFiles(wd string, config RoutesFileConfiguration, fileSet *tok
 	}) {
 		decls = append(decls, sseTemplateDataDecls(file, config)...)
 	}
+	if slices.ContainsFunc(groups.all, func(definition muxt.Definition) bool {
+		return definition.Representation == muxt.RepresentationWebSocket
+	}) {
+		decls = append(decls, webSocketTemplateDataDecls(file, config)...)
+	}
+	if config.ResponseCache {
+		decls = append(decls, responseCacheDecls(file)...)
+	}
 	decls = append(decls, routePathDecls...)
 	outputFile := &ast.File{
 		Name:  ast.NewIdent(config.PackageName),
@@ -291,14 +384,23 @@ func TemplateRoutesFiles(wd string, config RoutesFileConfiguration, fileSet *tok
 	return generatedFiles, nil
 }
 
-func hydrateGroup(defs []muxt.Definition, file *File, receiver *types.Named, templatesPackage *types.Package, receiverInterface *ast.InterfaceType) error {
+func hydrateGroup(defs []muxt.Definition, file *File, config RoutesFileConfiguration, receiver *types.Named, templatesPackage *types.Package, receiverInterface *ast.InterfaceType) error {
+	parsers, err := muxt.NewParsers(file.Packages(), config.Parsers, templatesPackage.Path())
+	if err != nil {
+		return err
+	}
 	for i := range defs {
+		if err := muxt.ResolveCall(&defs[i], templatesPackage, receiver, file.Packages(), parsers); err != nil {
+			return err
+		}
+		if fn := defs[i].Authorize(); fn != nil {
+			if err := accumulateReceiverMethods(fn.Name(), fn.Type().(*types.Signature), true, nil, file, receiverInterface); err != nil {
+				return err
+			}
+		}
 		if defs[i].FunctionIdentifier() == nil {
 			continue
 		}
-		if err := muxt.ResolveCall(&defs[i], templatesPackage, receiver, file.Packages()); err != nil {
-			return err
-		}
 		if err := accumulateReceiverMethods(defs[i].FunctionIdentifier().Name, defs[i].Signature(), defs[i].IsMethod(), defs[i].Arguments, file, receiverInterface); err != nil {
 			return err
 		}
@@ -311,11 +413,15 @@ func accumulateReceiverMethods(name string, sig *types.Signature, isMethod bool,
 	// receiver method: a package-scope function may receive nested receiver
 	// method calls that must appear in the interface.
 	for _, a := range args {
-		if a.Type != muxt.ArgumentTypeCall {
-			continue
-		}
-		if err := accumulateReceiverMethods(a.Identifier, a.Signature(), a.IsMethod(), a.Arguments(), file, receiverInterface); err != nil {
-			return err
+		switch a.Type {
+		case muxt.ArgumentTypeCall:
+			if err := accumulateReceiverMethods(a.Identifier, a.Signature(), a.IsMethod(), a.Arguments(), file, receiverInterface); err != nil {
+				return err
+			}
+		case muxt.ArgumentTypeProvider:
+			if err := accumulateReceiverMethods(a.Provider(), a.Signature(), true, nil, file, receiverInterface); err != nil {
+				return err
+			}
 		}
 	}
 	if !isMethod {
@@ -373,6 +479,10 @@ func sourceFileRouteFunctionFiles(wd string, config RoutesFileConfiguration, tem
 		receiverInterface.Methods.List = append(receiverInterface.Methods.List, &ast.Field{
 			Type: ast.NewIdent(receiverInterfaceName),
 		})
+		if config.RouteTable {
+			// The route table calls the handler constructors instead.
+			continue
+		}
 
 		callArgs := []ast.Expr{ast.NewIdent(muxParamName), ast.NewIdent(receiverIdent)}
 		if config.Logger {
@@ -383,6 +493,9 @@ func sourceFileRouteFunctionFiles(wd string, config RoutesFileConfiguration, tem
 		if config.Middleware {
 			callArgs = append(callArgs, ast.NewIdent(middlewareParamName))
 		}
+		if config.ResponseCache {
+			callArgs = append(callArgs, ast.NewIdent(responseCacheParamName))
+		}
 
 		routesFunc.Body.List = append(routesFunc.Body.List, &ast.ExprStmt{
 			X: &ast.CallExpr{
@@ -427,17 +540,11 @@ func middlewareNilGuard(file *File) ast.Stmt {
 	}
 }
 
-func callHandleFunc(file *File, def muxt.Definition, handlerFuncLit *ast.FuncLit, config RoutesFileConfiguration) *ast.ExprStmt {
-	normalized := def.Pattern()
-	pattern := ast.Expr(astgen.String(normalized))
-	if config.PathPrefix {
-		i := strings.Index(normalized, "/")
-		pattern = &ast.BinaryExpr{
-			X:  astgen.String(normalized[:i]),
-			Op: token.ADD,
-			Y:  astgen.Call(file, "path", "path", "Join", ast.NewIdent(pathPrefixPathsStructFieldName), astgen.String(normalized[i:])),
-		}
-	}
+// callHandleFunc registers the handler on the mux. The registration is wrapped
+// in a block that first resolves the templates the handler executes (see
+// lookupTemplateStatements).
+func callHandleFunc(file *File, def muxt.Definition, handlerFuncLit *ast.FuncLit, config RoutesFileConfiguration) ast.Stmt {
+	pattern, _ := routePatternExpressions(file, config, def)
 	method, handler := httpHandleFuncIdent, ast.Expr(handlerFuncLit)
 	if config.Middleware {
 		method = httpHandleIdent
@@ -449,13 +556,18 @@ func callHandleFunc(file *File, def muxt.Definition, handlerFuncLit *ast.FuncLit
 			}},
 		}
 	}
-	return &ast.ExprStmt{X: &ast.CallExpr{
+	call := &ast.ExprStmt{X: &ast.CallExpr{
 		Fun: &ast.SelectorExpr{
 			X:   ast.NewIdent(muxVarIdent),
 			Sel: ast.NewIdent(method),
 		},
 		Args: []ast.Expr{pattern, handler},
 	}}
+	lookups := lookupTemplateStatements(def, handlerFuncLit)
+	if len(lookups) == 0 {
+		return call
+	}
+	return &ast.BlockStmt{List: append(lookups, call)}
 }
 
 // generatePerFileRouteFunction creates a route registration function for templates from a specific source file.
@@ -508,6 +620,9 @@ func generatePerFileRouteFunction(
 			Type:  astgen.HTTPMiddlewareFuncType(file),
 		})
 	}
+	if config.ResponseCache {
+		routesFunc.Type.Params.List = append(routesFunc.Type.Params.List, responseCacheField())
+	}
 
 	// Declare the buffer pool shared by this file's handlers.
 	if len(defs) > 0 {
@@ -515,7 +630,7 @@ func generatePerFileRouteFunction(
 	}
 
 	// Generate handlers for each template
-	if err := hydrateGroup(defs, file, receiver, routesPkg.Types, receiverInterface); err != nil {
+	if err := hydrateGroup(defs, file, config, receiver, routesPkg.Types, receiverInterface); err != nil {
 		return nil, err
 	}
 	for i := range defs {
@@ -523,6 +638,10 @@ func generatePerFileRouteFunction(
 		if config.Verbose {
 			logger.Printf("generating handler for pattern %s in %s", t.RawPattern(), sourceFile)
 		}
+		if prerendered(config, t) {
+			routesFunc.Body.List = append(routesFunc.Body.List, prerenderedRoute(file, config, t, receiverInterfaceName))
+			continue
+		}
 		if t.FunctionIdentifier() == nil {
 			handlerFunc := noReceiverMethodCall(file, t, config, receiverInterfaceName)
 			call := callHandleFunc(file, t, handlerFunc, config)
@@ -560,21 +679,40 @@ func generatePerFileAST(
 		Methods: new(ast.FieldList),
 	}
 
-	// Generate the route function
-	routesFunc, err := generatePerFileRouteFunction(
-		sourceFile,
-		defs,
-		file,
-		funcName,
-		receiverInterfaceName,
-		logger,
-		config,
-		receiver,
-		scopedReceiverInterface,
-		routesPkg,
-	)
-	if err != nil {
-		return nil, err
+	// Generate the route function, or the handler constructors for the
+	// route table
+	var routeDecls []ast.Decl
+	if config.RouteTable {
+		if err := hydrateGroup(defs, file, config, receiver, routesPkg.Types, scopedReceiverInterface); err != nil {
+			return nil, err
+		}
+		for _, def := range defs {
+			if config.Verbose {
+				logger.Printf("generating handler for pattern %s in %s", def.RawPattern(), sourceFile)
+			}
+			decl, err := routeHandlerConstructor(file, config, def, receiverInterfaceName)
+			if err != nil {
+				return nil, err
+			}
+			routeDecls = append(routeDecls, decl)
+		}
+	} else {
+		routesFunc, err := generatePerFileRouteFunction(
+			sourceFile,
+			defs,
+			file,
+			funcName,
+			receiverInterfaceName,
+			logger,
+			config,
+			receiver,
+			scopedReceiverInterface,
+			routesPkg,
+		)
+		if err != nil {
+			return nil, err
+		}
+		routeDecls = append(routeDecls, routesFunc)
 	}
 
 	// Get import specs
@@ -587,7 +725,7 @@ func generatePerFileAST(
 	// Build the output file
 	outputFile := &ast.File{
 		Name: ast.NewIdent(config.PackageName),
-		Decls: []ast.Decl{
+		Decls: append([]ast.Decl{
 			// imports
 			&ast.GenDecl{
 				Tok:   token.IMPORT,
@@ -603,9 +741,7 @@ func generatePerFileAST(
 					},
 				},
 			},
-			// routes function
-			routesFunc,
-		},
+		}, routeDecls...),
 	}
 
 	return outputFile, nil
@@ -614,7 +750,6 @@ func generatePerFileAST(
 func noReceiverMethodCall(file *File, def muxt.Definition, config RoutesFileConfiguration, receiverInterfaceName string) *ast.FuncLit {
 	const (
 		bufIdent             = "buf"
-		statusCodeIdent      = "statusCode"
 		templateDataVarIdent = "td"
 	)
 	handlerFunc := &ast.FuncLit{
@@ -642,11 +777,14 @@ func noReceiverMethodCall(file *File, def muxt.Definition, config RoutesFileConf
 		},
 	}
 
+	handlerFunc.Body.List = append(routeOptionStatements(file, def), handlerFunc.Body.List...)
+	handlerFunc.Body.List = append(handlerFunc.Body.List, recoverPanicStatements(file, config, def, templateDataVarIdent, types.NewStruct(nil, nil))...)
+	handlerFunc.Body.List = append(handlerFunc.Body.List, authorizeStatements(file, def, templateDataVarIdent)...)
 	handlerFunc.Body.List = append(handlerFunc.Body.List, astgen.GetBufferFromPool(file, bufferPoolIdent, bufIdent)...)
 
 	callExecuteTemplate(file, config, def, handlerFunc, bufIdent, templateDataVarIdent)
 
-	handlerFunc.Body.List = append(handlerFunc.Body.List, writeStatusAndHeaders(file, def, types.NewStruct(nil, nil), def.DefaultStatusCode(), statusCodeIdent, bufIdent, templateDataVarIdent, func() ast.Expr {
+	handlerFunc.Body.List = append(handlerFunc.Body.List, writeStatusAndHeaders(file, def, types.NewStruct(nil, nil), bufIdent, templateDataVarIdent, func() ast.Expr {
 		panic("when no receiver method is called, then the result variable should not be needed")
 	})...)
 	return handlerFunc
@@ -655,7 +793,6 @@ func noReceiverMethodCall(file *File, def muxt.Definition, config RoutesFileConf
 func callHandlerFunc(file *File, config RoutesFileConfiguration, def muxt.Definition, receiverInterfaceName string) (*ast.FuncLit, error) {
 	const (
 		bufIdent        = "buf"
-		statusCodeIdent = "statusCode"
 		resultDataIdent = "td"
 	)
 	sig := def.Signature()
@@ -665,8 +802,10 @@ func callHandlerFunc(file *File, config RoutesFileConfiguration, def muxt.Defini
 	switch def.Representation {
 	case muxt.RepresentationSSE:
 		return sseMethodHandlerFunc(file, config, def, sig, receiverInterfaceName)
+	case muxt.RepresentationWebSocket:
+		return webSocketMethodHandlerFunc(file, config, def, sig, receiverInterfaceName)
 	default:
-		return executeHTMLTemplateHandler(file, config, def, sig, resultDataIdent, receiverInterfaceName, bufIdent, statusCodeIdent)
+		return executeHTMLTemplateHandler(file, config, def, sig, resultDataIdent, receiverInterfaceName, bufIdent)
 	}
 }
 
@@ -682,44 +821,23 @@ func appendTemplateDataError(_ *File, tdIdent string, err ast.Expr) *ast.BlockSt
 	}
 }
 
-func writeBodyAndWriteHeadersFunc(file *File, bufIdent, statusCodeIdent string) []ast.Stmt {
-	return []ast.Stmt{
-		setContentTypeHeaderSetOnTemplateData(),
-		&ast.ExprStmt{X: &ast.CallExpr{
-			Fun:  &ast.SelectorExpr{X: &ast.CallExpr{Fun: &ast.SelectorExpr{X: ast.NewIdent(muxt.TemplateNameScopeIdentifierHTTPResponse), Sel: ast.NewIdent("Header")}, Args: []ast.Expr{}}, Sel: ast.NewIdent("Set")},
-			Args: []ast.Expr{astgen.String("content-length"), astgen.StrconvItoaCall(file, &ast.CallExpr{Fun: &ast.SelectorExpr{X: ast.NewIdent(bufIdent), Sel: ast.NewIdent("Len")}, Args: []ast.Expr{}})},
-		}},
-		callWriteHeader(ast.NewIdent(statusCodeIdent)),
-		callWriteOnResponse(bufIdent),
-	}
-}
-
-func callWriteHeader(statusCode ast.Expr) *ast.ExprStmt {
-	return &ast.ExprStmt{X: &ast.CallExpr{
-		Fun:  &ast.SelectorExpr{X: ast.NewIdent(muxt.TemplateNameScopeIdentifierHTTPResponse), Sel: ast.NewIdent("WriteHeader")},
-		Args: []ast.Expr{statusCode},
-	}}
-}
-
-func checkExecuteTemplateError(file *File, withLogger bool, pattern string) *ast.IfStmt {
-	var logStmts []ast.Stmt
+// checkExecuteTemplateError builds the check after a route template renders:
+//
+//	if err != nil {
+//		td.executeTemplateFailed(err) // or (logger, "GET /", err)
+//		return
+//	}
+func checkExecuteTemplateError(withLogger bool, pattern, dataIdent string) *ast.IfStmt {
+	var args []ast.Expr
 	if withLogger {
-		logStmts = []ast.Stmt{
-			&ast.ExprStmt{X: loggerErrorCall(file, executeTemplateErrorMessage, pattern, errIdent)},
-		}
-	} else {
-		logStmts = []ast.Stmt{
-			&ast.ExprStmt{X: executeTemplateFailedLogLine(file, executeTemplateErrorMessage, errIdent)},
-		}
+		args = append(args, ast.NewIdent("logger"), astgen.String(pattern))
 	}
 	return &ast.IfStmt{
 		Cond: &ast.BinaryExpr{X: ast.NewIdent(errIdent), Op: token.NEQ, Y: astgen.Nil()},
-		Body: &ast.BlockStmt{
-			List: append(logStmts,
-				&ast.ExprStmt{X: astgen.HTTPErrorCall(file, ast.NewIdent(muxt.TemplateNameScopeIdentifierHTTPResponse), astgen.String(executeTemplateErrorMessage), http.StatusInternalServerError)},
-				&ast.ReturnStmt{},
-			),
-		},
+		Body: &ast.BlockStmt{List: []ast.Stmt{
+			&ast.ExprStmt{X: methodCall(ast.NewIdent(dataIdent), templateDataExecuteTemplateFailedMethodName, append(args, ast.NewIdent(errIdent))...)},
+			&ast.ReturnStmt{},
+		}},
 	}
 }
 
@@ -801,10 +919,18 @@ func appendParseArgumentStatements(statements []ast.Stmt, def muxt.Definition, f
 
 			statements = append(parseArgStatements, nestedCall.DefineStmts()...)
 		case *ast.Ident:
-			if arg.Name == muxt.TemplateNameScopeIdentifierExecute || muxt.IsSSEArgument(arg.Name) {
-				// The render callback (execute/sse/sse-prefixed) is validated and
-				// wired into the call in methodHandlerFunc. It is not parsed from
-				// the request.
+			if args[i].Type == muxt.ArgumentTypeProvider {
+				if _, ok := parsed[arg.Name]; !ok {
+					parsed[arg.Name] = struct{}{}
+					statements = append(statements, providerCallStatements(file, args[i], rdIdent)...)
+				}
+				continue
+			}
+			if arg.Name == muxt.TemplateNameScopeIdentifierExecute || muxt.IsSSEArgument(arg.Name) ||
+				(arg.Name == muxt.TemplateNameScopeIdentifierReceive && def.Representation == muxt.RepresentationWebSocket) {
+				// The render callback (execute/sse/sse-prefixed) and the ws
+				// receive callback are validated and wired into the call in
+				// methodHandlerFunc. They are not parsed from the request.
 				continue
 			}
 			argType, ok := muxt.DefaultScopeType(file.Packages(), &def, arg.Name)
@@ -821,13 +947,13 @@ func appendParseArgumentStatements(statements []ast.Stmt, def muxt.Definition, f
 						if err != nil {
 							return nil, err
 						}
-						statements = append(statements, callParseForm(), declareFormVar)
+						statements = append(statements, callParseForm(file, def, rdIdent), declareFormVar)
 					case muxt.TemplateNameScopeIdentifierMultipart:
 						declareMultipartVar, err := multipartVariableAssignment(file, arg, param.Type())
 						if err != nil {
 							return nil, err
 						}
-						statements = append(statements, callParseMultipartForm(file, config, rdIdent), declareMultipartVar)
+						statements = append(statements, callParseMultipartForm(file, config, def, rdIdent), declareMultipartVar)
 					case muxt.TemplateNameScopeIdentifierContext:
 						statements = append(statements, contextAssignment(muxt.TemplateNameScopeIdentifierContext))
 					default:
@@ -844,7 +970,7 @@ func appendParseArgumentStatements(statements []ast.Stmt, def muxt.Definition, f
 			switch {
 			case slices.Contains(def.PathValueIdentifiers(), arg.Name):
 				parsed[arg.Name] = struct{}{}
-				s, err := generateParseValueFromStringStatements(file, def, arg.Name+"Parsed", resultType, src, param.Type(), nil, singleAssignment(token.DEFINE, ast.NewIdent(arg.Name)), parseErrBlock())
+				s, err := generateParseValueFromStringStatements(file, def, arg.Name+"Parsed", resultType, src, param.Type(), nil, singleAssignment(token.DEFINE, ast.NewIdent(arg.Name)), parseErrBlock(), "")
 				if err != nil {
 					return nil, err
 				}
@@ -852,20 +978,20 @@ func appendParseArgumentStatements(statements []ast.Stmt, def muxt.Definition, f
 				def.SetArgumentType(arg.Name, param.Type())
 			case arg.Name == muxt.TemplateNameScopeIdentifierLastEventID:
 				parsed[arg.Name] = struct{}{}
-				s, err := generateParseValueFromStringStatements(file, def, arg.Name+"Parsed", resultType, src, param.Type(), nil, singleAssignment(token.DEFINE, ast.NewIdent(arg.Name)), parseErrBlock())
+				s, err := generateParseValueFromStringStatements(file, def, arg.Name+"Parsed", resultType, src, param.Type(), nil, singleAssignment(token.DEFINE, ast.NewIdent(arg.Name)), parseErrBlock(), "")
 				if err != nil {
 					return nil, err
 				}
 				statements = append(statements, s...)
 				def.SetArgumentType(arg.Name, param.Type())
 			case arg.Name == muxt.TemplateNameScopeIdentifierForm:
-				s, err := appendParseFormToStructStatements(statements, def, file, resultType, arg, args[i], validationFailureBlock, rdIdent)
+				s, err := appendParseFormToStructStatements(statements, def, file, resultType, arg, args[i], validationFailureBlock, parseErrBlock, rdIdent)
 				if err != nil {
 					return nil, err
 				}
 				statements = s
 			case arg.Name == muxt.TemplateNameScopeIdentifierMultipart:
-				s, err := appendParseMultipartFormToStructStatements(statements, def, file, resultType, arg, args[i], validationFailureBlock, rdIdent, config)
+				s, err := appendParseMultipartFormToStructStatements(statements, def, file, resultType, arg, args[i], validationFailureBlock, parseErrBlock, rdIdent, config)
 				if err != nil {
 					return nil, err
 				}
@@ -880,16 +1006,19 @@ func appendParseArgumentStatements(statements []ast.Stmt, def muxt.Definition, f
 	return statements, nil
 }
 
-func appendParseFormToStructStatements(statements []ast.Stmt, def muxt.Definition, file *File, resultType types.Type, arg *ast.Ident, argument muxt.Argument, validationBlock ValidationErrorBlock, rdIdent string) ([]ast.Stmt, error) {
-	return appendStructFieldParseStatements(statements, def, file, resultType, arg, argument, validationBlock, rdIdent, callParseForm())
+func appendParseFormToStructStatements(statements []ast.Stmt, def muxt.Definition, file *File, resultType types.Type, arg *ast.Ident, argument muxt.Argument, validationBlock ValidationErrorBlock, parseErrBlock func() *ast.BlockStmt, rdIdent string) ([]ast.Stmt, error) {
+	return appendStructFieldParseStatements(statements, def, file, resultType, arg, argument, validationBlock, parseErrBlock, rdIdent, callParseForm(file, def, rdIdent))
 }
 
 // appendStructFieldParseStatements renders the per-field parse statements for
 // a form or multipart struct parameter from the field bindings resolved by
-// muxt.ResolveCall. Used by both `form` (parseCall = callParseForm()) and
+// muxt.ResolveCall. Used by both `form` (parseCall = callParseForm(...)) and
 // `multipart` (parseCall = callParseMultipartForm(...)).
-func appendStructFieldParseStatements(statements []ast.Stmt, def muxt.Definition, file *File, resultType types.Type, arg *ast.Ident, argument muxt.Argument, validationBlock ValidationErrorBlock, rdIdent string, parseCall ast.Stmt) ([]ast.Stmt, error) {
-	const parsedVariableName = "value"
+//
+// Normal handlers record a field parse failure under the field's input name
+// (see TemplateData.FieldError); handlers without template data (rdIdent is
+// empty) fall back to parseErrBlock.
+func appendStructFieldParseStatements(statements []ast.Stmt, def muxt.Definition, file *File, resultType types.Type, arg *ast.Ident, argument muxt.Argument, validationBlock ValidationErrorBlock, parseErrBlock func() *ast.BlockStmt, rdIdent string, parseCall ast.Stmt) ([]ast.Stmt, error) {
 	statements = append(statements, parseCall)
 
 	declareVar, err := formVariableDeclaration(file, arg, argument.ParamType)
@@ -898,50 +1027,175 @@ func appendStructFieldParseStatements(statements []ast.Stmt, def muxt.Definition
 	}
 	statements = append(statements, declareVar)
 
-	for _, fb := range argument.FormFields() {
-		if fb.FileHeader {
+	fields := formFieldStatements{
+		def:             def,
+		file:            file,
+		resultType:      resultType,
+		argName:         arg.Name,
+		validationBlock: validationBlock,
+		parseErrBlock:   parseErrBlock,
+		rdIdent:         rdIdent,
+	}
+	statements, err = fields.append(statements, func() ast.Expr { return ast.NewIdent(arg.Name) }, argument.FormFields(), nil)
+	if err != nil || rdIdent == "" || argument.FormFields() == nil {
+		return statements, err
+	}
+	// The template reads the parsed values with .FormData (see templateDataFormDecls).
+	return append(statements, singleAssignment(token.ASSIGN, &ast.SelectorExpr{X: ast.NewIdent(rdIdent), Sel: ast.NewIdent(TemplateDataFieldIdentifierFormData)})(ast.NewIdent(arg.Name))), nil
+}
+
+// formFieldStatements renders the parse statements for the field bindings of
+// a form or multipart struct parameter.
+type formFieldStatements struct {
+	def             muxt.Definition
+	file            *File
+	resultType      types.Type
+	argName         string
+	validationBlock ValidationErrorBlock
+	parseErrBlock   func() *ast.BlockStmt
+	rdIdent         string
+}
+
+// inputName returns the expression for a binding's input name when it is
+// only known at request time: inside a slice of structs the input names are
+// relative to the element prefix ("items[0]."), and map values are named by
+// their key ("attrs[key]"). A nil inputName means the names are constant.
+type inputName func(name string) ast.Expr
+
+func (n inputName) expr(name string) ast.Expr {
+	if n == nil {
+		return astgen.String(name)
+	}
+	return n(name)
+}
+
+// concatString returns x + s, folding s into a trailing string literal.
+func concatString(x ast.Expr, s string) ast.Expr {
+	if lit, ok := x.(*ast.BasicLit); ok && lit.Kind == token.STRING {
+		v, _ := strconv.Unquote(lit.Value)
+		return astgen.String(v + s)
+	}
+	if bin, ok := x.(*ast.BinaryExpr); ok && bin.Op == token.ADD {
+		if lit, ok := bin.Y.(*ast.BasicLit); ok && lit.Kind == token.STRING {
+			v, _ := strconv.Unquote(lit.Value)
+			return &ast.BinaryExpr{X: bin.X, Op: token.ADD, Y: astgen.String(v + s)}
+		}
+	}
+	return &ast.BinaryExpr{X: x, Op: token.ADD, Y: astgen.String(s)}
+}
+
+// fieldError records err for the named input; see appendTemplateDataFieldError.
+func (g formFieldStatements) fieldError(name inputName, input, constraint string, err ast.Expr) *ast.BlockStmt {
+	if g.rdIdent == "" {
+		return g.parseErrBlock()
+	}
+	return appendTemplateDataFieldError(g.file, g.rdIdent, name.expr(input), constraint, err)
+}
+
+// validationErrorBlock returns the ValidationErrorBlock for inputs named by
+// name. Validation messages start with the (relative) input name, so for
+// request time names it is replaced with the full input name.
+func (g formFieldStatements) validationErrorBlock(name inputName) ValidationErrorBlock {
+	if name == nil || g.rdIdent == "" {
+		return g.validationBlock
+	}
+	return func(input, constraint, message string) *ast.BlockStmt {
+		msg := concatString(name(input), strings.TrimPrefix(message, input))
+		return g.fieldError(name, input, constraint, astgen.ErrorsNew(g.file, msg))
+	}
+}
+
+func (g formFieldStatements) append(statements []ast.Stmt, target func() ast.Expr, fields []muxt.FieldBinding, name inputName) ([]ast.Stmt, error) {
+	const parsedVariableName = "value"
+	validationBlock := g.validationErrorBlock(name)
+	for _, fb := range fields {
+		field := func() ast.Expr { return &ast.SelectorExpr{X: target(), Sel: ast.NewIdent(fb.Field.Name())} }
+		switch {
+		case fb.FileHeader:
 			if fb.Slice {
-				statements = append(statements, fileHeaderSliceAssignment(arg, fb.Field.Name(), fb.InputName))
+				statements = append(statements, fileHeaderSliceAssignment(field(), fb.InputName))
 			} else {
-				statements = append(statements, fileHeaderSingleAssignment(arg, fb.Field.Name(), fb.InputName))
+				statements = append(statements, fileHeaderSingleAssignment(field(), fb.InputName))
 			}
+			if len(fb.Validations) > 0 {
+				fileErrBlock := func(input, constraint, message string, statusCode int) *ast.BlockStmt {
+					if g.rdIdent == "" {
+						return g.parseErrBlock()
+					}
+					block := g.fieldError(name, input, constraint, astgen.ErrorsNew(g.file, astgen.String(message)))
+					block.List[len(block.List)-1] = assignTemplateDataErrStatusCode(g.file, g.rdIdent, statusCode)
+					return block
+				}
+				statements = append(statements, renderFileValidations(g.file, field(), fb.Slice, fb.Validations, fileErrBlock))
+			}
+			continue
+		case fb.Slice && fb.Fields != nil:
+			stmt, err := g.structSlice(field, fb)
+			if err != nil {
+				return nil, err
+			}
+			statements = append(statements, stmt)
+			continue
+		case fb.Fields != nil:
+			s, err := g.append(statements, field, fb.Fields, name)
+			if err != nil {
+				return nil, err
+			}
+			statements = s
+			continue
+		case fb.Map:
+			stmt, err := g.mapValues(field, fb)
+			if err != nil {
+				return nil, err
+			}
+			statements = append(statements, stmt)
 			continue
 		}
 
-		validations := renderValidations(file, ast.NewIdent(parsedVariableName), fb.Validations, validationBlock)
+		validations := renderValidations(g.file, ast.NewIdent(parsedVariableName), fb.Validations, validationBlock)
+		fieldParseErrBlock := func() *ast.BlockStmt {
+			return g.fieldError(name, fb.InputName, "parse", ast.NewIdent(errIdent))
+		}
 		if fb.Slice {
 			parseResult := func(expr ast.Expr) ast.Stmt {
 				return &ast.AssignStmt{
-					Lhs: []ast.Expr{&ast.SelectorExpr{X: ast.NewIdent(arg.Name), Sel: ast.NewIdent(fb.Field.Name())}},
+					Lhs: []ast.Expr{field()},
 					Tok: token.ASSIGN,
-					Rhs: []ast.Expr{astgen.CallBuiltinAppend(&ast.SelectorExpr{X: ast.NewIdent(arg.Name), Sel: ast.NewIdent(fb.Field.Name())}, expr)},
+					Rhs: []ast.Expr{astgen.CallBuiltinAppend(field(), expr)},
 				}
 			}
-			parseStatements, err := generateParseValueFromStringStatements(file, def, parsedVariableName, resultType, ast.NewIdent("val"), fb.Elem, validations, parseResult, templateDataParseErrBlock(file, rdIdent))
+			parseStatements, err := generateParseValueFromStringStatements(g.file, g.def, parsedVariableName, g.resultType, ast.NewIdent("val"), fb.Elem, validations, parseResult, fieldParseErrBlock(), fb.TimeLayout)
 			if err != nil {
-				return nil, fmt.Errorf("failed to generate parse statements for %s field %s: %w", arg.Name, fb.Field.Name(), err)
+				return nil, fmt.Errorf("failed to generate parse statements for %s field %s: %w", g.argName, fb.Field.Name(), err)
 			}
-			statements = append(statements, &ast.RangeStmt{
+			values := &ast.IndexExpr{X: &ast.SelectorExpr{X: ast.NewIdent(muxt.TemplateNameScopeIdentifierHTTPRequest), Sel: ast.NewIdent("Form")}, Index: name.expr(fb.InputName)}
+			var rangeValues ast.Stmt = &ast.RangeStmt{
 				Key:   ast.NewIdent("_"),
 				Value: ast.NewIdent("val"),
 				Tok:   token.DEFINE,
-				X:     &ast.IndexExpr{X: &ast.SelectorExpr{X: ast.NewIdent(muxt.TemplateNameScopeIdentifierHTTPRequest), Sel: ast.NewIdent("Form")}, Index: &ast.BasicLit{Kind: token.STRING, Value: strconv.Quote(fb.InputName)}},
+				X:     values,
 				Body:  &ast.BlockStmt{List: parseStatements},
-			})
+			}
+			if required := requiredValidation(g.file, values, fb.Validations, validationBlock, []ast.Stmt{rangeValues}); required != nil {
+				rangeValues = required
+			}
+			statements = append(statements, rangeValues)
 		} else {
 			parseResult := func(expr ast.Expr) ast.Stmt {
 				return &ast.AssignStmt{
-					Lhs: []ast.Expr{&ast.SelectorExpr{X: ast.NewIdent(arg.Name), Sel: ast.NewIdent(fb.Field.Name())}},
+					Lhs: []ast.Expr{field()},
 					Tok: token.ASSIGN,
 					Rhs: []ast.Expr{expr},
 				}
 			}
-			str := &ast.CallExpr{Fun: &ast.SelectorExpr{X: ast.NewIdent(muxt.TemplateNameScopeIdentifierHTTPRequest), Sel: ast.NewIdent("FormValue")}, Args: []ast.Expr{&ast.BasicLit{Kind: token.STRING, Value: strconv.Quote(fb.InputName)}}}
-			parseStatements, err := generateParseValueFromStringStatements(file, def, parsedVariableName, resultType, str, fb.Elem, validations, parseResult, templateDataParseErrBlock(file, rdIdent))
+			str := &ast.CallExpr{Fun: &ast.SelectorExpr{X: ast.NewIdent(muxt.TemplateNameScopeIdentifierHTTPRequest), Sel: ast.NewIdent("FormValue")}, Args: []ast.Expr{name.expr(fb.InputName)}}
+			parseStatements, err := generateParseValueFromStringStatements(g.file, g.def, parsedVariableName, g.resultType, str, fb.Elem, validations, parseResult, fieldParseErrBlock(), fb.TimeLayout)
 			if err != nil {
-				return nil, fmt.Errorf("failed to generate parse statements for %s field %s: %w", arg.Name, fb.Field.Name(), err)
+				return nil, fmt.Errorf("failed to generate parse statements for %s field %s: %w", g.argName, fb.Field.Name(), err)
 			}
-			if len(parseStatements) > 1 {
+			if required := requiredValidation(g.file, str, fb.Validations, validationBlock, parseStatements); required != nil {
+				statements = append(statements, required)
+			} else if len(parseStatements) > 1 {
 				statements = append(statements, &ast.BlockStmt{
 					List: parseStatements,
 				})
@@ -954,23 +1208,230 @@ func appendStructFieldParseStatements(statements []ast.Stmt, def muxt.Definition
 	return statements, nil
 }
 
+// structSlice binds a slice of structs field from the inputs named
+// <name>[<index>].<field>. Elements are appended in index order; gaps in the
+// submitted indexes are not preserved.
+//
+//	{
+//		var indexes []int
+//		for key := range request.Form {
+//			if rest, ok := strings.CutPrefix(key, "<name>["); ok {
+//				if i, _, ok := strings.Cut(rest, "]."); ok {
+//					if index, err := strconv.Atoi(i); err == nil && index >= 0 && strconv.Itoa(index) == i {
+//						indexes = append(indexes, index)
+//					}
+//				}
+//			}
+//		}
+//		slices.Sort(indexes)
+//		for _, index := range slices.Compact(indexes) {
+//			prefix := "<name>[" + strconv.Itoa(index) + "]."
+//			var elem <Elem>
+//			<field parse statements for "prefix + <field>">
+//			form.Field = append(form.Field, elem)
+//		}
+//	}
+func (g formFieldStatements) structSlice(field func() ast.Expr, fb muxt.FieldBinding) (ast.Stmt, error) {
+	const (
+		indexesIdent = "indexes"
+		indexIdent   = "index"
+		keyIdent     = "key"
+		restIdent    = "rest"
+		iIdent       = "i"
+		okIdent      = "ok"
+		prefixIdent  = "prefix"
+		elemIdent    = "elem"
+	)
+	elemType, err := g.file.TypeASTExpression(fb.Elem)
+	if err != nil {
+		return nil, err
+	}
+	fieldStatements, err := g.append(nil, func() ast.Expr { return ast.NewIdent(elemIdent) }, fb.Fields, func(name string) ast.Expr {
+		return &ast.BinaryExpr{X: ast.NewIdent(prefixIdent), Op: token.ADD, Y: astgen.String(name)}
+	})
+	if err != nil {
+		return nil, err
+	}
+	var elemStatements []ast.Stmt
+	if len(fieldStatements) > 0 {
+		elemStatements = append(elemStatements, &ast.AssignStmt{
+			Lhs: []ast.Expr{ast.NewIdent(prefixIdent)},
+			Tok: token.DEFINE,
+			Rhs: []ast.Expr{concatString(&ast.BinaryExpr{
+				X:  astgen.String(fb.InputName + "["),
+				Op: token.ADD,
+				Y:  astgen.Call(g.file, "", "strconv", "Itoa", ast.NewIdent(indexIdent)),
+			}, "].")},
+		})
+	}
+	elemStatements = append(elemStatements, &ast.DeclStmt{Decl: &ast.GenDecl{Tok: token.VAR, Specs: []ast.Spec{&ast.ValueSpec{Names: []*ast.Ident{ast.NewIdent(elemIdent)}, Type: elemType}}}})
+	elemStatements = append(elemStatements, fieldStatements...)
+	elemStatements = append(elemStatements, &ast.AssignStmt{
+		Lhs: []ast.Expr{field()},
+		Tok: token.ASSIGN,
+		Rhs: []ast.Expr{astgen.CallBuiltinAppend(field(), ast.NewIdent(elemIdent))},
+	})
+	collectIndex := &ast.IfStmt{
+		Init: &ast.AssignStmt{
+			Lhs: []ast.Expr{ast.NewIdent(indexIdent), ast.NewIdent(errIdent)},
+			Tok: token.DEFINE,
+			Rhs: []ast.Expr{astgen.Call(g.file, "", "strconv", "Atoi", ast.NewIdent(iIdent))},
+		},
+		Cond: &ast.BinaryExpr{
+			X: &ast.BinaryExpr{
+				X:  &ast.BinaryExpr{X: ast.NewIdent(errIdent), Op: token.EQL, Y: astgen.Nil()},
+				Op: token.LAND,
+				Y:  &ast.BinaryExpr{X: ast.NewIdent(indexIdent), Op: token.GEQ, Y: astgen.Int(0)},
+			},
+			Op: token.LAND,
+			Y:  &ast.BinaryExpr{X: astgen.Call(g.file, "", "strconv", "Itoa", ast.NewIdent(indexIdent)), Op: token.EQL, Y: ast.NewIdent(iIdent)},
+		},
+		Body: &ast.BlockStmt{List: []ast.Stmt{&ast.AssignStmt{
+			Lhs: []ast.Expr{ast.NewIdent(indexesIdent)},
+			Tok: token.ASSIGN,
+			Rhs: []ast.Expr{astgen.CallBuiltinAppend(ast.NewIdent(indexesIdent), ast.NewIdent(indexIdent))},
+		}}},
+	}
+	return &ast.BlockStmt{List: []ast.Stmt{
+		&ast.DeclStmt{Decl: &ast.GenDecl{Tok: token.VAR, Specs: []ast.Spec{&ast.ValueSpec{Names: []*ast.Ident{ast.NewIdent(indexesIdent)}, Type: &ast.ArrayType{Elt: ast.NewIdent("int")}}}}},
+		&ast.RangeStmt{
+			Key: ast.NewIdent(keyIdent),
+			Tok: token.DEFINE,
+			X:   &ast.SelectorExpr{X: ast.NewIdent(muxt.TemplateNameScopeIdentifierHTTPRequest), Sel: ast.NewIdent("Form")},
+			Body: &ast.BlockStmt{List: []ast.Stmt{&ast.IfStmt{
+				Init: &ast.AssignStmt{
+					Lhs: []ast.Expr{ast.NewIdent(restIdent), ast.NewIdent(okIdent)},
+					Tok: token.DEFINE,
+					Rhs: []ast.Expr{astgen.Call(g.file, "", "strings", "CutPrefix", ast.NewIdent(keyIdent), astgen.String(fb.InputName+"["))},
+				},
+				Cond: ast.NewIdent(okIdent),
+				Body: &ast.BlockStmt{List: []ast.Stmt{&ast.IfStmt{
+					Init: &ast.AssignStmt{
+						Lhs: []ast.Expr{ast.NewIdent(iIdent), ast.NewIdent("_"), ast.NewIdent(okIdent)},
+						Tok: token.DEFINE,
+						Rhs: []ast.Expr{astgen.Call(g.file, "", "strings", "Cut", ast.NewIdent(restIdent), astgen.String("]."))},
+					},
+					Cond: ast.NewIdent(okIdent),
+					Body: &ast.BlockStmt{List: []ast.Stmt{collectIndex}},
+				}}},
+			}}},
+		},
+		&ast.ExprStmt{X: astgen.Call(g.file, "", "slices", "Sort", ast.NewIdent(indexesIdent))},
+		&ast.RangeStmt{
+			Key:   ast.NewIdent("_"),
+			Value: ast.NewIdent(indexIdent),
+			Tok:   token.DEFINE,
+			X:     astgen.Call(g.file, "", "slices", "Compact", ast.NewIdent(indexesIdent)),
+			Body:  &ast.BlockStmt{List: elemStatements},
+		},
+	}}, nil
+}
+
+// mapValues binds a map[string]T field from the first value of each input
+// named <name>[<key>].
+//
+//	for key, values := range request.Form {
+//		rest, ok := strings.CutPrefix(key, "<name>[")
+//		if !ok || len(values) == 0 {
+//			continue
+//		}
+//		mapKey, ok := strings.CutSuffix(rest, "]")
+//		if !ok {
+//			continue
+//		}
+//		if form.Field == nil {
+//			form.Field = make(map[string]T)
+//		}
+//		<parse values[0]>
+//		form.Field[mapKey] = value
+//	}
+func (g formFieldStatements) mapValues(field func() ast.Expr, fb muxt.FieldBinding) (ast.Stmt, error) {
+	const (
+		parsedVariableName = "value"
+		keyIdent           = "key"
+		valuesIdent        = "values"
+		restIdent          = "rest"
+		okIdent            = "ok"
+		mapKeyIdent        = "mapKey"
+	)
+	mapType, err := g.file.TypeASTExpression(fb.Field.Type())
+	if err != nil {
+		return nil, err
+	}
+	name := inputName(func(input string) ast.Expr {
+		return concatString(&ast.BinaryExpr{X: astgen.String(input + "["), Op: token.ADD, Y: ast.NewIdent(mapKeyIdent)}, "]")
+	})
+	validations := renderValidations(g.file, ast.NewIdent(parsedVariableName), fb.Validations, g.validationErrorBlock(name))
+	parseResult := func(expr ast.Expr) ast.Stmt {
+		return &ast.AssignStmt{
+			Lhs: []ast.Expr{&ast.IndexExpr{X: field(), Index: ast.NewIdent(mapKeyIdent)}},
+			Tok: token.ASSIGN,
+			Rhs: []ast.Expr{expr},
+		}
+	}
+	value := &ast.IndexExpr{X: ast.NewIdent(valuesIdent), Index: astgen.Int(0)}
+	parseStatements, err := generateParseValueFromStringStatements(g.file, g.def, parsedVariableName, g.resultType, value, fb.Elem, validations, parseResult, g.fieldError(name, fb.InputName, "parse", ast.NewIdent(errIdent)), fb.TimeLayout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate parse statements for %s field %s: %w", g.argName, fb.Field.Name(), err)
+	}
+	body := []ast.Stmt{
+		&ast.AssignStmt{
+			Lhs: []ast.Expr{ast.NewIdent(restIdent), ast.NewIdent(okIdent)},
+			Tok: token.DEFINE,
+			Rhs: []ast.Expr{astgen.Call(g.file, "", "strings", "CutPrefix", ast.NewIdent(keyIdent), astgen.String(fb.InputName+"["))},
+		},
+		&ast.IfStmt{
+			Cond: &ast.BinaryExpr{
+				X:  &ast.UnaryExpr{Op: token.NOT, X: ast.NewIdent(okIdent)},
+				Op: token.LOR,
+				Y:  &ast.BinaryExpr{X: astgen.CallBuiltinLen(ast.NewIdent(valuesIdent)), Op: token.EQL, Y: astgen.Int(0)},
+			},
+			Body: &ast.BlockStmt{List: []ast.Stmt{&ast.BranchStmt{Tok: token.CONTINUE}}},
+		},
+		&ast.AssignStmt{
+			Lhs: []ast.Expr{ast.NewIdent(mapKeyIdent), ast.NewIdent(okIdent)},
+			Tok: token.DEFINE,
+			Rhs: []ast.Expr{astgen.Call(g.file, "", "strings", "CutSuffix", ast.NewIdent(restIdent), astgen.String("]"))},
+		},
+		&ast.IfStmt{
+			Cond: &ast.UnaryExpr{Op: token.NOT, X: ast.NewIdent(okIdent)},
+			Body: &ast.BlockStmt{List: []ast.Stmt{&ast.BranchStmt{Tok: token.CONTINUE}}},
+		},
+		&ast.IfStmt{
+			Cond: &ast.BinaryExpr{X: field(), Op: token.EQL, Y: astgen.Nil()},
+			Body: &ast.BlockStmt{List: []ast.Stmt{&ast.AssignStmt{
+				Lhs: []ast.Expr{field()},
+				Tok: token.ASSIGN,
+				Rhs: []ast.Expr{astgen.CallBuiltin("make", mapType)},
+			}}},
+		},
+	}
+	return &ast.RangeStmt{
+		Key:   ast.NewIdent(keyIdent),
+		Value: ast.NewIdent(valuesIdent),
+		Tok:   token.DEFINE,
+		X:     &ast.SelectorExpr{X: ast.NewIdent(muxt.TemplateNameScopeIdentifierHTTPRequest), Sel: ast.NewIdent("Form")},
+		Body:  &ast.BlockStmt{List: append(body, parseStatements...)},
+	}, nil
+}
+
 // appendParseMultipartFormToStructStatements is a thin wrapper over
 // appendStructFieldParseStatements that emits a ParseMultipartForm call.
 // FileHeader field bindings (from request.MultipartForm.File) are resolved by
 // muxt.ResolveCall; all other field-binding behavior is shared with the form
 // codepath.
-func appendParseMultipartFormToStructStatements(statements []ast.Stmt, def muxt.Definition, file *File, resultType types.Type, arg *ast.Ident, argument muxt.Argument, validationBlock ValidationErrorBlock, rdIdent string, config RoutesFileConfiguration) ([]ast.Stmt, error) {
-	return appendStructFieldParseStatements(statements, def, file, resultType, arg, argument, validationBlock, rdIdent, callParseMultipartForm(file, config, rdIdent))
+func appendParseMultipartFormToStructStatements(statements []ast.Stmt, def muxt.Definition, file *File, resultType types.Type, arg *ast.Ident, argument muxt.Argument, validationBlock ValidationErrorBlock, parseErrBlock func() *ast.BlockStmt, rdIdent string, config RoutesFileConfiguration) ([]ast.Stmt, error) {
+	return appendStructFieldParseStatements(statements, def, file, resultType, arg, argument, validationBlock, parseErrBlock, rdIdent, callParseMultipartForm(file, config, def, rdIdent))
 }
 
 // fileHeaderSingleAssignment emits:
 //
 //	if request.MultipartForm != nil {
 //	    if fhs := request.MultipartForm.File["<inputName>"]; len(fhs) > 0 {
-//	        <arg>.Field = fhs[0]
+//	        <field> = fhs[0]
 //	    }
 //	}
-func fileHeaderSingleAssignment(arg *ast.Ident, fieldName, inputName string) ast.Stmt {
+func fileHeaderSingleAssignment(field ast.Expr, inputName string) ast.Stmt {
 	const tmp = "fhs"
 	inner := &ast.IfStmt{
 		Init: &ast.AssignStmt{
@@ -991,7 +1452,7 @@ func fileHeaderSingleAssignment(arg *ast.Ident, fieldName, inputName string) ast
 		},
 		Body: &ast.BlockStmt{List: []ast.Stmt{
 			&ast.AssignStmt{
-				Lhs: []ast.Expr{&ast.SelectorExpr{X: ast.NewIdent(arg.Name), Sel: ast.NewIdent(fieldName)}},
+				Lhs: []ast.Expr{field},
 				Tok: token.ASSIGN,
 				Rhs: []ast.Expr{&ast.IndexExpr{X: ast.NewIdent(tmp), Index: &ast.BasicLit{Kind: token.INT, Value: "0"}}},
 			},
@@ -1003,11 +1464,11 @@ func fileHeaderSingleAssignment(arg *ast.Ident, fieldName, inputName string) ast
 // fileHeaderSliceAssignment emits:
 //
 //	if request.MultipartForm != nil {
-//	    <arg>.Field = request.MultipartForm.File["<inputName>"]
+//	    <field> = request.MultipartForm.File["<inputName>"]
 //	}
-func fileHeaderSliceAssignment(arg *ast.Ident, fieldName, inputName string) ast.Stmt {
+func fileHeaderSliceAssignment(field ast.Expr, inputName string) ast.Stmt {
 	assign := &ast.AssignStmt{
-		Lhs: []ast.Expr{&ast.SelectorExpr{X: ast.NewIdent(arg.Name), Sel: ast.NewIdent(fieldName)}},
+		Lhs: []ast.Expr{field},
 		Tok: token.ASSIGN,
 		Rhs: []ast.Expr{&ast.IndexExpr{
 			X: &ast.SelectorExpr{
@@ -1097,7 +1558,18 @@ func templateDataParseErrBlock(file *File, rdIdent string) *ast.BlockStmt {
 // errBlock, which callers supply so the failure can be handled differently per
 // context (normal handlers accumulate into the template data; SSE handlers
 // respond 400 before establishing the stream).
-func generateParseValueFromStringStatements(file *File, _ muxt.Definition, tmp string, _ types.Type, str ast.Expr, valueType types.Type, validations []ast.Stmt, assignment func(ast.Expr) ast.Stmt, errBlock *ast.BlockStmt) ([]ast.Stmt, error) {
+//
+// A registered parser (see muxt.Parsers) takes precedence. Otherwise pointer
+// types parse only non-empty values (see
+// generateParseOptionalValueStatements). A time.Time with a timeLayout parses
+// with time.Parse instead of UnmarshalText.
+func generateParseValueFromStringStatements(file *File, def muxt.Definition, tmp string, resultType types.Type, str ast.Expr, valueType types.Type, validations []ast.Stmt, assignment func(ast.Expr) ast.Stmt, errBlock *ast.BlockStmt, timeLayout string) ([]ast.Stmt, error) {
+	if fn, ok := def.Parser(valueType); ok {
+		return parseBlock(tmp, parserCall(file, fn, str), validations, errBlock, assignment), nil
+	}
+	if ptr, ok := valueType.(*types.Pointer); ok {
+		return generateParseOptionalValueStatements(file, def, tmp, resultType, str, ptr, validations, assignment, errBlock, timeLayout)
+	}
 	// convert wraps the parsed value in a conversion to the target basic type
 	// for the strconv functions that return a wider type (ParseInt/ParseUint).
 	convert := func(exp ast.Expr) ast.Stmt {
@@ -1106,7 +1578,7 @@ func generateParseValueFromStringStatements(file *File, _ muxt.Definition, tmp s
 			Args: []ast.Expr{exp},
 		})
 	}
-	switch muxt.UnmarshalMethodFor(file.Packages(), valueType) {
+	switch def.UnmarshalMethod(file.Packages(), valueType) {
 	case muxt.UnmarshalBool:
 		return parseBlock(tmp, astgen.StrconvParseBoolCall(file, str), validations, errBlock, assignment), nil
 	case muxt.UnmarshalInt:
@@ -1129,6 +1601,12 @@ func generateParseValueFromStringStatements(file *File, _ muxt.Definition, tmp s
 		return parseBlock(tmp, astgen.StrconvParseUint32Call(file, str), validations, errBlock, convert), nil
 	case muxt.UnmarshalUint64:
 		return parseBlock(tmp, astgen.StrconvParseUint64Call(file, str), validations, errBlock, assignment), nil
+	case muxt.UnmarshalFloat32:
+		return parseBlock(tmp, astgen.StrconvParseFloatCall(file, str, 32), validations, errBlock, convert), nil
+	case muxt.UnmarshalFloat64:
+		return parseBlock(tmp, astgen.StrconvParseFloatCall(file, str, 64), validations, errBlock, assignment), nil
+	case muxt.UnmarshalDuration:
+		return parseBlock(tmp, astgen.TimeParseDurationCall(file, str), validations, errBlock, assignment), nil
 	case muxt.UnmarshalString:
 		if len(validations) == 0 {
 			assign := assignment(str)
@@ -1142,6 +1620,9 @@ func generateParseValueFromStringStatements(file *File, _ muxt.Definition, tmp s
 		}}, validations, []ast.Stmt{assignment(ast.NewIdent(tmp))})
 		return statements, nil
 	case muxt.UnmarshalTextUnmarshaler:
+		if timeLayout != "" && muxt.IsTimeType(valueType) {
+			return parseBlock(tmp, astgen.TimeParseCall(file, timeLayout, str), validations, errBlock, assignment), nil
+		}
 		tp, _ := file.TypeASTExpression(valueType)
 		return []ast.Stmt{
 			&ast.DeclStmt{
@@ -1187,6 +1668,60 @@ func generateParseValueFromStringStatements(file *File, _ muxt.Definition, tmp s
 	}
 }
 
+// parserCall calls a registered parser function with str.
+func parserCall(file *File, fn *types.Func, str ast.Expr) *ast.CallExpr {
+	if fn.Pkg().Path() == file.OutputPackage().PkgPath {
+		return &ast.CallExpr{Fun: ast.NewIdent(fn.Name()), Args: []ast.Expr{str}}
+	}
+	return astgen.Call(file, fn.Pkg().Name(), fn.Pkg().Path(), fn.Name(), str)
+}
+
+// generateParseOptionalValueStatements parses str into a new value of the
+// pointer type's element when str is not empty, leaving the assignment target
+// nil otherwise:
+//
+//	var id *int // only when assignment defines the target
+//	if request.PathValue("id") != "" {
+//		id = new(int)
+//		<parse statements>
+//		*id = idParsed
+//	}
+func generateParseOptionalValueStatements(file *File, def muxt.Definition, tmp string, resultType types.Type, str ast.Expr, valueType *types.Pointer, validations []ast.Stmt, assignment func(ast.Expr) ast.Stmt, errBlock *ast.BlockStmt, timeLayout string) ([]ast.Stmt, error) {
+	assign, ok := assignment(astgen.Nil()).(*ast.AssignStmt)
+	if !ok || len(assign.Lhs) != 1 {
+		return nil, fmt.Errorf("unsupported assignment for optional type")
+	}
+	target := assign.Lhs[0]
+	elemType, err := file.TypeASTExpression(valueType.Elem())
+	if err != nil {
+		return nil, err
+	}
+	var statements []ast.Stmt
+	if assign.Tok == token.DEFINE {
+		pointerType, err := file.TypeASTExpression(valueType)
+		if err != nil {
+			return nil, err
+		}
+		statements = append(statements, &ast.DeclStmt{Decl: &ast.GenDecl{Tok: token.VAR, Specs: []ast.Spec{
+			&ast.ValueSpec{Names: []*ast.Ident{ast.NewIdent(astgen.Format(target))}, Type: pointerType},
+		}}})
+	}
+	parse, err := generateParseValueFromStringStatements(file, def, tmp, resultType, str, valueType.Elem(), validations, func(expr ast.Expr) ast.Stmt {
+		return &ast.AssignStmt{Lhs: []ast.Expr{&ast.StarExpr{X: target}}, Tok: token.ASSIGN, Rhs: []ast.Expr{expr}}
+	}, errBlock, timeLayout)
+	if err != nil {
+		return nil, err
+	}
+	return append(statements, &ast.IfStmt{
+		Cond: &ast.BinaryExpr{X: str, Op: token.NEQ, Y: astgen.String("")},
+		Body: &ast.BlockStmt{List: append([]ast.Stmt{&ast.AssignStmt{
+			Lhs: []ast.Expr{target},
+			Tok: token.ASSIGN,
+			Rhs: []ast.Expr{astgen.CallBuiltin("new", elemType)},
+		}}, parse...)},
+	}), nil
+}
+
 func parseBlock(tmpIdent string, parseCall ast.Expr, validations []ast.Stmt, errBlock *ast.BlockStmt, handleResult func(out ast.Expr) ast.Stmt) []ast.Stmt {
 	const errIdent = "err"
 	callParse := &ast.AssignStmt{
@@ -1321,14 +1856,45 @@ func requestArgumentSource(def muxt.Definition, name string) ast.Expr {
 	}
 }
 
-func callParseForm() *ast.ExprStmt {
-	return &ast.ExprStmt{X: &ast.CallExpr{
+// callParseForm emits request.ParseForm(). With the maxbody route option the
+// error is checked and recorded with status 400, or 413 when the body is too
+// large; handlers without template data respond with http.Error instead.
+func callParseForm(file *File, def muxt.Definition, rdIdent string) ast.Stmt {
+	const statusCodeIdent = "statusCode"
+	parseForm := &ast.CallExpr{
 		Fun: &ast.SelectorExpr{
 			X:   ast.NewIdent(muxt.TemplateNameScopeIdentifierHTTPRequest),
 			Sel: ast.NewIdent("ParseForm"),
 		},
 		Args: []ast.Expr{},
-	}}
+	}
+	if def.MaxBodyBytes() <= 0 {
+		return &ast.ExprStmt{X: parseForm}
+	}
+	var (
+		errBody    *ast.BlockStmt
+		statusCode ast.Expr
+	)
+	if rdIdent != "" {
+		errBody = appendTemplateDataError(file, rdIdent, ast.NewIdent(errIdent))
+		errBody.List = append(errBody.List, assignTemplateDataErrStatusCode(file, rdIdent, http.StatusBadRequest))
+		statusCode = &ast.SelectorExpr{X: ast.NewIdent(rdIdent), Sel: ast.NewIdent(TemplateDataFieldIdentifierErrStatusCode)}
+	} else {
+		statusCode = ast.NewIdent(statusCodeIdent)
+		errBody = &ast.BlockStmt{List: []ast.Stmt{singleAssignment(token.DEFINE, statusCode)(astgen.HTTPStatusCode(file, http.StatusBadRequest))}}
+	}
+	errBody.List = append(errBody.List, maxBytesErrorStatements(file, statusCode)...)
+	if rdIdent == "" {
+		errBody.List = append(errBody.List,
+			&ast.ExprStmt{X: astgen.Call(file, "http", "net/http", "Error", ast.NewIdent(muxt.TemplateNameScopeIdentifierHTTPResponse), astgen.CallError(errIdent), statusCode)},
+			&ast.ReturnStmt{},
+		)
+	}
+	return &ast.IfStmt{
+		Init: singleAssignment(token.DEFINE, ast.NewIdent(errIdent))(parseForm),
+		Cond: &ast.BinaryExpr{X: ast.NewIdent(errIdent), Op: token.NEQ, Y: astgen.Nil()},
+		Body: errBody,
+	}
 }
 
 // callParseMultipartForm emits:
@@ -1338,18 +1904,24 @@ func callParseForm() *ast.ExprStmt {
 //	    td.errStatusCode = http.StatusBadRequest
 //	}
 //
+// With the maxbody route option a body that is too large sets status 413
+// (see maxBytesErrorStatements).
+//
 // http.ErrNotMultipart is exempted because ParseMultipartForm calls ParseForm
 // internally, so url-encoded POSTs to a multipart route still populate
 // request.PostForm — the receiver method should run with text fields bound
 // (file fields stay nil). Other errors (truncated body, bad boundary,
 // underlying ParseForm failures) are real and surface as 400.
-func callParseMultipartForm(file *File, config RoutesFileConfiguration, rdIdent string) *ast.IfStmt {
+func callParseMultipartForm(file *File, config RoutesFileConfiguration, def muxt.Definition, rdIdent string) *ast.IfStmt {
 	maxMemory := config.MultipartMaxMemory
 	if maxMemory <= 0 {
 		maxMemory = DefaultMultipartMaxMemory
 	}
 	errBlock := appendTemplateDataError(file, rdIdent, ast.NewIdent(errIdent))
 	errBlock.List = append(errBlock.List, assignTemplateDataErrStatusCode(file, rdIdent, http.StatusBadRequest))
+	if def.MaxBodyBytes() > 0 {
+		errBlock.List = append(errBlock.List, maxBytesErrorStatements(file, &ast.SelectorExpr{X: ast.NewIdent(rdIdent), Sel: ast.NewIdent(TemplateDataFieldIdentifierErrStatusCode)})...)
+	}
 	httpPkg := astgen.AddNetHTTP(file)
 	notNil := &ast.BinaryExpr{X: ast.NewIdent(errIdent), Op: token.NEQ, Y: ast.NewIdent("nil")}
 	notErrNotMultipart := &ast.UnaryExpr{
@@ -1415,6 +1987,104 @@ func contextAssignment(ident string) *ast.AssignStmt {
 	}
 }
 
+// providerCallStatements calls the provider method of a provider argument:
+//
+//	user, err := receiver.ProvideUser(request)
+//	if err != nil {
+//		td.errList = append(td.errList, err)
+//		td.errStatusCode = http.StatusInternalServerError
+//		var statusCoder interface{ StatusCode() int }
+//		if errors.As(err, &statusCoder) {
+//			td.errStatusCode = statusCoder.StatusCode()
+//		}
+//	}
+//
+// Handlers without template data (rdIdent is empty on sse and ws routes)
+// instead respond with http.Error and return before the stream or connection
+// is established. The error check is omitted for a provider with a single
+// result.
+func providerCallStatements(file *File, arg muxt.Argument, rdIdent string) []ast.Stmt {
+	call := &ast.CallExpr{
+		Fun:  &ast.SelectorExpr{X: ast.NewIdent(receiverIdent), Sel: ast.NewIdent(arg.Provider())},
+		Args: []ast.Expr{ast.NewIdent(muxt.TemplateNameScopeIdentifierHTTPRequest)},
+	}
+	if arg.Signature().Results().Len() == 1 {
+		return []ast.Stmt{singleAssignment(token.DEFINE, ast.NewIdent(arg.Identifier))(call)}
+	}
+	return []ast.Stmt{
+		&ast.AssignStmt{
+			Lhs: []ast.Expr{ast.NewIdent(arg.Identifier), ast.NewIdent(errIdent)},
+			Tok: token.DEFINE,
+			Rhs: []ast.Expr{call},
+		},
+		&ast.IfStmt{
+			Cond: &ast.BinaryExpr{X: ast.NewIdent(errIdent), Op: token.NEQ, Y: astgen.Nil()},
+			Body: errStatusCodeBlock(file, rdIdent, http.StatusInternalServerError),
+		},
+	}
+}
+
+// authorizeStatements guards a route with the receiver's Authorize method:
+//
+//	if err := receiver.Authorize(request, "GET /"); err != nil { ... }
+//
+// A rejected request renders with the error and a 403, or the status code of
+// the error when it has a StatusCode method. With an empty rdIdent (sse and
+// ws routes) the handler responds with http.Error and returns instead.
+func authorizeStatements(file *File, def muxt.Definition, rdIdent string) []ast.Stmt {
+	if def.Authorize() == nil {
+		return nil
+	}
+	return []ast.Stmt{&ast.IfStmt{
+		Init: singleAssignment(token.DEFINE, ast.NewIdent(errIdent))(&ast.CallExpr{
+			Fun:  &ast.SelectorExpr{X: ast.NewIdent(receiverIdent), Sel: ast.NewIdent(def.Authorize().Name())},
+			Args: []ast.Expr{ast.NewIdent(muxt.TemplateNameScopeIdentifierHTTPRequest), astgen.String(def.Pattern())},
+		}),
+		Cond: &ast.BinaryExpr{X: ast.NewIdent(errIdent), Op: token.NEQ, Y: astgen.Nil()},
+		Body: errStatusCodeBlock(file, rdIdent, http.StatusForbidden),
+	}}
+}
+
+// errStatusCodeBlock handles err with fallbackStatusCode unless err has a
+// StatusCode method. With template data the error is appended to the error
+// list; with an empty rdIdent the handler responds with http.Error and
+// returns.
+func errStatusCodeBlock(file *File, rdIdent string, fallbackStatusCode int) *ast.BlockStmt {
+	const (
+		statusCoderIdent = "statusCoder"
+		statusCodeIdent  = "statusCode"
+	)
+	var (
+		errBody    *ast.BlockStmt
+		statusCode ast.Expr
+	)
+	if rdIdent != "" {
+		errBody = appendTemplateDataError(file, rdIdent, ast.NewIdent(errIdent))
+		errBody.List = append(errBody.List, assignTemplateDataErrStatusCode(file, rdIdent, fallbackStatusCode))
+		statusCode = &ast.SelectorExpr{X: ast.NewIdent(rdIdent), Sel: ast.NewIdent(TemplateDataFieldIdentifierErrStatusCode)}
+	} else {
+		statusCode = ast.NewIdent(statusCodeIdent)
+		errBody = &ast.BlockStmt{List: []ast.Stmt{singleAssignment(token.DEFINE, statusCode)(astgen.HTTPStatusCode(file, fallbackStatusCode))}}
+	}
+	errBody.List = append(errBody.List,
+		&ast.DeclStmt{Decl: &ast.GenDecl{Tok: token.VAR, Specs: []ast.Spec{&ast.ValueSpec{
+			Names: []*ast.Ident{ast.NewIdent(statusCoderIdent)},
+			Type:  &ast.InterfaceType{Methods: &ast.FieldList{List: []*ast.Field{{Names: []*ast.Ident{ast.NewIdent("StatusCode")}, Type: &ast.FuncType{Params: &ast.FieldList{}, Results: &ast.FieldList{List: []*ast.Field{{Type: ast.NewIdent("int")}}}}}}}},
+		}}}},
+		&ast.IfStmt{
+			Cond: astgen.Call(file, "errors", "errors", "As", ast.NewIdent(errIdent), &ast.UnaryExpr{Op: token.AND, X: ast.NewIdent(statusCoderIdent)}),
+			Body: &ast.BlockStmt{List: []ast.Stmt{singleAssignment(token.ASSIGN, statusCode)(&ast.CallExpr{Fun: &ast.SelectorExpr{X: ast.NewIdent(statusCoderIdent), Sel: ast.NewIdent("StatusCode")}})}},
+		},
+	)
+	if rdIdent == "" {
+		errBody.List = append(errBody.List,
+			&ast.ExprStmt{X: astgen.Call(file, "http", "net/http", "Error", ast.NewIdent(muxt.TemplateNameScopeIdentifierHTTPResponse), astgen.CallError(errIdent), statusCode)},
+			&ast.ReturnStmt{},
+		)
+	}
+	return errBody
+}
+
 func singleAssignment(assignTok token.Token, result ast.Expr) func(exp ast.Expr) ast.Stmt {
 	return func(exp ast.Expr) ast.Stmt {
 		return &ast.AssignStmt{
@@ -1427,105 +2097,153 @@ func singleAssignment(assignTok token.Token, result ast.Expr) func(exp ast.Expr)
 
 var statusCoder = statusCoderInterface()
 
-func writeStatusAndHeaders(file *File, def muxt.Definition, resultType types.Type, fallbackStatusCode int, statusCode, bufIdent, resultDataIdent string, resultVar func() ast.Expr) []ast.Stmt {
-	statusCodePriorityList := []ast.Expr{
-		&ast.SelectorExpr{X: ast.NewIdent(resultDataIdent), Sel: ast.NewIdent(templateDataFieldStatusCode)},
-		&ast.SelectorExpr{X: ast.NewIdent(resultDataIdent), Sel: ast.NewIdent(TemplateDataFieldIdentifierErrStatusCode)},
+// resultHeaderStatements applies the headers and cookies a result provides
+// with a Headers() http.Header or Cookies() []*http.Cookie method, or a
+// Headers or Cookies field of those types:
+//
+//	for key, values := range td.result.Headers() {
+//		for _, value := range values {
+//			response.Header().Add(key, value)
+//		}
+//	}
+//	for _, cookie := range td.result.Cookies() {
+//		http.SetCookie(response, cookie)
+//	}
+func resultHeaderStatements(file *File, resultType types.Type, resultVar func() ast.Expr) []ast.Stmt {
+	const (
+		keyIdent    = "key"
+		valuesIdent = "values"
+		valueIdent  = "value"
+		cookieIdent = "cookie"
+	)
+	var list []ast.Stmt
+	if headers, ok := resultMember(file, resultType, "Headers", resultVar, func(tp types.Type) bool {
+		return muxt.IsNamedType(tp, "net/http", "Header")
+	}); ok {
+		list = append(list, &ast.RangeStmt{
+			Key:   ast.NewIdent(keyIdent),
+			Value: ast.NewIdent(valuesIdent),
+			Tok:   token.DEFINE,
+			X:     headers,
+			Body: &ast.BlockStmt{List: []ast.Stmt{&ast.RangeStmt{
+				Key:   ast.NewIdent("_"),
+				Value: ast.NewIdent(valueIdent),
+				Tok:   token.DEFINE,
+				X:     ast.NewIdent(valuesIdent),
+				Body: &ast.BlockStmt{List: []ast.Stmt{&ast.ExprStmt{X: &ast.CallExpr{
+					Fun:  &ast.SelectorExpr{X: &ast.CallExpr{Fun: &ast.SelectorExpr{X: ast.NewIdent(muxt.TemplateNameScopeIdentifierHTTPResponse), Sel: ast.NewIdent("Header")}}, Sel: ast.NewIdent("Add")},
+					Args: []ast.Expr{ast.NewIdent(keyIdent), ast.NewIdent(valueIdent)},
+				}}}},
+			}}},
+		})
 	}
+	if cookies, ok := resultMember(file, resultType, "Cookies", resultVar, func(tp types.Type) bool {
+		slice, ok := types.Unalias(tp).(*types.Slice)
+		if !ok {
+			return false
+		}
+		ptr, ok := slice.Elem().(*types.Pointer)
+		return ok && muxt.IsNamedType(ptr.Elem(), "net/http", "Cookie")
+	}); ok {
+		list = append(list, &ast.RangeStmt{
+			Key:   ast.NewIdent("_"),
+			Value: ast.NewIdent(cookieIdent),
+			Tok:   token.DEFINE,
+			X:     cookies,
+			Body: &ast.BlockStmt{List: []ast.Stmt{&ast.ExprStmt{X: astgen.Call(file, "http", "net/http", "SetCookie",
+				ast.NewIdent(muxt.TemplateNameScopeIdentifierHTTPResponse),
+				ast.NewIdent(cookieIdent),
+			)}}},
+		})
+	}
+	return list
+}
+
+// resultMember returns the expression for a method name() T or a field name T
+// on the result when isType(T).
+func resultMember(file *File, resultType types.Type, name string, resultVar func() ast.Expr, isType func(types.Type) bool) (ast.Expr, bool) {
+	obj, _, _ := types.LookupFieldOrMethod(resultType, true, file.OutputPackage().Types, name)
+	switch obj := obj.(type) {
+	case *types.Func:
+		sig := obj.Type().(*types.Signature)
+		if sig.Params().Len() == 0 && sig.Results().Len() == 1 && isType(sig.Results().At(0).Type()) {
+			return &ast.CallExpr{Fun: &ast.SelectorExpr{X: resultVar(), Sel: ast.NewIdent(name)}}, true
+		}
+	case *types.Var:
+		if isType(obj.Type()) {
+			return &ast.SelectorExpr{X: resultVar(), Sel: ast.NewIdent(name)}, true
+		}
+	}
+	return nil, false
+}
+
+// writeStatusAndHeaders applies the result headers and cookies, resolves the
+// status with td.responseStatusCode, redirects when the template may call
+// Redirect, then calls td.writeResponse (see templateDataWriteResponseMethod).
+func writeStatusAndHeaders(file *File, def muxt.Definition, resultType types.Type, bufIdent, resultDataIdent string, resultVar func() ast.Expr) []ast.Stmt {
+	const statusCodeIdent = "statusCode"
+	var resultStatusCode ast.Expr = astgen.Int(0)
 	if types.Implements(resultType, statusCoder) {
-		statusCodePriorityList = append(statusCodePriorityList, &ast.CallExpr{Fun: &ast.SelectorExpr{X: resultVar(), Sel: ast.NewIdent("StatusCode")}})
+		resultStatusCode = &ast.CallExpr{Fun: &ast.SelectorExpr{X: resultVar(), Sel: ast.NewIdent("StatusCode")}}
 	} else if obj, _, _ := types.LookupFieldOrMethod(resultType, true, file.OutputPackage().Types, "StatusCode"); obj != nil {
-		statusCodePriorityList = append(statusCodePriorityList, &ast.SelectorExpr{X: resultVar(), Sel: ast.NewIdent("StatusCode")})
+		resultStatusCode = &ast.SelectorExpr{X: resultVar(), Sel: ast.NewIdent("StatusCode")}
 	}
-	var list []ast.Stmt
-	if fallbackStatusCode == http.StatusOK {
-		const defaultStatusIdent = "defaultStatusCode"
-		list = append(list,
-			&ast.AssignStmt{
-				Lhs: []ast.Expr{ast.NewIdent(defaultStatusIdent)},
-				Tok: token.DEFINE,
-				Rhs: []ast.Expr{astgen.HTTPStatusCode(file, http.StatusOK)},
-			},
-			&ast.IfStmt{
-				Cond: &ast.BinaryExpr{
-					X:  &ast.CallExpr{Fun: &ast.SelectorExpr{X: ast.NewIdent(bufIdent), Sel: ast.NewIdent("Len")}},
-					Op: token.EQL,
-					Y:  astgen.Int(0),
-				},
-				Body: &ast.BlockStmt{List: []ast.Stmt{
-					&ast.AssignStmt{
-						Lhs: []ast.Expr{ast.NewIdent(defaultStatusIdent)},
-						Tok: token.ASSIGN,
-						Rhs: []ast.Expr{astgen.HTTPStatusCode(file, http.StatusNoContent)},
-					},
-				}},
-			},
-		)
-		statusCodePriorityList = append(statusCodePriorityList, ast.NewIdent(defaultStatusIdent))
-	} else {
-		statusCodePriorityList = append(statusCodePriorityList, astgen.HTTPStatusCode(file, fallbackStatusCode))
-	}
-	list = append(list, &ast.AssignStmt{
-		Lhs: []ast.Expr{ast.NewIdent(statusCode)},
+	list := append(resultHeaderStatements(file, resultType, resultVar), &ast.AssignStmt{
+		Lhs: []ast.Expr{ast.NewIdent(statusCodeIdent)},
 		Tok: token.DEFINE,
-		Rhs: []ast.Expr{astgen.CmpOr(file, statusCodePriorityList...)},
+		Rhs: []ast.Expr{&ast.CallExpr{
+			Fun:  &ast.SelectorExpr{X: ast.NewIdent(resultDataIdent), Sel: ast.NewIdent(templateDataResponseStatusCodeMethodName)},
+			Args: []ast.Expr{ast.NewIdent(bufIdent), resultStatusCode, astgen.HTTPStatusCode(file, def.DefaultStatusCode())},
+		}},
 	})
 
 	// Only add redirect block if the template can call Redirect
 	if def.MayRedirect() {
 		list = append(list, &ast.IfStmt{
-			Cond: &ast.BinaryExpr{
-				X: &ast.SelectorExpr{
-					X:   ast.NewIdent(resultDataIdent),
-					Sel: ast.NewIdent(TemplateDataFieldIdentifierRedirectURL),
-				},
-				Op: token.NEQ,
-				Y:  astgen.String(""),
-			},
-			Body: &ast.BlockStmt{
-				List: []ast.Stmt{
-					&ast.ExprStmt{
-						X: astgen.Call(file, "", "net/http", "Redirect",
-							ast.NewIdent(muxt.TemplateNameScopeIdentifierHTTPResponse),
-							ast.NewIdent(muxt.TemplateNameScopeIdentifierHTTPRequest),
-							&ast.SelectorExpr{
-								X:   ast.NewIdent(resultDataIdent),
-								Sel: ast.NewIdent(TemplateDataFieldIdentifierRedirectURL),
-							},
-							ast.NewIdent(statusCode),
-						),
-					},
-					&ast.ReturnStmt{},
-				},
-			},
+			Cond: methodCall(ast.NewIdent(resultDataIdent), templateDataWriteRedirectMethodName, ast.NewIdent(statusCodeIdent)),
+			Body: &ast.BlockStmt{List: []ast.Stmt{&ast.ReturnStmt{}}},
 		})
 	}
-
-	return append(list, writeBodyAndWriteHeadersFunc(file, bufIdent, statusCode)...)
+	return append(list, &ast.ExprStmt{X: &ast.CallExpr{
+		Fun:  &ast.SelectorExpr{X: ast.NewIdent(resultDataIdent), Sel: ast.NewIdent(templateDataWriteResponseMethodName)},
+		Args: []ast.Expr{ast.NewIdent(bufIdent), ast.NewIdent(statusCodeIdent)},
+	}})
 }
 
 func executeTemplateFailedLogLine(file *File, message, errIdent string) *ast.CallExpr {
+	return slogErrorCall(file, ast.NewIdent(muxt.TemplateNameScopeIdentifierHTTPRequest), message, errIdent)
+}
+
+// slogErrorCall logs message and err with the default logger, attributed to
+// the path and matched pattern of request.
+func slogErrorCall(file *File, request ast.Expr, message, errIdent string) *ast.CallExpr {
 	args := []ast.Expr{
-		&ast.CallExpr{Fun: &ast.SelectorExpr{X: ast.NewIdent(muxt.TemplateNameScopeIdentifierHTTPRequest), Sel: ast.NewIdent("Context")}},
+		&ast.CallExpr{Fun: &ast.SelectorExpr{X: request, Sel: ast.NewIdent("Context")}},
 		astgen.String(message),
 
 		astgen.SlogString(file, "path", &ast.SelectorExpr{
-			X:   &ast.SelectorExpr{X: ast.NewIdent(muxt.TemplateNameScopeIdentifierHTTPRequest), Sel: ast.NewIdent("URL")},
+			X:   &ast.SelectorExpr{X: request, Sel: ast.NewIdent("URL")},
 			Sel: ast.NewIdent("Path"),
 		}),
-		astgen.SlogString(file, "pattern", &ast.SelectorExpr{X: ast.NewIdent(muxt.TemplateNameScopeIdentifierHTTPRequest), Sel: ast.NewIdent("Pattern")}),
+		astgen.SlogString(file, "pattern", &ast.SelectorExpr{X: request, Sel: ast.NewIdent("Pattern")}),
 		astgen.SlogString(file, "error", astgen.CallError(errIdent)),
 	}
 	return astgen.Call(file, "", "log/slog", "ErrorContext", args...)
 }
 
 func loggerErrorCall(file *File, message, pattern, errIdent string) *ast.CallExpr {
+	return loggerErrorCallWithRequest(file, ast.NewIdent(muxt.TemplateNameScopeIdentifierHTTPRequest), message, astgen.String(pattern), errIdent)
+}
+
+// loggerErrorCallWithRequest logs message and err with the routes function
+// logger parameter, attributed to pattern and the path of request.
+func loggerErrorCallWithRequest(file *File, request ast.Expr, message string, pattern ast.Expr, errIdent string) *ast.CallExpr {
 	args := []ast.Expr{
-		&ast.CallExpr{Fun: &ast.SelectorExpr{X: ast.NewIdent(muxt.TemplateNameScopeIdentifierHTTPRequest), Sel: ast.NewIdent("Context")}},
+		&ast.CallExpr{Fun: &ast.SelectorExpr{X: request, Sel: ast.NewIdent("Context")}},
 		astgen.String(message),
-		astgen.SlogString(file, "pattern", astgen.String(pattern)),
+		astgen.SlogString(file, "pattern", pattern),
 		astgen.SlogString(file, "path", &ast.SelectorExpr{
-			X:   &ast.SelectorExpr{X: ast.NewIdent(muxt.TemplateNameScopeIdentifierHTTPRequest), Sel: ast.NewIdent("URL")},
+			X:   &ast.SelectorExpr{X: request, Sel: ast.NewIdent("URL")},
 			Sel: ast.NewIdent("Path"),
 		}),
 		astgen.SlogString(file, "error", astgen.CallError(errIdent)),
@@ -0,0 +1,137 @@
+package generate
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"github.com/typelate/muxt/internal/astgen"
+	"github.com/typelate/muxt/internal/muxt"
+)
+
+const sequenceErrorMessage = "result sequence failed"
+
+// sequenceResultStatements streams the route template straight to the
+// response when the result is a function iterator the template ranges over:
+//
+//	if len(td.errList) == 0 {
+//		seq := td.result
+//		td.result = func(yield func(T, error) bool) {
+//			for value, err := range seq {
+//				if err != nil {
+//					td.errList = append(td.errList, err)
+//					return
+//				}
+//				if !yield(value, nil) {
+//					return
+//				}
+//			}
+//		}
+//		if contentType := response.Header().Get("content-type"); contentType == "" {
+//			response.Header().Set("content-type", "text/html; charset=utf-8")
+//		}
+//		response.WriteHeader(http.StatusOK)
+//		if err := routeTemplate.Execute(response, &td); err != nil {
+//			slog.ErrorContext(request.Context(), "failed to render page", ...)
+//			return
+//		}
+//		if err := errors.Join(td.errList...); err != nil {
+//			slog.ErrorContext(request.Context(), "result sequence failed", ...)
+//		}
+//		return
+//	}
+//
+// The status and headers are written before the first item, so they come
+// from the template name and the template can not change them. For an
+// iter.Seq2[T, error] result the wrapper stops at the first error and adds it
+// to .Err, so the template reports it after its range. It returns nil when
+// the result is not a sequence.
+func sequenceResultStatements(file *File, config RoutesFileConfiguration, def muxt.Definition, resultType types.Type, rdIdent string) ([]ast.Stmt, error) {
+	if def.ResultSequence() == muxt.ResultSequenceNone {
+		return nil, nil
+	}
+	const (
+		seqIdent   = "seq"
+		valueIdent = "value"
+	)
+	result := &ast.SelectorExpr{X: ast.NewIdent(rdIdent), Sel: ast.NewIdent(TemplateDataFieldIdentifierResult)}
+	errList := &ast.SelectorExpr{X: ast.NewIdent(rdIdent), Sel: ast.NewIdent(TemplateDataFieldIdentifierError)}
+	response := ast.NewIdent(muxt.TemplateNameScopeIdentifierHTTPResponse)
+
+	var body []ast.Stmt
+	if def.ResultSequence() == muxt.ResultSequenceSeqError {
+		yieldType, err := file.TypeASTExpression(resultType.Underlying().(*types.Signature).Params().At(0).Type())
+		if err != nil {
+			return nil, err
+		}
+		body = append(body,
+			singleAssignment(token.DEFINE, ast.NewIdent(seqIdent))(result),
+			singleAssignment(token.ASSIGN, result)(&ast.FuncLit{
+				Type: &ast.FuncType{Params: &ast.FieldList{List: []*ast.Field{{Names: []*ast.Ident{ast.NewIdent("yield")}, Type: yieldType}}}},
+				Body: &ast.BlockStmt{List: []ast.Stmt{&ast.RangeStmt{
+					Key:   ast.NewIdent(valueIdent),
+					Value: ast.NewIdent(errIdent),
+					Tok:   token.DEFINE,
+					X:     ast.NewIdent(seqIdent),
+					Body: &ast.BlockStmt{List: []ast.Stmt{
+						&ast.IfStmt{
+							Cond: &ast.BinaryExpr{X: ast.NewIdent(errIdent), Op: token.NEQ, Y: astgen.Nil()},
+							Body: &ast.BlockStmt{List: []ast.Stmt{
+								singleAssignment(token.ASSIGN, errList)(astgen.CallBuiltinAppend(errList, ast.NewIdent(errIdent))),
+								&ast.ReturnStmt{},
+							}},
+						},
+						&ast.IfStmt{
+							Cond: &ast.UnaryExpr{Op: token.NOT, X: &ast.CallExpr{Fun: ast.NewIdent("yield"), Args: []ast.Expr{ast.NewIdent(valueIdent), astgen.Nil()}}},
+							Body: &ast.BlockStmt{List: []ast.Stmt{&ast.ReturnStmt{}}},
+						},
+					}},
+				}}},
+			}),
+		)
+	}
+
+	logError := func(message string) ast.Stmt {
+		if config.Logger {
+			return &ast.ExprStmt{X: loggerErrorCall(file, message, def.RawPattern(), errIdent)}
+		}
+		return &ast.ExprStmt{X: executeTemplateFailedLogLine(file, message, errIdent)}
+	}
+	if config.Logger {
+		body = append(body, logDebugStatement(file, "handling request", def.RawPattern()))
+	}
+	body = append(body,
+		&ast.IfStmt{
+			Init: singleAssignment(token.DEFINE, ast.NewIdent("contentType"))(&ast.CallExpr{
+				Fun:  &ast.SelectorExpr{X: &ast.CallExpr{Fun: &ast.SelectorExpr{X: response, Sel: ast.NewIdent("Header")}}, Sel: ast.NewIdent("Get")},
+				Args: []ast.Expr{astgen.String("content-type")},
+			}),
+			Cond: &ast.BinaryExpr{X: ast.NewIdent("contentType"), Op: token.EQL, Y: astgen.String("")},
+			Body: &ast.BlockStmt{List: []ast.Stmt{&ast.ExprStmt{X: &ast.CallExpr{
+				Fun:  &ast.SelectorExpr{X: &ast.CallExpr{Fun: &ast.SelectorExpr{X: response, Sel: ast.NewIdent("Header")}}, Sel: ast.NewIdent("Set")},
+				Args: []ast.Expr{astgen.String("content-type"), astgen.String("text/html; charset=utf-8")},
+			}}}},
+		},
+		&ast.ExprStmt{X: &ast.CallExpr{
+			Fun:  &ast.SelectorExpr{X: response, Sel: ast.NewIdent("WriteHeader")},
+			Args: []ast.Expr{astgen.HTTPStatusCode(file, def.DefaultStatusCode())},
+		}},
+		&ast.IfStmt{
+			Init: singleAssignment(token.DEFINE, ast.NewIdent(errIdent))(executeTemplateCall(def, def.Name(), response, templateDataArgument(file, config, def, rdIdent))),
+			Cond: &ast.BinaryExpr{X: ast.NewIdent(errIdent), Op: token.NEQ, Y: astgen.Nil()},
+			// The status is already written so the error can only be logged.
+			Body: &ast.BlockStmt{List: []ast.Stmt{logError(executeTemplateErrorMessage), &ast.ReturnStmt{}}},
+		},
+	)
+	if def.ResultSequence() == muxt.ResultSequenceSeqError {
+		join := astgen.Call(file, "errors", "errors", "Join", errList)
+		join.Ellipsis = 1
+		body = append(body, &ast.IfStmt{
+			Init: singleAssignment(token.DEFINE, ast.NewIdent(errIdent))(join),
+			Cond: &ast.BinaryExpr{X: ast.NewIdent(errIdent), Op: token.NEQ, Y: astgen.Nil()},
+			Body: &ast.BlockStmt{List: []ast.Stmt{logError(sequenceErrorMessage)}},
+		})
+	}
+	body = append(body, &ast.ReturnStmt{})
+	return []ast.Stmt{ifNoErrors(rdIdent, body)}, nil
+}
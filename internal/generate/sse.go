@@ -6,7 +6,6 @@ import (
 	"go/types"
 	"net/http"
 	"slices"
-	"strconv"
 
 	"github.com/typelate/muxt/internal/astgen"
 	"github.com/typelate/muxt/internal/muxt"
@@ -78,13 +77,14 @@ func sseMethodHandlerFunc(file *File, config RoutesFileConfiguration, def muxt.D
 			&ast.ReturnStmt{},
 		}}
 	}
-	validationFailureBlock := func(string) *ast.BlockStmt { return parseErrBlock() }
+	validationFailureBlock := func(string, string, string) *ast.BlockStmt { return parseErrBlock() }
 	// The result type is per-callback; arg parsing only needs ctx/lastEventID/path
 	// (it ignores the result type), so pass an empty struct here.
 	body, err := appendParseArgumentStatements(body, def, file, types.NewStruct(nil, nil), sig, def.Arguments, nil, "", config, def.CallExpression(), validationFailureBlock, parseErrBlock)
 	if err != nil {
 		return nil, err
 	}
+	body = append(body, authorizeStatements(file, def, "")...)
 
 	// h := response.Header(); set the SSE headers; WriteHeader(200); flush.
 	headerSet := func(key, value string) ast.Stmt {
@@ -141,7 +141,7 @@ func sseMethodHandlerFunc(file *File, config RoutesFileConfiguration, def muxt.D
 		body = append(body, &ast.ExprStmt{X: callExpr})
 	}
 
-	handlerFunc.Body.List = body
+	handlerFunc.Body.List = slices.Concat(recoverPanicStatements(file, config, def, "", nil), routeOptionStatements(file, def), body)
 	return handlerFunc, nil
 }
 
@@ -155,7 +155,7 @@ func sseMethodHandlerFunc(file *File, config RoutesFileConfiguration, def muxt.D
 //		buf.Reset()
 //		defer bytesBufferPool.Put(buf)
 //		td := SSETemplateData[Recv, T]{receiver: receiver, request: request, pathsPrefix: pathsPrefix, result: result}
-//		if err := templates.ExecuteTemplate(buf, name, &td); err != nil { slog...; return err }
+//		if err := sseNameTemplate.Execute(buf, &td); err != nil { slog...; return err }
 //		td.data = buf
 //		mut.Lock()
 //		defer mut.Unlock()
@@ -214,12 +214,9 @@ func sseClosure(file *File, config RoutesFileConfiguration, def muxt.Definition,
 			Elts: tdElts,
 		}},
 	})
-	// if err := templates.ExecuteTemplate(buf, name, &td); err != nil { slog...; return err }
+	// if err := sseNameTemplate.Execute(buf, &td); err != nil { slog...; return err }
 	body = append(body, &ast.IfStmt{
-		Init: &ast.AssignStmt{Lhs: []ast.Expr{ast.NewIdent(errIdent)}, Tok: token.DEFINE, Rhs: []ast.Expr{&ast.CallExpr{
-			Fun:  &ast.SelectorExpr{X: ast.NewIdent(def.TemplatesVariable()), Sel: ast.NewIdent("ExecuteTemplate")},
-			Args: []ast.Expr{ast.NewIdent(bufIdent), &ast.BasicLit{Kind: token.STRING, Value: strconv.Quote(templateName)}, &ast.UnaryExpr{Op: token.AND, X: ast.NewIdent(tdIdent)}},
-		}}},
+		Init: &ast.AssignStmt{Lhs: []ast.Expr{ast.NewIdent(errIdent)}, Tok: token.DEFINE, Rhs: []ast.Expr{executeTemplateCall(def, templateName, ast.NewIdent(bufIdent), &ast.UnaryExpr{Op: token.AND, X: ast.NewIdent(tdIdent)})}},
 		Cond: &ast.BinaryExpr{X: ast.NewIdent(errIdent), Op: token.NEQ, Y: astgen.Nil()},
 		Body: &ast.BlockStmt{List: []ast.Stmt{
 			&ast.ExprStmt{X: executeTemplateFailedLogLine(file, executeTemplateErrorMessage, errIdent)},
@@ -34,7 +34,7 @@ func sseTemplateDataDecls(file *File, config RoutesFileConfiguration) []ast.Decl
 		sseTemplateDataEventMethod(typeIdent),
 		sseTemplateDataIDMethod(typeIdent),
 		sseTemplateDataRetryMethod(typeIdent),
-		sseTemplateDataPathMethod(config),
+		sseTemplateDataPathMethod(typeIdent, config.TemplateRoutePathsTypeName),
 		sseTemplateDataWriteToMethod(file, typeIdent),
 	}
 }
@@ -180,13 +180,13 @@ func sseTemplateDataRetryMethod(typeIdent string) *ast.FuncDecl {
 	return sseTemplateDataPointerSetterMethod(typeIdent, "Retry", "retryMilliseconds", "int", sseTemplateDataFieldRetry)
 }
 
-func sseTemplateDataPathMethod(config RoutesFileConfiguration) *ast.FuncDecl {
+func sseTemplateDataPathMethod(typeIdent, routePathsTypeIdent string) *ast.FuncDecl {
 	return &ast.FuncDecl{
-		Recv: sseTemplateDataMethodReceiver(config.SSETemplateDataType),
+		Recv: sseTemplateDataMethodReceiver(typeIdent),
 		Name: ast.NewIdent("Path"),
-		Type: &ast.FuncType{Results: &ast.FieldList{List: []*ast.Field{{Type: ast.NewIdent(config.TemplateRoutePathsTypeName)}}}},
+		Type: &ast.FuncType{Results: &ast.FieldList{List: []*ast.Field{{Type: ast.NewIdent(routePathsTypeIdent)}}}},
 		Body: &ast.BlockStmt{List: []ast.Stmt{&ast.ReturnStmt{Results: []ast.Expr{
-			&ast.CompositeLit{Type: ast.NewIdent(config.TemplateRoutePathsTypeName), Elts: []ast.Expr{
+			&ast.CompositeLit{Type: ast.NewIdent(routePathsTypeIdent), Elts: []ast.Expr{
 				&ast.KeyValueExpr{
 					Key:   ast.NewIdent(pathPrefixPathsStructFieldName),
 					Value: &ast.SelectorExpr{X: ast.NewIdent(sseTemplateDataReceiverName), Sel: ast.NewIdent(pathPrefixPathsStructFieldName)},
@@ -345,3 +345,45 @@ func sseTemplateDataWriteToMethod(file *File, typeIdent string) *ast.FuncDecl {
 		Body: &ast.BlockStmt{List: body},
 	}
 }
+
+// webSocketTemplateDataDecls returns the WebSocketTemplateData type declaration
+// and its methods. It is emitted only when at least one route uses the ws
+// wrapper. Each execute call renders one text frame, so unlike SSETemplateData
+// there is no frame metadata to set.
+func webSocketTemplateDataDecls(file *File, config RoutesFileConfiguration) []ast.Decl {
+	typeIdent := config.WebSocketTemplateDataType
+	decls := []ast.Decl{
+		webSocketTemplateDataType(file, typeIdent),
+		sseTemplateDataStringMethod(typeIdent),
+		sseTemplateDataReceiverMethod(typeIdent),
+		sseTemplateDataRequestMethod(file, typeIdent),
+		sseTemplateDataResultMethod(typeIdent),
+		sseTemplateDataErrMethod(file, typeIdent),
+		sseTemplateDataPathMethod(typeIdent, config.TemplateRoutePathsTypeName),
+	}
+	if !config.WebSocketAllowCrossOrigin {
+		decls = append(decls, webSocketSameOriginFunc(file))
+	}
+	return decls
+}
+
+func webSocketTemplateDataType(file *File, typeIdent string) *ast.GenDecl {
+	return &ast.GenDecl{
+		Tok: token.TYPE,
+		Specs: []ast.Spec{
+			&ast.TypeSpec{
+				Name:       ast.NewIdent(typeIdent),
+				TypeParams: sseTemplateDataTypeParams(),
+				Type: &ast.StructType{
+					Fields: &ast.FieldList{List: []*ast.Field{
+						{Names: []*ast.Ident{ast.NewIdent(TemplateDataFieldIdentifierReceiver)}, Type: ast.NewIdent("R")},
+						{Names: []*ast.Ident{ast.NewIdent(muxt.TemplateNameScopeIdentifierHTTPRequest)}, Type: astgen.HTTPRequestPtr(file)},
+						{Names: []*ast.Ident{ast.NewIdent(TemplateDataFieldIdentifierResult)}, Type: ast.NewIdent("T")},
+						{Names: []*ast.Ident{ast.NewIdent(pathPrefixPathsStructFieldName)}, Type: ast.NewIdent("string")},
+						{Names: []*ast.Ident{ast.NewIdent(TemplateDataFieldIdentifierError)}, Type: &ast.ArrayType{Elt: ast.NewIdent("error")}},
+					}},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,123 @@
+package generate
+
+import (
+	"go/ast"
+	"go/token"
+	"net/http"
+
+	"github.com/typelate/muxt/internal/astgen"
+	"github.com/typelate/muxt/internal/muxt"
+)
+
+// prerendered reports whether the route is rendered once when the routes
+// function runs: a static call-less GET route (see muxt.Definition.Static)
+// with no Authorize check and the default 200 status.
+func prerendered(config RoutesFileConfiguration, def muxt.Definition) bool {
+	if !config.PrerenderStaticRoutes || !def.Static() || def.Authorize() != nil || def.DefaultStatusCode() != http.StatusOK {
+		return false
+	}
+	switch def.HTTPMethod() {
+	case "", http.MethodGet:
+		return true
+	}
+	return false
+}
+
+// prerenderedRoute renders a static route template once and registers a
+// handler serving the bytes with an ETag:
+//
+//	{
+//		routeTemplate := templates.Lookup(name)
+//		...
+//		td := TemplateData[R, struct{}]{receiver: receiver, pathsPrefix: pathsPrefix}
+//		page := new(bytes.Buffer)
+//		if err := routeTemplate.Execute(page, &td); err != nil {
+//			panic("failed to pre-render template \"name\": " + err.Error())
+//		}
+//		etag := fmt.Sprintf("\"%x\"", sha256.Sum256(page.Bytes()))
+//		mux.HandleFunc(pattern, func(response http.ResponseWriter, request *http.Request) {
+//			response.Header().Set("content-type", "text/html; charset=utf-8")
+//			response.Header().Set("etag", etag)
+//			http.ServeContent(response, request, "", time.Time{}, bytes.NewReader(page.Bytes()))
+//		})
+//	}
+//
+// http.ServeContent answers If-None-Match with 304 Not Modified and handles
+// HEAD and Range requests.
+func prerenderedRoute(file *File, config RoutesFileConfiguration, def muxt.Definition, receiverInterfaceName string) ast.Stmt {
+	list, handlerFunc := prerenderedHandler(file, config, def, receiverInterfaceName)
+	list = append(list, callHandleFunc(file, def, handlerFunc, config))
+	return &ast.BlockStmt{List: list}
+}
+
+// prerenderedHandler returns the statements rendering the route template and
+// the handler serving the page they declare.
+func prerenderedHandler(file *File, config RoutesFileConfiguration, def muxt.Definition, receiverInterfaceName string) ([]ast.Stmt, *ast.FuncLit) {
+	const (
+		tdIdent   = "td"
+		pageIdent = "page"
+		etagIdent = "etag"
+	)
+	response := ast.NewIdent(muxt.TemplateNameScopeIdentifierHTTPResponse)
+	headerSet := func(key string, value ast.Expr) ast.Stmt {
+		return &ast.ExprStmt{X: &ast.CallExpr{
+			Fun:  &ast.SelectorExpr{X: &ast.CallExpr{Fun: &ast.SelectorExpr{X: response, Sel: ast.NewIdent("Header")}}, Sel: ast.NewIdent("Set")},
+			Args: []ast.Expr{astgen.String(key), value},
+		}}
+	}
+	pageBytes := func() ast.Expr {
+		return &ast.CallExpr{Fun: &ast.SelectorExpr{X: ast.NewIdent(pageIdent), Sel: ast.NewIdent("Bytes")}}
+	}
+
+	var handlerBody []ast.Stmt
+	if config.Logger {
+		handlerBody = append(handlerBody, logDebugStatement(file, "handling request", def.RawPattern()))
+	}
+	handlerBody = append(handlerBody,
+		headerSet("content-type", astgen.String("text/html; charset=utf-8")),
+		headerSet("etag", ast.NewIdent(etagIdent)),
+		&ast.ExprStmt{X: astgen.Call(file, "", "net/http", "ServeContent",
+			response,
+			ast.NewIdent(muxt.TemplateNameScopeIdentifierHTTPRequest),
+			astgen.String(""),
+			&ast.CompositeLit{Type: astgen.ExportedIdentifier(file, "", "time", "Time")},
+			astgen.Call(file, "", "bytes", "NewReader", pageBytes()),
+		)},
+	)
+	handlerFunc := &ast.FuncLit{
+		Type: astgen.HTTPHandlerFuncType(file, muxt.TemplateNameScopeIdentifierHTTPResponse, muxt.TemplateNameScopeIdentifierHTTPRequest),
+		Body: &ast.BlockStmt{List: handlerBody},
+	}
+
+	render := []ast.Stmt{
+		singleAssignment(token.DEFINE, ast.NewIdent(tdIdent))(&ast.CompositeLit{
+			Type: &ast.IndexListExpr{
+				X:       ast.NewIdent(config.TemplateDataType),
+				Indices: []ast.Expr{ast.NewIdent(receiverInterfaceName), astgen.EmptyStructType()},
+			},
+			Elts: []ast.Expr{
+				&ast.KeyValueExpr{Key: ast.NewIdent(TemplateDataFieldIdentifierReceiver), Value: ast.NewIdent(receiverIdent)},
+				&ast.KeyValueExpr{Key: ast.NewIdent(pathPrefixPathsStructFieldName), Value: ast.NewIdent(pathPrefixPathsStructFieldName)},
+			},
+		}),
+		singleAssignment(token.DEFINE, ast.NewIdent(pageIdent))(&ast.CallExpr{Fun: ast.NewIdent("new"), Args: []ast.Expr{astgen.ExportedIdentifier(file, "", "bytes", "Buffer")}}),
+		&ast.IfStmt{
+			Init: singleAssignment(token.DEFINE, ast.NewIdent(errIdent))(executeTemplateCall(def, def.Name(), ast.NewIdent(pageIdent), &ast.UnaryExpr{Op: token.AND, X: ast.NewIdent(tdIdent)})),
+			Cond: &ast.BinaryExpr{X: ast.NewIdent(errIdent), Op: token.NEQ, Y: astgen.Nil()},
+			Body: &ast.BlockStmt{List: []ast.Stmt{&ast.ExprStmt{X: &ast.CallExpr{
+				Fun: ast.NewIdent("panic"),
+				Args: []ast.Expr{&ast.BinaryExpr{
+					X:  astgen.String("failed to pre-render template " + astgen.String(def.Name()).Value + ": "),
+					Op: token.ADD,
+					Y:  &ast.CallExpr{Fun: &ast.SelectorExpr{X: ast.NewIdent(errIdent), Sel: ast.NewIdent("Error")}},
+				}},
+			}}}},
+		},
+		singleAssignment(token.DEFINE, ast.NewIdent(etagIdent))(astgen.Call(file, "", "fmt", "Sprintf",
+			astgen.String(`"%x"`),
+			astgen.Call(file, "", "crypto/sha256", "Sum256", pageBytes()),
+		)),
+	}
+	list := lookupTemplateStatements(def, &ast.BlockStmt{List: render})
+	return append(list, render...), handlerFunc
+}
@@ -3,6 +3,7 @@ package generate
 import (
 	"go/ast"
 	"go/token"
+	"net/http"
 
 	"github.com/typelate/muxt/internal/astgen"
 	"github.com/typelate/muxt/internal/muxt"
@@ -18,6 +19,14 @@ const (
 	TemplateDataFieldIdentifierReceiver      = "receiver"
 	TemplateDataFieldIdentifierStatusCode    = "statusCode"
 	TemplateDataFieldIdentifierErrStatusCode = "errStatusCode"
+	TemplateDataFieldIdentifierFieldErrors   = "fieldErrors"
+	TemplateDataFieldIdentifierFormData      = "formData"
+
+	templateDataAppendFieldErrorMethodName      = "appendFieldError"
+	templateDataResponseStatusCodeMethodName    = "responseStatusCode"
+	templateDataWriteResponseMethodName         = "writeResponse"
+	templateDataWriteRedirectMethodName         = "writeRedirect"
+	templateDataExecuteTemplateFailedMethodName = "executeTemplateFailed"
 )
 
 func templateDataType(file *File, templateTypeIdent string, receiverType ast.Expr) *ast.GenDecl {
@@ -43,6 +52,8 @@ func templateDataType(file *File, templateTypeIdent string, receiverType ast.Exp
 							{Names: []*ast.Ident{ast.NewIdent(TemplateDataFieldIdentifierErrStatusCode)}, Type: ast.NewIdent("int")},
 							{Names: []*ast.Ident{ast.NewIdent(TemplateDataFieldIdentifierOkay)}, Type: ast.NewIdent("bool")},
 							{Names: []*ast.Ident{ast.NewIdent(TemplateDataFieldIdentifierError)}, Type: &ast.ArrayType{Elt: ast.NewIdent("error")}},
+							{Names: []*ast.Ident{ast.NewIdent(TemplateDataFieldIdentifierFieldErrors)}, Type: &ast.MapType{Key: ast.NewIdent("string"), Value: ast.NewIdent(templateDataFieldErrorTypeName(templateTypeIdent))}},
+							{Names: []*ast.Ident{ast.NewIdent(TemplateDataFieldIdentifierFormData)}, Type: ast.NewIdent("any")},
 							{Names: []*ast.Ident{ast.NewIdent(TemplateDataFieldIdentifierRedirectURL)}, Type: ast.NewIdent("string")},
 							{Names: []*ast.Ident{ast.NewIdent(pathPrefixPathsStructFieldName)}, Type: ast.NewIdent("string")},
 						},
@@ -291,6 +302,60 @@ func templateDataRequestMethod(file *File, templateDataTypeIdent string) *ast.Fu
 	}
 }
 
+// templateDataFormMethods returns Form and FormValue. They read the submitted
+// values from the request rather than the parsed form argument, so when a
+// field fails to parse or validate (and the receiver method is not called)
+// the template can still echo back what was typed.
+//
+//	func (data *TemplateData[R, T]) Form() url.Values {
+//		if data.request == nil {
+//			return nil
+//		}
+//		return data.request.Form
+//	}
+//
+//	func (data *TemplateData[R, T]) FormValue(name string) string {
+//		return data.Form().Get(name)
+//	}
+func templateDataFormMethods(file *File, templateDataTypeIdent string) []*ast.FuncDecl {
+	const nameIdent = "name"
+	request := &ast.SelectorExpr{X: ast.NewIdent(templateDataReceiverName), Sel: ast.NewIdent("request")}
+	return []*ast.FuncDecl{
+		{
+			Recv: templateDataMethodReceiver(templateDataTypeIdent),
+			Name: ast.NewIdent("Form"),
+			Type: &ast.FuncType{
+				Results: &ast.FieldList{List: []*ast.Field{{Type: astgen.ExportedIdentifier(file, "", "net/url", "Values")}}},
+			},
+			Body: &ast.BlockStmt{
+				List: []ast.Stmt{
+					&ast.IfStmt{
+						Cond: &ast.BinaryExpr{X: request, Op: token.EQL, Y: astgen.Nil()},
+						Body: &ast.BlockStmt{List: []ast.Stmt{&ast.ReturnStmt{Results: []ast.Expr{astgen.Nil()}}}},
+					},
+					&ast.ReturnStmt{Results: []ast.Expr{&ast.SelectorExpr{X: request, Sel: ast.NewIdent("Form")}}},
+				},
+			},
+		},
+		{
+			Recv: templateDataMethodReceiver(templateDataTypeIdent),
+			Name: ast.NewIdent("FormValue"),
+			Type: &ast.FuncType{
+				Params:  &ast.FieldList{List: []*ast.Field{{Names: []*ast.Ident{ast.NewIdent(nameIdent)}, Type: ast.NewIdent("string")}}},
+				Results: &ast.FieldList{List: []*ast.Field{{Type: ast.NewIdent("string")}}},
+			},
+			Body: &ast.BlockStmt{
+				List: []ast.Stmt{
+					&ast.ReturnStmt{Results: []ast.Expr{&ast.CallExpr{
+						Fun:  &ast.SelectorExpr{X: &ast.CallExpr{Fun: &ast.SelectorExpr{X: ast.NewIdent(templateDataReceiverName), Sel: ast.NewIdent("Form")}}, Sel: ast.NewIdent("Get")},
+						Args: []ast.Expr{ast.NewIdent(nameIdent)},
+					}}},
+				},
+			},
+		},
+	}
+}
+
 func templateDataStatusCodeMethod(templateDataTypeIdent string) *ast.FuncDecl {
 	const (
 		scIdent = "statusCode"
@@ -368,35 +433,207 @@ func templateDataHeaderMethod(templateDataTypeIdent string) *ast.FuncDecl {
 	}
 }
 
-func setContentTypeHeaderSetOnTemplateData() *ast.IfStmt {
+// templateDataResponseStatusCodeMethod builds the helper html handlers call
+// to resolve the response status, so each handler does not repeat the status
+// precedence:
+//
+//	func (data *TemplateData[R, T]) responseStatusCode(buf *bytes.Buffer, resultStatusCode, defaultStatusCode int) int {
+//		if defaultStatusCode == http.StatusOK && buf.Len() == 0 {
+//			defaultStatusCode = http.StatusNoContent
+//		}
+//		return cmp.Or(data.statusCode, data.errStatusCode, resultStatusCode, defaultStatusCode)
+//	}
+//
+// resultStatusCode is the result's StatusCode (zero when it has none).
+func templateDataResponseStatusCodeMethod(file *File, templateDataTypeIdent string) *ast.FuncDecl {
 	const (
-		ctIdent  = "contentType"
-		ctHeader = "content-type"
+		bufIdent               = "buf"
+		resultStatusCodeIdent  = "resultStatusCode"
+		defaultStatusCodeIdent = "defaultStatusCode"
 	)
-	return &ast.IfStmt{
-		Init: &ast.AssignStmt{
-			Lhs: []ast.Expr{ast.NewIdent(ctIdent)},
-			Tok: token.DEFINE,
-			Rhs: []ast.Expr{&ast.CallExpr{
-				Fun: &ast.SelectorExpr{
-					X: &ast.CallExpr{
-						Fun: &ast.SelectorExpr{
-							X:   ast.NewIdent(muxt.TemplateNameScopeIdentifierHTTPResponse),
-							Sel: ast.NewIdent("Header"),
-						},
+	field := func(name string) ast.Expr {
+		return &ast.SelectorExpr{X: ast.NewIdent(templateDataReceiverName), Sel: ast.NewIdent(name)}
+	}
+	return &ast.FuncDecl{
+		Recv: templateDataMethodReceiver(templateDataTypeIdent),
+		Name: ast.NewIdent(templateDataResponseStatusCodeMethodName),
+		Type: &ast.FuncType{
+			Params: &ast.FieldList{List: []*ast.Field{
+				{Names: []*ast.Ident{ast.NewIdent(bufIdent)}, Type: &ast.StarExpr{X: astgen.ExportedIdentifier(file, "", "bytes", "Buffer")}},
+				{Names: []*ast.Ident{ast.NewIdent(resultStatusCodeIdent), ast.NewIdent(defaultStatusCodeIdent)}, Type: ast.NewIdent("int")},
+			}},
+			Results: &ast.FieldList{List: []*ast.Field{{Type: ast.NewIdent("int")}}},
+		},
+		Body: &ast.BlockStmt{List: []ast.Stmt{
+			&ast.IfStmt{
+				Cond: &ast.BinaryExpr{
+					X:  &ast.BinaryExpr{X: ast.NewIdent(defaultStatusCodeIdent), Op: token.EQL, Y: astgen.HTTPStatusCode(file, http.StatusOK)},
+					Op: token.LAND,
+					Y: &ast.BinaryExpr{
+						X:  &ast.CallExpr{Fun: &ast.SelectorExpr{X: ast.NewIdent(bufIdent), Sel: ast.NewIdent("Len")}},
+						Op: token.EQL,
+						Y:  astgen.Int(0),
 					},
-					Sel: ast.NewIdent("Get"),
 				},
-				Args: []ast.Expr{astgen.String(ctHeader)},
-			}},
+				Body: &ast.BlockStmt{List: []ast.Stmt{&ast.AssignStmt{
+					Lhs: []ast.Expr{ast.NewIdent(defaultStatusCodeIdent)},
+					Tok: token.ASSIGN,
+					Rhs: []ast.Expr{astgen.HTTPStatusCode(file, http.StatusNoContent)},
+				}}},
+			},
+			&ast.ReturnStmt{Results: []ast.Expr{astgen.CmpOr(file,
+				field(TemplateDataFieldIdentifierStatusCode),
+				field(TemplateDataFieldIdentifierErrStatusCode),
+				ast.NewIdent(resultStatusCodeIdent),
+				ast.NewIdent(defaultStatusCodeIdent),
+			)}},
+		}},
+	}
+}
+
+// templateDataWriteRedirectMethod builds the helper html handlers call after
+// rendering a template that may call .Redirect:
+//
+//	func (data *TemplateData[R, T]) writeRedirect(statusCode int) bool {
+//		if data.redirectURL == "" {
+//			return false
+//		}
+//		http.Redirect(data.response, data.request, data.redirectURL, statusCode)
+//		return true
+//	}
+func templateDataWriteRedirectMethod(file *File, templateDataTypeIdent string) *ast.FuncDecl {
+	const statusCodeIdent = "statusCode"
+	field := func(name string) ast.Expr {
+		return &ast.SelectorExpr{X: ast.NewIdent(templateDataReceiverName), Sel: ast.NewIdent(name)}
+	}
+	return &ast.FuncDecl{
+		Recv: templateDataMethodReceiver(templateDataTypeIdent),
+		Name: ast.NewIdent(templateDataWriteRedirectMethodName),
+		Type: &ast.FuncType{
+			Params:  &ast.FieldList{List: []*ast.Field{{Names: []*ast.Ident{ast.NewIdent(statusCodeIdent)}, Type: ast.NewIdent("int")}}},
+			Results: &ast.FieldList{List: []*ast.Field{{Type: ast.NewIdent("bool")}}},
 		},
-		Cond: &ast.BinaryExpr{X: ast.NewIdent(ctIdent), Op: token.EQL, Y: astgen.String("")},
-		Body: &ast.BlockStmt{
-			List: []ast.Stmt{&ast.ExprStmt{X: &ast.CallExpr{
-				Fun:  &ast.SelectorExpr{X: &ast.CallExpr{Fun: &ast.SelectorExpr{X: ast.NewIdent(muxt.TemplateNameScopeIdentifierHTTPResponse), Sel: ast.NewIdent("Header")}, Args: []ast.Expr{}}, Sel: ast.NewIdent("Set")},
-				Args: []ast.Expr{astgen.String(ctHeader), astgen.String("text/html; charset=utf-8")},
-			}}},
+		Body: &ast.BlockStmt{List: []ast.Stmt{
+			&ast.IfStmt{
+				Cond: &ast.BinaryExpr{X: field(TemplateDataFieldIdentifierRedirectURL), Op: token.EQL, Y: astgen.String("")},
+				Body: &ast.BlockStmt{List: []ast.Stmt{&ast.ReturnStmt{Results: []ast.Expr{astgen.Bool(false)}}}},
+			},
+			&ast.ExprStmt{X: astgen.Call(file, "", "net/http", "Redirect",
+				field(muxt.TemplateNameScopeIdentifierHTTPResponse),
+				field(muxt.TemplateNameScopeIdentifierHTTPRequest),
+				field(TemplateDataFieldIdentifierRedirectURL),
+				ast.NewIdent(statusCodeIdent),
+			)},
+			&ast.ReturnStmt{Results: []ast.Expr{astgen.Bool(true)}},
+		}},
+	}
+}
+
+// templateDataExecuteTemplateFailedMethod builds the helper html handlers
+// call when the route template fails to render. It logs the error and
+// responds 500:
+//
+//	func (data *TemplateData[R, T]) executeTemplateFailed(err error) {
+//		slog.ErrorContext(data.request.Context(), "failed to render page", slog.String("path", data.request.URL.Path), slog.String("pattern", data.request.Pattern), slog.String("error", err.Error()))
+//		http.Error(data.response, "failed to render page", http.StatusInternalServerError)
+//	}
+//
+// With the logger parameter it takes the logger and the route pattern:
+//
+//	func (data *TemplateData[R, T]) executeTemplateFailed(logger *slog.Logger, pattern string, err error) {
+//		logger.ErrorContext(data.request.Context(), "failed to render page", slog.String("pattern", pattern), slog.String("path", data.request.URL.Path), slog.String("error", err.Error()))
+//		http.Error(data.response, "failed to render page", http.StatusInternalServerError)
+//	}
+func templateDataExecuteTemplateFailedMethod(file *File, config RoutesFileConfiguration) *ast.FuncDecl {
+	const (
+		loggerIdent  = "logger"
+		patternIdent = "pattern"
+	)
+	request := &ast.SelectorExpr{X: ast.NewIdent(templateDataReceiverName), Sel: ast.NewIdent(muxt.TemplateNameScopeIdentifierHTTPRequest)}
+	var (
+		params []*ast.Field
+		log    *ast.CallExpr
+	)
+	if config.Logger {
+		params = append(params,
+			&ast.Field{Names: []*ast.Ident{ast.NewIdent(loggerIdent)}, Type: &ast.StarExpr{X: astgen.ExportedIdentifier(file, "", "log/slog", "Logger")}},
+			&ast.Field{Names: []*ast.Ident{ast.NewIdent(patternIdent)}, Type: ast.NewIdent("string")},
+		)
+		log = loggerErrorCallWithRequest(file, request, executeTemplateErrorMessage, ast.NewIdent(patternIdent), errIdent)
+	} else {
+		log = slogErrorCall(file, request, executeTemplateErrorMessage, errIdent)
+	}
+	params = append(params, &ast.Field{Names: []*ast.Ident{ast.NewIdent(errIdent)}, Type: ast.NewIdent("error")})
+	return &ast.FuncDecl{
+		Recv: templateDataMethodReceiver(config.TemplateDataType),
+		Name: ast.NewIdent(templateDataExecuteTemplateFailedMethodName),
+		Type: &ast.FuncType{Params: &ast.FieldList{List: params}},
+		Body: &ast.BlockStmt{List: []ast.Stmt{
+			&ast.ExprStmt{X: log},
+			&ast.ExprStmt{X: astgen.HTTPErrorCall(file, &ast.SelectorExpr{X: ast.NewIdent(templateDataReceiverName), Sel: ast.NewIdent(muxt.TemplateNameScopeIdentifierHTTPResponse)}, astgen.String(executeTemplateErrorMessage), http.StatusInternalServerError)},
+		}},
+	}
+}
+
+// templateDataWriteResponseMethod builds the helper html handlers call to
+// write the rendered page with its headers:
+//
+//	func (data *TemplateData[R, T]) writeResponse(buf *bytes.Buffer, statusCode int) {
+//		if contentType := data.response.Header().Get("content-type"); contentType == "" {
+//			data.response.Header().Set("content-type", "text/html; charset=utf-8")
+//		}
+//		data.response.Header().Set("content-length", strconv.Itoa(buf.Len()))
+//		data.response.WriteHeader(statusCode)
+//		_, _ = buf.WriteTo(data.response)
+//	}
+func templateDataWriteResponseMethod(file *File, templateDataTypeIdent string) *ast.FuncDecl {
+	const (
+		bufIdent         = "buf"
+		statusCodeIdent  = "statusCode"
+		contentTypeIdent = "contentType"
+	)
+	response := func() ast.Expr {
+		return &ast.SelectorExpr{X: ast.NewIdent(templateDataReceiverName), Sel: ast.NewIdent(muxt.TemplateNameScopeIdentifierHTTPResponse)}
+	}
+	header := func(method string, args ...ast.Expr) *ast.CallExpr {
+		return &ast.CallExpr{
+			Fun:  &ast.SelectorExpr{X: &ast.CallExpr{Fun: &ast.SelectorExpr{X: response(), Sel: ast.NewIdent("Header")}}, Sel: ast.NewIdent(method)},
+			Args: args,
+		}
+	}
+	return &ast.FuncDecl{
+		Recv: templateDataMethodReceiver(templateDataTypeIdent),
+		Name: ast.NewIdent(templateDataWriteResponseMethodName),
+		Type: &ast.FuncType{
+			Params: &ast.FieldList{List: []*ast.Field{
+				{Names: []*ast.Ident{ast.NewIdent(bufIdent)}, Type: &ast.StarExpr{X: astgen.ExportedIdentifier(file, "", "bytes", "Buffer")}},
+				{Names: []*ast.Ident{ast.NewIdent(statusCodeIdent)}, Type: ast.NewIdent("int")},
+			}},
 		},
+		Body: &ast.BlockStmt{List: []ast.Stmt{
+			&ast.IfStmt{
+				Init: &ast.AssignStmt{
+					Lhs: []ast.Expr{ast.NewIdent(contentTypeIdent)},
+					Tok: token.DEFINE,
+					Rhs: []ast.Expr{header("Get", astgen.String("content-type"))},
+				},
+				Cond: &ast.BinaryExpr{X: ast.NewIdent(contentTypeIdent), Op: token.EQL, Y: astgen.String("")},
+				Body: &ast.BlockStmt{List: []ast.Stmt{&ast.ExprStmt{X: header("Set", astgen.String("content-type"), astgen.String("text/html; charset=utf-8"))}}},
+			},
+			&ast.ExprStmt{X: header("Set", astgen.String("content-length"), astgen.StrconvItoaCall(file, &ast.CallExpr{Fun: &ast.SelectorExpr{X: ast.NewIdent(bufIdent), Sel: ast.NewIdent("Len")}}))},
+			&ast.ExprStmt{X: &ast.CallExpr{
+				Fun:  &ast.SelectorExpr{X: response(), Sel: ast.NewIdent("WriteHeader")},
+				Args: []ast.Expr{ast.NewIdent(statusCodeIdent)},
+			}},
+			&ast.AssignStmt{
+				Lhs: []ast.Expr{ast.NewIdent("_"), ast.NewIdent("_")},
+				Tok: token.ASSIGN,
+				Rhs: []ast.Expr{&ast.CallExpr{
+					Fun:  &ast.SelectorExpr{X: ast.NewIdent(bufIdent), Sel: ast.NewIdent("WriteTo")},
+					Args: []ast.Expr{response()},
+				}},
+			},
+		}},
 	}
 }
 
@@ -593,3 +830,313 @@ func templateDataHTMXHelperMethods(templateDataTypeIdent string) []*ast.FuncDecl
 		htmxRequestHeaderStringMethod(templateDataTypeIdent, "HXTriggerElementID", "HX-Trigger"),
 	}
 }
+
+// templateDataFieldErrorTypeName is the type recording one form field's
+// parse or validation failure: the TemplateData type name suffixed with
+// FieldError (TemplateDataFieldError, or templateDataFieldError when the
+// template data type is unexported).
+func templateDataFieldErrorTypeName(templateDataTypeIdent string) string {
+	return templateDataTypeIdent + "FieldError"
+}
+
+const (
+	templateDataFieldErrorReceiverName = "e"
+
+	templateDataFieldErrorFieldName       = "Name"
+	templateDataFieldErrorFieldConstraint = "Constraint"
+	templateDataFieldErrorFieldErr        = "Err"
+)
+
+// templateDataFieldErrorDecls returns the field error type and its error
+// methods:
+//
+//	type TemplateDataFieldError struct {
+//		Name       string
+//		Constraint string
+//		Err        error
+//	}
+//
+//	func (e TemplateDataFieldError) Error() string { return e.Err.Error() }
+//	func (e TemplateDataFieldError) Unwrap() error { return e.Err }
+func templateDataFieldErrorDecls(templateDataTypeIdent string) []ast.Decl {
+	typeIdent := templateDataFieldErrorTypeName(templateDataTypeIdent)
+	recv := func() *ast.FieldList {
+		return &ast.FieldList{List: []*ast.Field{{Names: []*ast.Ident{ast.NewIdent(templateDataFieldErrorReceiverName)}, Type: ast.NewIdent(typeIdent)}}}
+	}
+	errField := func() ast.Expr {
+		return &ast.SelectorExpr{X: ast.NewIdent(templateDataFieldErrorReceiverName), Sel: ast.NewIdent(templateDataFieldErrorFieldErr)}
+	}
+	return []ast.Decl{
+		&ast.GenDecl{
+			Tok: token.TYPE,
+			Specs: []ast.Spec{&ast.TypeSpec{
+				Name: ast.NewIdent(typeIdent),
+				Type: &ast.StructType{Fields: &ast.FieldList{List: []*ast.Field{
+					{Names: []*ast.Ident{ast.NewIdent(templateDataFieldErrorFieldName)}, Type: ast.NewIdent("string")},
+					{Names: []*ast.Ident{ast.NewIdent(templateDataFieldErrorFieldConstraint)}, Type: ast.NewIdent("string")},
+					{Names: []*ast.Ident{ast.NewIdent(templateDataFieldErrorFieldErr)}, Type: ast.NewIdent("error")},
+				}}},
+			}},
+		},
+		&ast.FuncDecl{
+			Recv: recv(),
+			Name: ast.NewIdent("Error"),
+			Type: &ast.FuncType{Results: &ast.FieldList{List: []*ast.Field{{Type: ast.NewIdent("string")}}}},
+			Body: &ast.BlockStmt{List: []ast.Stmt{&ast.ReturnStmt{Results: []ast.Expr{
+				&ast.CallExpr{Fun: &ast.SelectorExpr{X: errField(), Sel: ast.NewIdent("Error")}},
+			}}}},
+		},
+		&ast.FuncDecl{
+			Recv: recv(),
+			Name: ast.NewIdent("Unwrap"),
+			Type: &ast.FuncType{Results: &ast.FieldList{List: []*ast.Field{{Type: ast.NewIdent("error")}}}},
+			Body: &ast.BlockStmt{List: []ast.Stmt{&ast.ReturnStmt{Results: []ast.Expr{errField()}}}},
+		},
+	}
+}
+
+func templateDataFieldErrorsMethod(templateDataTypeIdent string) *ast.FuncDecl {
+	return &ast.FuncDecl{
+		Recv: templateDataMethodReceiver(templateDataTypeIdent),
+		Name: ast.NewIdent("FieldErrors"),
+		Type: &ast.FuncType{
+			Results: &ast.FieldList{List: []*ast.Field{{Type: &ast.MapType{Key: ast.NewIdent("string"), Value: ast.NewIdent(templateDataFieldErrorTypeName(templateDataTypeIdent))}}}},
+		},
+		Body: &ast.BlockStmt{List: []ast.Stmt{&ast.ReturnStmt{Results: []ast.Expr{
+			&ast.SelectorExpr{X: ast.NewIdent(templateDataReceiverName), Sel: ast.NewIdent(TemplateDataFieldIdentifierFieldErrors)},
+		}}}},
+	}
+}
+
+// templateDataFieldErrorMethod returns the error recorded for the named form
+// field, or nil so templates can guard with {{with .FieldError "name"}}.
+func templateDataFieldErrorMethod(templateDataTypeIdent string) *ast.FuncDecl {
+	const (
+		nameIdent = "name"
+		feIdent   = "fe"
+		okIdent   = "ok"
+	)
+	return &ast.FuncDecl{
+		Recv: templateDataMethodReceiver(templateDataTypeIdent),
+		Name: ast.NewIdent("FieldError"),
+		Type: &ast.FuncType{
+			Params:  &ast.FieldList{List: []*ast.Field{{Names: []*ast.Ident{ast.NewIdent(nameIdent)}, Type: ast.NewIdent("string")}}},
+			Results: &ast.FieldList{List: []*ast.Field{{Type: &ast.StarExpr{X: ast.NewIdent(templateDataFieldErrorTypeName(templateDataTypeIdent))}}}},
+		},
+		Body: &ast.BlockStmt{List: []ast.Stmt{
+			&ast.IfStmt{
+				Init: &ast.AssignStmt{
+					Lhs: []ast.Expr{ast.NewIdent(feIdent), ast.NewIdent(okIdent)},
+					Tok: token.DEFINE,
+					Rhs: []ast.Expr{&ast.IndexExpr{
+						X:     &ast.SelectorExpr{X: ast.NewIdent(templateDataReceiverName), Sel: ast.NewIdent(TemplateDataFieldIdentifierFieldErrors)},
+						Index: ast.NewIdent(nameIdent),
+					}},
+				},
+				Cond: ast.NewIdent(okIdent),
+				Body: &ast.BlockStmt{List: []ast.Stmt{&ast.ReturnStmt{Results: []ast.Expr{&ast.UnaryExpr{Op: token.AND, X: ast.NewIdent(feIdent)}}}}},
+			},
+			&ast.ReturnStmt{Results: []ast.Expr{astgen.Nil()}},
+		}},
+	}
+}
+
+// templateDataAppendFieldErrorMethod builds the unexported helper handlers use
+// to record a form field failure. The first failure per field is kept in the
+// field error map; every failure is appended to the flat error list so .Err
+// still reports all of them.
+//
+//	func (data *TemplateData[R, T]) appendFieldError(name, constraint string, err error) {
+//		if data.fieldErrors == nil {
+//			data.fieldErrors = make(map[string]TemplateDataFieldError)
+//		}
+//		if _, ok := data.fieldErrors[name]; !ok {
+//			data.fieldErrors[name] = TemplateDataFieldError{Name: name, Constraint: constraint, Err: err}
+//		}
+//		data.errList = append(data.errList, err)
+//	}
+func templateDataAppendFieldErrorMethod(templateDataTypeIdent string) *ast.FuncDecl {
+	const (
+		nameIdent       = "name"
+		constraintIdent = "constraint"
+		okIdent         = "ok"
+	)
+	typeIdent := templateDataFieldErrorTypeName(templateDataTypeIdent)
+	fieldErrors := func() ast.Expr {
+		return &ast.SelectorExpr{X: ast.NewIdent(templateDataReceiverName), Sel: ast.NewIdent(TemplateDataFieldIdentifierFieldErrors)}
+	}
+	errList := func() ast.Expr {
+		return &ast.SelectorExpr{X: ast.NewIdent(templateDataReceiverName), Sel: ast.NewIdent(TemplateDataFieldIdentifierError)}
+	}
+	return &ast.FuncDecl{
+		Recv: templateDataMethodReceiver(templateDataTypeIdent),
+		Name: ast.NewIdent(templateDataAppendFieldErrorMethodName),
+		Type: &ast.FuncType{
+			Params: &ast.FieldList{List: []*ast.Field{
+				{Names: []*ast.Ident{ast.NewIdent(nameIdent), ast.NewIdent(constraintIdent)}, Type: ast.NewIdent("string")},
+				{Names: []*ast.Ident{ast.NewIdent(errIdent)}, Type: ast.NewIdent("error")},
+			}},
+		},
+		Body: &ast.BlockStmt{List: []ast.Stmt{
+			&ast.IfStmt{
+				Cond: &ast.BinaryExpr{X: fieldErrors(), Op: token.EQL, Y: astgen.Nil()},
+				Body: &ast.BlockStmt{List: []ast.Stmt{&ast.AssignStmt{
+					Lhs: []ast.Expr{fieldErrors()},
+					Tok: token.ASSIGN,
+					Rhs: []ast.Expr{astgen.CallBuiltin("make", &ast.MapType{Key: ast.NewIdent("string"), Value: ast.NewIdent(typeIdent)})},
+				}}},
+			},
+			&ast.IfStmt{
+				Init: &ast.AssignStmt{
+					Lhs: []ast.Expr{ast.NewIdent("_"), ast.NewIdent(okIdent)},
+					Tok: token.DEFINE,
+					Rhs: []ast.Expr{&ast.IndexExpr{X: fieldErrors(), Index: ast.NewIdent(nameIdent)}},
+				},
+				Cond: &ast.UnaryExpr{Op: token.NOT, X: ast.NewIdent(okIdent)},
+				Body: &ast.BlockStmt{List: []ast.Stmt{&ast.AssignStmt{
+					Lhs: []ast.Expr{&ast.IndexExpr{X: fieldErrors(), Index: ast.NewIdent(nameIdent)}},
+					Tok: token.ASSIGN,
+					Rhs: []ast.Expr{&ast.CompositeLit{Type: ast.NewIdent(typeIdent), Elts: []ast.Expr{
+						&ast.KeyValueExpr{Key: ast.NewIdent(templateDataFieldErrorFieldName), Value: ast.NewIdent(nameIdent)},
+						&ast.KeyValueExpr{Key: ast.NewIdent(templateDataFieldErrorFieldConstraint), Value: ast.NewIdent(constraintIdent)},
+						&ast.KeyValueExpr{Key: ast.NewIdent(templateDataFieldErrorFieldErr), Value: ast.NewIdent(errIdent)},
+					}}},
+				}}},
+			},
+			&ast.AssignStmt{
+				Lhs: []ast.Expr{errList()},
+				Tok: token.ASSIGN,
+				Rhs: []ast.Expr{astgen.CallBuiltinAppend(errList(), ast.NewIdent(errIdent))},
+			},
+		}},
+	}
+}
+
+// appendTemplateDataFieldError emits td.appendFieldError(name, constraint, err)
+// followed by td.errStatusCode = 400.
+func appendTemplateDataFieldError(file *File, tdIdent string, name ast.Expr, constraint string, err ast.Expr) *ast.BlockStmt {
+	return &ast.BlockStmt{List: []ast.Stmt{
+		&ast.ExprStmt{X: &ast.CallExpr{
+			Fun:  &ast.SelectorExpr{X: ast.NewIdent(tdIdent), Sel: ast.NewIdent(templateDataAppendFieldErrorMethodName)},
+			Args: []ast.Expr{name, astgen.String(constraint), err},
+		}},
+		assignTemplateDataErrStatusCode(file, tdIdent, http.StatusBadRequest),
+	}}
+}
+
+func templateDataFormTypeName(templateDataTypeIdent string) string {
+	return templateDataTypeIdent + "Form"
+}
+
+func templateDataFormConstructorName(templateDataTypeIdent string) string {
+	return "new" + templateDataTypeIdent + "Form"
+}
+
+// templateDataFormDecls returns the template data type of routes with a form
+// or multipart struct argument. It embeds the route's template data and adds
+// FormData, typed as the route's form parameter, so the type checker (and
+// muxt check) resolve {{.FormData.Field}} like any other method. FormData
+// returns the parsed form argument: fields that parsed hold their values and
+// a field that failed to parse keeps its zero value.
+//
+//	type TemplateDataForm[R, T, F any] struct {
+//		*TemplateData[R, T]
+//	}
+//
+//	func (data *TemplateDataForm[R, T, F]) FormData() F {
+//		form, _ := data.formData.(F)
+//		return form
+//	}
+//
+//	func newTemplateDataForm[F, R, T any](data *TemplateData[R, T]) *TemplateDataForm[R, T, F] {
+//		return &TemplateDataForm[R, T, F]{TemplateData: data}
+//	}
+func templateDataFormDecls(templateDataTypeIdent string) []ast.Decl {
+	const formIdent = "form"
+	typeIdent := templateDataFormTypeName(templateDataTypeIdent)
+	typeParams := func(names ...string) *ast.FieldList {
+		idents := make([]*ast.Ident, 0, len(names))
+		for _, name := range names {
+			idents = append(idents, ast.NewIdent(name))
+		}
+		return &ast.FieldList{List: []*ast.Field{{Names: idents, Type: ast.NewIdent("any")}}}
+	}
+	formType := func() *ast.StarExpr {
+		return &ast.StarExpr{X: &ast.IndexListExpr{X: ast.NewIdent(typeIdent), Indices: []ast.Expr{ast.NewIdent("R"), ast.NewIdent("T"), ast.NewIdent("F")}}}
+	}
+	dataType := func() *ast.StarExpr {
+		return &ast.StarExpr{X: &ast.IndexListExpr{X: ast.NewIdent(templateDataTypeIdent), Indices: []ast.Expr{ast.NewIdent("R"), ast.NewIdent("T")}}}
+	}
+	return []ast.Decl{
+		&ast.GenDecl{
+			Tok: token.TYPE,
+			Specs: []ast.Spec{&ast.TypeSpec{
+				Name:       ast.NewIdent(typeIdent),
+				TypeParams: typeParams("R", "T", "F"),
+				Type:       &ast.StructType{Fields: &ast.FieldList{List: []*ast.Field{{Type: dataType()}}}},
+			}},
+		},
+		&ast.FuncDecl{
+			Recv: &ast.FieldList{List: []*ast.Field{{Names: []*ast.Ident{ast.NewIdent(templateDataReceiverName)}, Type: formType()}}},
+			Name: ast.NewIdent("FormData"),
+			Type: &ast.FuncType{Results: &ast.FieldList{List: []*ast.Field{{Type: ast.NewIdent("F")}}}},
+			Body: &ast.BlockStmt{List: []ast.Stmt{
+				&ast.AssignStmt{
+					Lhs: []ast.Expr{ast.NewIdent(formIdent), ast.NewIdent("_")},
+					Tok: token.DEFINE,
+					Rhs: []ast.Expr{&ast.TypeAssertExpr{
+						X:    &ast.SelectorExpr{X: ast.NewIdent(templateDataReceiverName), Sel: ast.NewIdent(TemplateDataFieldIdentifierFormData)},
+						Type: ast.NewIdent("F"),
+					}},
+				},
+				&ast.ReturnStmt{Results: []ast.Expr{ast.NewIdent(formIdent)}},
+			}},
+		},
+		&ast.FuncDecl{
+			Name: ast.NewIdent(templateDataFormConstructorName(templateDataTypeIdent)),
+			Type: &ast.FuncType{
+				TypeParams: typeParams("F", "R", "T"),
+				Params:     &ast.FieldList{List: []*ast.Field{{Names: []*ast.Ident{ast.NewIdent(templateDataReceiverName)}, Type: dataType()}}},
+				Results:    &ast.FieldList{List: []*ast.Field{{Type: formType()}}},
+			},
+			Body: &ast.BlockStmt{List: []ast.Stmt{&ast.ReturnStmt{Results: []ast.Expr{&ast.UnaryExpr{Op: token.AND, X: &ast.CompositeLit{
+				Type: formType().X,
+				Elts: []ast.Expr{&ast.KeyValueExpr{Key: ast.NewIdent(templateDataTypeIdent), Value: ast.NewIdent(templateDataReceiverName)}},
+			}}}}}},
+		},
+	}
+}
+
+// templateDataFormArgument returns the form or multipart struct argument of
+// def, searching nested call arguments too. Handlers record it in
+// TemplateData.formData once it is parsed.
+func templateDataFormArgument(args []muxt.Argument) (muxt.Argument, bool) {
+	for _, arg := range args {
+		if (arg.Type == muxt.ArgumentTypeRequestForm || arg.Type == muxt.ArgumentTypeRequestMultipartForm) && arg.FormFields() != nil {
+			return arg, true
+		}
+		if nested, ok := templateDataFormArgument(arg.Arguments()); ok {
+			return nested, true
+		}
+	}
+	return muxt.Argument{}, false
+}
+
+// templateDataArgument returns the data a route template executes with:
+// &td, or newTemplateDataForm[Form](&td) when the route parses a form struct
+// so the template can read .FormData.
+func templateDataArgument(file *File, config RoutesFileConfiguration, def muxt.Definition, dataIdent string) ast.Expr {
+	data := &ast.UnaryExpr{Op: token.AND, X: ast.NewIdent(dataIdent)}
+	arg, ok := templateDataFormArgument(def.Arguments)
+	if !ok {
+		return data
+	}
+	formType, err := file.TypeASTExpression(arg.ParamType)
+	if err != nil {
+		return data
+	}
+	return &ast.CallExpr{
+		Fun:  &ast.IndexExpr{X: ast.NewIdent(templateDataFormConstructorName(config.TemplateDataType)), Index: formType},
+		Args: []ast.Expr{data},
+	}
+}
@@ -157,57 +157,20 @@ func routePathFunc(file *File, config RoutesFileConfiguration, def *muxt.Definit
 		summer.Write([]byte(def.Name()))
 		pathHash := hex.EncodeToString(summer.Sum(nil))
 
-		if types.Implements(pathValueType, textMarshalerInterface) {
+		segmentIdent := fmt.Sprintf("segment%d_%s", si, pathHash[:8])
+		stmts, exp, marshals, err := pathSegmentExpression(file, textMarshalerInterface, ast.NewIdent(ident), pathValueType, segmentIdent, fmt.Sprintf("failed to marshal path value {%s} (segment %d) in %s: %%w", ident, si, def.Path()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode variable %s: %v", ident, err)
+		}
+		if marshals {
 			hasErrorResult = true
 			if len(method.Type.Results.List) == 1 {
 				method.Type.Results.List = append(method.Type.Results.List, &ast.Field{
 					Type: ast.NewIdent("error"),
 				})
 			}
-			segmentIdent := fmt.Sprintf("segment%d_%s", si, pathHash[:8])
-			method.Body.List = append(method.Body.List, &ast.AssignStmt{
-				Rhs: []ast.Expr{&ast.CallExpr{
-					Fun: &ast.SelectorExpr{
-						X:   ast.NewIdent(ident),
-						Sel: ast.NewIdent("MarshalText"),
-					},
-				}},
-				Tok: token.DEFINE,
-				Lhs: []ast.Expr{
-					ast.NewIdent(segmentIdent),
-					ast.NewIdent("err"),
-				},
-			}, &ast.IfStmt{
-				Cond: &ast.BinaryExpr{X: ast.NewIdent(errIdent), Op: token.NEQ, Y: astgen.Nil()},
-				Body: &ast.BlockStmt{
-					List: []ast.Stmt{
-						&ast.ReturnStmt{
-							Results: []ast.Expr{
-								&ast.BasicLit{Kind: token.STRING, Value: `""`},
-								astgen.Call(file, "fmt", "fmt", "Errorf",
-									astgen.String(fmt.Sprintf("failed to marshal path value {%s} (segment %d) in %s: %%w", ident, si, def.Path())),
-									ast.NewIdent("err"),
-								),
-							},
-						},
-					},
-				},
-			})
-			segmentExpressions = append(segmentExpressions, &ast.CallExpr{
-				Fun:  ast.NewIdent("string"),
-				Args: []ast.Expr{ast.NewIdent(segmentIdent)},
-			})
-			continue
-		}
-
-		basicType, ok := pathValueType.Underlying().(*types.Basic)
-		if !ok {
-			return nil, fmt.Errorf("unsupported type %s for path parameters: %s", astgen.Format(tpNode), ident)
-		}
-		exp, err := astgen.ConvertToString(file, ast.NewIdent(ident), basicType.Kind())
-		if err != nil {
-			return nil, fmt.Errorf("failed to encode variable %s: %v", ident, err)
 		}
+		method.Body.List = append(method.Body.List, stmts...)
 		segmentExpressions = append(segmentExpressions, exp)
 	}
 
@@ -239,3 +202,103 @@ func routePathFunc(file *File, config RoutesFileConfiguration, def *muxt.Definit
 
 	return method, nil
 }
+
+// pathSegmentExpression returns the statements and expression that format a
+// path value of type tp as a path segment. TextMarshaler values are marshaled
+// into segmentIdent, returning errMessage wrapped on failure (marshals is
+// then set). A nil pointer formats as an empty segment.
+func pathSegmentExpression(file *File, textMarshalerInterface *types.Interface, value ast.Expr, tp types.Type, segmentIdent, errMessage string) ([]ast.Stmt, ast.Expr, bool, error) {
+	if ptr, ok := tp.(*types.Pointer); ok {
+		stmts, exp, marshals, err := pathSegmentExpression(file, textMarshalerInterface, &ast.StarExpr{X: value}, ptr.Elem(), segmentIdent+"Value", errMessage)
+		if err != nil {
+			return nil, nil, false, err
+		}
+		return []ast.Stmt{
+			&ast.DeclStmt{Decl: &ast.GenDecl{Tok: token.VAR, Specs: []ast.Spec{&ast.ValueSpec{Names: []*ast.Ident{ast.NewIdent(segmentIdent)}, Type: ast.NewIdent("string")}}}},
+			&ast.IfStmt{
+				Cond: &ast.BinaryExpr{X: value, Op: token.NEQ, Y: astgen.Nil()},
+				Body: &ast.BlockStmt{List: append(stmts, &ast.AssignStmt{
+					Lhs: []ast.Expr{ast.NewIdent(segmentIdent)},
+					Tok: token.ASSIGN,
+					Rhs: []ast.Expr{exp},
+				})},
+			},
+		}, ast.NewIdent(segmentIdent), marshals, nil
+	}
+	if types.Implements(tp, textMarshalerInterface) {
+		return []ast.Stmt{
+			&ast.AssignStmt{
+				Rhs: []ast.Expr{&ast.CallExpr{
+					Fun: &ast.SelectorExpr{
+						X:   selectorOperand(value),
+						Sel: ast.NewIdent("MarshalText"),
+					},
+				}},
+				Tok: token.DEFINE,
+				Lhs: []ast.Expr{
+					ast.NewIdent(segmentIdent),
+					ast.NewIdent("err"),
+				},
+			}, &ast.IfStmt{
+				Cond: &ast.BinaryExpr{X: ast.NewIdent(errIdent), Op: token.NEQ, Y: astgen.Nil()},
+				Body: &ast.BlockStmt{
+					List: []ast.Stmt{
+						&ast.ReturnStmt{
+							Results: []ast.Expr{
+								&ast.BasicLit{Kind: token.STRING, Value: `""`},
+								astgen.Call(file, "fmt", "fmt", "Errorf",
+									astgen.String(errMessage),
+									ast.NewIdent("err"),
+								),
+							},
+						},
+					},
+				},
+			},
+		}, &ast.CallExpr{
+			Fun:  ast.NewIdent("string"),
+			Args: []ast.Expr{ast.NewIdent(segmentIdent)},
+		}, true, nil
+	}
+	if muxt.UnmarshalMethodFor(file.Packages(), tp) == muxt.UnmarshalDuration {
+		// Duration.String round trips through time.ParseDuration
+		return nil, &ast.CallExpr{Fun: &ast.SelectorExpr{X: selectorOperand(value), Sel: ast.NewIdent("String")}}, false, nil
+	}
+	basicType, ok := tp.Underlying().(*types.Basic)
+	if !ok {
+		if hasStringMethod(tp) {
+			// types parsed with a registered parser often only implement fmt.Stringer
+			return nil, &ast.CallExpr{Fun: &ast.SelectorExpr{X: selectorOperand(value), Sel: ast.NewIdent("String")}}, false, nil
+		}
+		tpNode, _ := file.TypeASTExpression(tp)
+		return nil, nil, false, fmt.Errorf("unsupported type %s for path parameters", astgen.Format(tpNode))
+	}
+	exp, err := astgen.ConvertToString(file, value, basicType.Kind())
+	if err != nil {
+		return nil, nil, false, err
+	}
+	return nil, exp, false, nil
+}
+
+// hasStringMethod reports whether tp has a String() string method.
+func hasStringMethod(tp types.Type) bool {
+	obj, _, _ := types.LookupFieldOrMethod(tp, true, nil, "String")
+	fn, ok := obj.(*types.Func)
+	if !ok {
+		return false
+	}
+	sig := fn.Type().(*types.Signature)
+	if sig.Params().Len() != 0 || sig.Results().Len() != 1 {
+		return false
+	}
+	result, ok := sig.Results().At(0).Type().(*types.Basic)
+	return ok && result.Kind() == types.String
+}
+
+// selectorOperand parenthesizes a dereference used as a selector operand.
+func selectorOperand(x ast.Expr) ast.Expr {
+	if _, ok := x.(*ast.StarExpr); ok {
+		return &ast.ParenExpr{X: x}
+	}
+	return x
+}
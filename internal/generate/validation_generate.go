@@ -4,18 +4,38 @@ import (
 	"fmt"
 	"go/ast"
 	"go/token"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
 
 	"github.com/typelate/muxt/internal/astgen"
 	"github.com/typelate/muxt/internal/muxt"
 )
 
-type ValidationErrorBlock func(string) *ast.BlockStmt
+// ValidationErrorBlock builds the statements run when the value of the named
+// form field violates constraint ("required", "min", "max", "step",
+// "pattern", "email", "url", "minlength", "maxlength", or "option"); message
+// describes the violation.
+type ValidationErrorBlock func(name, constraint, message string) *ast.BlockStmt
+
+// FileValidationErrorBlock is ValidationErrorBlock for upload constraints
+// ("accept" or "max-size"), which respond with different status codes.
+type FileValidationErrorBlock func(name, constraint, message string, statusCode int) *ast.BlockStmt
 
 // renderValidations renders the guard statements for the input constraints
 // resolved by muxt.ResolveCall (muxt.ParseInputValidations).
+//
+// muxt.RequiredValidation is skipped: it checks the raw request value rather
+// than the parsed variable, so callers render it with requiredValidation.
 func renderValidations(im astgen.ImportManager, variable ast.Expr, validations []muxt.InputValidation, handleError ValidationErrorBlock) []ast.Stmt {
 	var statements []ast.Stmt
 	for _, validation := range validations {
+		if _, ok := validation.(muxt.RequiredValidation); ok {
+			continue
+		}
 		statements = append(statements, renderValidation(im, variable, validation, handleError))
 	}
 	return statements
@@ -24,22 +44,34 @@ func renderValidations(im astgen.ImportManager, variable ast.Expr, validations [
 func renderValidation(im astgen.ImportManager, variable ast.Expr, validation muxt.InputValidation, handleError ValidationErrorBlock) ast.Stmt {
 	switch val := validation.(type) {
 	case muxt.MinValidation:
+		if val.Layout != "" {
+			return &ast.IfStmt{
+				Cond: &ast.CallExpr{Fun: &ast.SelectorExpr{X: variable, Sel: ast.NewIdent("Before")}, Args: []ast.Expr{timeBound(im, val.Layout, val.Min)}},
+				Body: handleError(val.Name, "min", fmt.Sprintf("%s must not be before %s", val.Name, val.Min)),
+			}
+		}
 		return &ast.IfStmt{
 			Cond: &ast.BinaryExpr{
 				X:  variable,
 				Op: token.LSS, // value < 13
 				Y:  &ast.BasicLit{Value: val.Min, Kind: token.INT},
 			},
-			Body: handleError(fmt.Sprintf("%s must not be less than %s", val.Name, val.Min)),
+			Body: handleError(val.Name, "min", fmt.Sprintf("%s must not be less than %s", val.Name, val.Min)),
 		}
 	case muxt.MaxValidation:
+		if val.Layout != "" {
+			return &ast.IfStmt{
+				Cond: &ast.CallExpr{Fun: &ast.SelectorExpr{X: variable, Sel: ast.NewIdent("After")}, Args: []ast.Expr{timeBound(im, val.Layout, val.Max)}},
+				Body: handleError(val.Name, "max", fmt.Sprintf("%s must not be after %s", val.Name, val.Max)),
+			}
+		}
 		return &ast.IfStmt{
 			Cond: &ast.BinaryExpr{
 				X:  variable,
 				Op: token.GTR, // value > 13
 				Y:  &ast.BasicLit{Value: val.Max, Kind: token.INT},
 			},
-			Body: handleError(fmt.Sprintf("%s must not be more than %s", val.Name, val.Max)),
+			Body: handleError(val.Name, "max", fmt.Sprintf("%s must not be more than %s", val.Name, val.Max)),
 		}
 	case muxt.PatternValidation:
 		return &ast.IfStmt{
@@ -53,7 +85,7 @@ func renderValidation(im astgen.ImportManager, variable ast.Expr, validation mux
 					Args: []ast.Expr{variable},
 				},
 			},
-			Body: handleError(fmt.Sprintf("%s must match %q", val.Name, val.Pattern.String())),
+			Body: handleError(val.Name, "pattern", fmt.Sprintf("%s must match %q", val.Name, val.Pattern.String())),
 		}
 	case muxt.MinLengthValidation:
 		return &ast.IfStmt{
@@ -62,7 +94,7 @@ func renderValidation(im astgen.ImportManager, variable ast.Expr, validation mux
 				Op: token.LSS,
 				Y:  astgen.Int(val.MinLength),
 			},
-			Body: handleError(fmt.Sprintf("%s is too short (the min length is %d)", val.Name, val.MinLength)),
+			Body: handleError(val.Name, "minlength", fmt.Sprintf("%s is too short (the min length is %d)", val.Name, val.MinLength)),
 		}
 	case muxt.MaxLengthValidation:
 		return &ast.IfStmt{
@@ -71,9 +103,374 @@ func renderValidation(im astgen.ImportManager, variable ast.Expr, validation mux
 				Op: token.GTR,
 				Y:  astgen.Int(val.MaxLength),
 			},
-			Body: handleError(fmt.Sprintf("%s is too long (the max length is %d)", val.Name, val.MaxLength)),
+			Body: handleError(val.Name, "maxlength", fmt.Sprintf("%s is too long (the max length is %d)", val.Name, val.MaxLength)),
+		}
+	case muxt.RequiredValidation:
+		// variable is the raw string (or []string for slice fields)
+		return &ast.IfStmt{
+			Cond: &ast.BinaryExpr{
+				X:  &ast.CallExpr{Fun: ast.NewIdent("len"), Args: []ast.Expr{variable}},
+				Op: token.EQL,
+				Y:  astgen.Int(0),
+			},
+			Body: handleError(val.Name, "required", fmt.Sprintf("%s is required", val.Name)),
+		}
+	case muxt.StepValidation:
+		if val.Tolerance != "" {
+			return floatStepValidation(im, variable, val, handleError)
+		}
+		offset := variable
+		message := fmt.Sprintf("%s must be a multiple of %s", val.Name, val.Step)
+		if val.Base != "0" {
+			offset = &ast.ParenExpr{X: &ast.BinaryExpr{X: variable, Op: token.SUB, Y: &ast.BasicLit{Value: val.Base, Kind: token.INT}}}
+			message = fmt.Sprintf("%s must be %s plus a multiple of %s", val.Name, val.Base, val.Step)
+		}
+		return &ast.IfStmt{
+			Cond: &ast.BinaryExpr{
+				X:  &ast.BinaryExpr{X: offset, Op: token.REM, Y: &ast.BasicLit{Value: val.Step, Kind: token.INT}},
+				Op: token.NEQ,
+				Y:  astgen.Int(0),
+			},
+			Body: handleError(val.Name, "step", message),
+		}
+	case muxt.EmailValidation:
+		return &ast.IfStmt{
+			Cond: &ast.BinaryExpr{
+				X:  &ast.BinaryExpr{X: variable, Op: token.NEQ, Y: astgen.String("")},
+				Op: token.LAND,
+				Y: &ast.UnaryExpr{
+					Op: token.NOT,
+					X: &ast.CallExpr{
+						Fun: &ast.SelectorExpr{
+							X:   astgen.Call(im, "", "regexp", "MustCompile", astgen.String(muxt.EmailPattern)),
+							Sel: ast.NewIdent("MatchString"),
+						},
+						Args: []ast.Expr{variable},
+					},
+				},
+			},
+			Body: handleError(val.Name, "email", fmt.Sprintf("%s must be an email address", val.Name)),
+		}
+	case muxt.URLValidation:
+		const u = "u"
+		return &ast.IfStmt{
+			Init: &ast.AssignStmt{
+				Lhs: []ast.Expr{ast.NewIdent(u), ast.NewIdent(errIdent)},
+				Tok: token.DEFINE,
+				Rhs: []ast.Expr{astgen.Call(im, "", "net/url", "Parse", variable)},
+			},
+			Cond: &ast.BinaryExpr{
+				X:  &ast.BinaryExpr{X: variable, Op: token.NEQ, Y: astgen.String("")},
+				Op: token.LAND,
+				Y: &ast.ParenExpr{X: &ast.BinaryExpr{
+					X:  &ast.BinaryExpr{X: ast.NewIdent(errIdent), Op: token.NEQ, Y: astgen.Nil()},
+					Op: token.LOR,
+					Y:  &ast.UnaryExpr{Op: token.NOT, X: &ast.CallExpr{Fun: &ast.SelectorExpr{X: ast.NewIdent(u), Sel: ast.NewIdent("IsAbs")}}},
+				}},
+			},
+			Body: handleError(val.Name, "url", fmt.Sprintf("%s must be an absolute URL", val.Name)),
+		}
+	case muxt.OptionValidation:
+		// value != "a" && value != "b" works for any string or integer
+		// field type without naming it.
+		literal := func(option string) ast.Expr {
+			if val.String {
+				return astgen.String(option)
+			}
+			return &ast.BasicLit{Value: option, Kind: token.INT}
+		}
+		options := val.Options
+		if val.String && !slices.Contains(options, "") {
+			options = append([]string{""}, options...)
+		}
+		var cond ast.Expr
+		for _, option := range options {
+			neq := &ast.BinaryExpr{X: variable, Op: token.NEQ, Y: literal(option)}
+			if cond == nil {
+				cond = neq
+			} else {
+				cond = &ast.BinaryExpr{X: cond, Op: token.LAND, Y: neq}
+			}
+		}
+		return &ast.IfStmt{
+			Cond: cond,
+			Body: handleError(val.Name, "option", fmt.Sprintf("%s must be one of %q", val.Name, val.Options)),
 		}
 	default:
 		panic(fmt.Sprintf("unknown input validation type %T", validation))
 	}
 }
+
+// timeBound renders the min or max attribute of a time input, checked by
+// muxt.ParseInputValidations, as a time.Date call.
+func timeBound(im astgen.ImportManager, layout, value string) ast.Expr {
+	t, err := time.Parse(layout, value)
+	if err != nil {
+		panic(fmt.Sprintf("time bound %q does not match layout %q: %v", value, layout, err))
+	}
+	return astgen.TimeDateCall(im, t)
+}
+
+// floatStepValidation renders the step check of a floating point field. The
+// value is a multiple of the step when its number of steps from the base is
+// within the tolerance (scaled by the number of steps) of an integer:
+//
+//	if steps := (float64(v) - 0.5) / 0.25; math.Abs(steps-math.Round(steps)) > 1e-9*math.Max(1, math.Abs(steps)) {
+//		// 400 Bad Request
+//	}
+func floatStepValidation(im astgen.ImportManager, variable ast.Expr, val muxt.StepValidation, handleError ValidationErrorBlock) *ast.IfStmt {
+	const stepsIdent = "steps"
+	offset := ast.Expr(&ast.CallExpr{Fun: ast.NewIdent("float64"), Args: []ast.Expr{variable}})
+	message := fmt.Sprintf("%s must be a multiple of %s", val.Name, val.Step)
+	if val.Base != "0" {
+		offset = &ast.ParenExpr{X: &ast.BinaryExpr{X: offset, Op: token.SUB, Y: &ast.BasicLit{Value: val.Base, Kind: token.FLOAT}}}
+		message = fmt.Sprintf("%s must be %s plus a multiple of %s", val.Name, val.Base, val.Step)
+	}
+	steps := ast.NewIdent(stepsIdent)
+	return &ast.IfStmt{
+		Init: singleAssignment(token.DEFINE, ast.NewIdent(stepsIdent))(&ast.BinaryExpr{X: offset, Op: token.QUO, Y: &ast.BasicLit{Value: val.Step, Kind: token.FLOAT}}),
+		Cond: &ast.BinaryExpr{
+			X:  astgen.Call(im, "", "math", "Abs", &ast.BinaryExpr{X: steps, Op: token.SUB, Y: astgen.Call(im, "", "math", "Round", steps)}),
+			Op: token.GTR,
+			Y: &ast.BinaryExpr{
+				X:  &ast.BasicLit{Value: val.Tolerance, Kind: token.FLOAT},
+				Op: token.MUL,
+				Y:  astgen.Call(im, "", "math", "Max", &ast.BasicLit{Value: "1", Kind: token.INT}, astgen.Call(im, "", "math", "Abs", steps)),
+			},
+		},
+		Body: handleError(val.Name, "step", message),
+	}
+}
+
+// requiredValidation returns the required check for raw, the unparsed
+// request value (a string, or []string for slice fields), with parse as its
+// else branch so a missing value is reported once as "required" rather than
+// also failing to parse. It returns nil when the field is not required.
+func requiredValidation(im astgen.ImportManager, raw ast.Expr, validations []muxt.InputValidation, handleError ValidationErrorBlock, parse []ast.Stmt) *ast.IfStmt {
+	for _, validation := range validations {
+		if required, ok := validation.(muxt.RequiredValidation); ok {
+			stmt := renderValidation(im, raw, required, handleError).(*ast.IfStmt)
+			stmt.Else = &ast.BlockStmt{List: parse}
+			return stmt
+		}
+	}
+	return nil
+}
+
+// renderFileValidations renders the upload constraints for a
+// *multipart.FileHeader (or, with slice, []*multipart.FileHeader) field:
+//
+//	if fh := form.Avatar; fh != nil {
+//		if fh.Size > 1048576 {
+//			// 413 Request Entity Too Large
+//		}
+//		// accept="image/*,.png"
+//		{
+//			sniffed := ""
+//			if f, err := fh.Open(); err == nil {
+//				head := make([]byte, 512)
+//				n, _ := io.ReadFull(f, head)
+//				_ = f.Close()
+//				sniffed = http.DetectContentType(head[:n])
+//			}
+//			header := fh.Header.Get("Content-Type")
+//			if ext := strings.ToLower(filepath.Ext(fh.Filename)); ext != ".png" && !(strings.HasPrefix(sniffed, "image/") && (header == "" || header == "application/octet-stream" || strings.HasPrefix(header, "image/"))) {
+//				// 400 Bad Request
+//			}
+//		}
+//	}
+func renderFileValidations(im astgen.ImportManager, field ast.Expr, slice bool, validations []muxt.InputValidation, handleError FileValidationErrorBlock) ast.Stmt {
+	const fh = "fh"
+	// check sizes before opening the file to sniff its type
+	validations = slices.Clone(validations)
+	slices.SortStableFunc(validations, func(a, b muxt.InputValidation) int {
+		_, aSize := a.(muxt.FileMaxSizeValidation)
+		_, bSize := b.(muxt.FileMaxSizeValidation)
+		switch {
+		case aSize && !bSize:
+			return -1
+		case bSize && !aSize:
+			return 1
+		}
+		return 0
+	})
+	var checks []ast.Stmt
+	for _, validation := range validations {
+		switch val := validation.(type) {
+		case muxt.FileMaxSizeValidation:
+			checks = append(checks, &ast.IfStmt{
+				Cond: &ast.BinaryExpr{
+					X:  &ast.SelectorExpr{X: ast.NewIdent(fh), Sel: ast.NewIdent("Size")},
+					Op: token.GTR,
+					Y:  astgen.Int(int(val.MaxSize)),
+				},
+				Body: handleError(val.Name, "max-size", fmt.Sprintf("%s is too large (the max size is %s)", val.Name, humanize.IBytes(uint64(val.MaxSize))), http.StatusRequestEntityTooLarge),
+			})
+		case muxt.FileAcceptValidation:
+			checks = append(checks, renderFileAcceptValidation(im, fh, val, handleError))
+		default:
+			panic(fmt.Sprintf("unknown file input validation type %T", validation))
+		}
+	}
+	if slice {
+		return &ast.RangeStmt{
+			Key:   ast.NewIdent("_"),
+			Value: ast.NewIdent(fh),
+			Tok:   token.DEFINE,
+			X:     field,
+			Body:  &ast.BlockStmt{List: checks},
+		}
+	}
+	return &ast.IfStmt{
+		Init: &ast.AssignStmt{Lhs: []ast.Expr{ast.NewIdent(fh)}, Tok: token.DEFINE, Rhs: []ast.Expr{field}},
+		Cond: &ast.BinaryExpr{X: ast.NewIdent(fh), Op: token.NEQ, Y: astgen.Nil()},
+		Body: &ast.BlockStmt{List: checks},
+	}
+}
+
+func renderFileAcceptValidation(im astgen.ImportManager, fh string, val muxt.FileAcceptValidation, handleError FileValidationErrorBlock) ast.Stmt {
+	const (
+		ext     = "ext"
+		sniffed = "sniffed"
+		generic = "generic"
+		header  = "header"
+		f       = "f"
+		head    = "head"
+		n       = "n"
+	)
+	var (
+		statements []ast.Stmt
+		rejected   ast.Expr
+	)
+	and := func(x, y ast.Expr) ast.Expr {
+		if x == nil {
+			return y
+		}
+		return &ast.BinaryExpr{X: x, Op: token.LAND, Y: y}
+	}
+	for _, extension := range val.Extensions {
+		rejected = and(rejected, &ast.BinaryExpr{X: ast.NewIdent(ext), Op: token.NEQ, Y: astgen.String(extension)})
+	}
+	if len(val.MIMETypes) > 0 {
+		// A missing or generic part Content-Type (multipart.Writer.CreateFormFile
+		// sends application/octet-stream) says nothing about the file, so
+		// only the sniffed type is checked.
+		const genericContentType = "application/octet-stream"
+		// matches renders tp matching any accepted MIME type; "image/*"
+		// matches by prefix, and the prefix match for exact types permits
+		// parameters such as "; charset=utf-8".
+		matches := func(tp string, mimeTypes []string) ast.Expr {
+			var or ast.Expr
+			for _, mimeType := range mimeTypes {
+				prefix := strings.TrimSuffix(mimeType, "*")
+				m := astgen.Call(im, "", "strings", "HasPrefix", ast.NewIdent(tp), astgen.String(prefix))
+				if or == nil {
+					or = m
+				} else {
+					or = &ast.BinaryExpr{X: or, Op: token.LOR, Y: m}
+				}
+			}
+			if len(mimeTypes) > 1 {
+				return &ast.ParenExpr{X: or}
+			}
+			return or
+		}
+		statements = append(statements,
+			&ast.AssignStmt{Lhs: []ast.Expr{ast.NewIdent(sniffed)}, Tok: token.DEFINE, Rhs: []ast.Expr{astgen.String("")}},
+			&ast.IfStmt{
+				Init: &ast.AssignStmt{
+					Lhs: []ast.Expr{ast.NewIdent(f), ast.NewIdent(errIdent)},
+					Tok: token.DEFINE,
+					Rhs: []ast.Expr{&ast.CallExpr{Fun: &ast.SelectorExpr{X: ast.NewIdent(fh), Sel: ast.NewIdent("Open")}}},
+				},
+				Cond: &ast.BinaryExpr{X: ast.NewIdent(errIdent), Op: token.EQL, Y: astgen.Nil()},
+				Body: &ast.BlockStmt{List: []ast.Stmt{
+					&ast.AssignStmt{Lhs: []ast.Expr{ast.NewIdent(head)}, Tok: token.DEFINE, Rhs: []ast.Expr{astgen.CallBuiltin("make", &ast.ArrayType{Elt: ast.NewIdent("byte")}, astgen.Int(512))}},
+					&ast.AssignStmt{Lhs: []ast.Expr{ast.NewIdent(n), ast.NewIdent("_")}, Tok: token.DEFINE, Rhs: []ast.Expr{astgen.Call(im, "", "io", "ReadFull", ast.NewIdent(f), ast.NewIdent(head))}},
+					&ast.AssignStmt{Lhs: []ast.Expr{ast.NewIdent("_")}, Tok: token.ASSIGN, Rhs: []ast.Expr{&ast.CallExpr{Fun: &ast.SelectorExpr{X: ast.NewIdent(f), Sel: ast.NewIdent("Close")}}}},
+					&ast.AssignStmt{Lhs: []ast.Expr{ast.NewIdent(sniffed)}, Tok: token.ASSIGN, Rhs: []ast.Expr{astgen.Call(im, "", "net/http", "DetectContentType", &ast.SliceExpr{X: ast.NewIdent(head), High: ast.NewIdent(n)})}},
+				}},
+			},
+			&ast.AssignStmt{
+				Lhs: []ast.Expr{ast.NewIdent(header)},
+				Tok: token.DEFINE,
+				Rhs: []ast.Expr{&ast.CallExpr{
+					Fun:  &ast.SelectorExpr{X: &ast.SelectorExpr{X: ast.NewIdent(fh), Sel: ast.NewIdent("Header")}, Sel: ast.NewIdent("Get")},
+					Args: []ast.Expr{astgen.String("Content-Type")},
+				}},
+			},
+		)
+		accepted := &ast.BinaryExpr{
+			X:  matches(sniffed, val.MIMETypes),
+			Op: token.LAND,
+			Y: &ast.ParenExpr{X: &ast.BinaryExpr{
+				X: &ast.BinaryExpr{
+					X:  &ast.BinaryExpr{X: ast.NewIdent(header), Op: token.EQL, Y: astgen.String("")},
+					Op: token.LOR,
+					Y:  &ast.BinaryExpr{X: ast.NewIdent(header), Op: token.EQL, Y: astgen.String(genericContentType)},
+				},
+				Op: token.LOR,
+				Y:  matches(header, val.MIMETypes),
+			}},
+		}
+		unsniffable := slices.DeleteFunc(slices.Clone(val.MIMETypes), isSniffedMIMEType)
+		if len(unsniffable) == 0 {
+			rejected = and(rejected, &ast.UnaryExpr{Op: token.NOT, X: &ast.ParenExpr{X: accepted}})
+		} else {
+			// http.DetectContentType reports text/plain for CSV and JSON and
+			// application/zip for OOXML documents, so for types it cannot
+			// identify a generic sniffed type defers to the Content-Type
+			// header.
+			statements = append(statements, &ast.AssignStmt{
+				Lhs: []ast.Expr{ast.NewIdent(generic)},
+				Tok: token.DEFINE,
+				Rhs: []ast.Expr{&ast.BinaryExpr{
+					X: &ast.BinaryExpr{
+						X:  astgen.Call(im, "", "strings", "HasPrefix", ast.NewIdent(sniffed), astgen.String("text/plain")),
+						Op: token.LOR,
+						Y:  &ast.BinaryExpr{X: ast.NewIdent(sniffed), Op: token.EQL, Y: astgen.String(genericContentType)},
+					},
+					Op: token.LOR,
+					Y:  &ast.BinaryExpr{X: ast.NewIdent(sniffed), Op: token.EQL, Y: astgen.String("application/zip")},
+				}},
+			})
+			rejected = and(rejected, &ast.UnaryExpr{Op: token.NOT, X: &ast.ParenExpr{X: &ast.BinaryExpr{
+				X:  accepted,
+				Op: token.LOR,
+				Y:  &ast.BinaryExpr{X: ast.NewIdent(generic), Op: token.LAND, Y: matches(header, unsniffable)},
+			}}})
+		}
+	}
+	check := &ast.IfStmt{
+		Cond: rejected,
+		Body: handleError(val.Name, "accept", fmt.Sprintf("%s file type is not accepted (accept is %q)", val.Name, val.Accept), http.StatusBadRequest),
+	}
+	if len(val.Extensions) > 0 {
+		check.Init = &ast.AssignStmt{
+			Lhs: []ast.Expr{ast.NewIdent(ext)},
+			Tok: token.DEFINE,
+			Rhs: []ast.Expr{astgen.Call(im, "", "strings", "ToLower", astgen.Call(im, "", "path/filepath", "Ext", &ast.SelectorExpr{X: ast.NewIdent(fh), Sel: ast.NewIdent("Filename")}))},
+		}
+	}
+	return &ast.BlockStmt{List: append(statements, check)}
+}
+
+// isSniffedMIMEType reports whether http.DetectContentType identifies
+// mimeType from file content. An upload claiming one of these types must
+// sniff as it; for other types (text/csv, application/json, OOXML documents)
+// the sniffed type is generic and the Content-Type header is trusted.
+func isSniffedMIMEType(mimeType string) bool {
+	for _, prefix := range []string{"image/", "audio/", "video/", "font/"} {
+		if strings.HasPrefix(mimeType, prefix) {
+			return true
+		}
+	}
+	switch mimeType {
+	case "text/plain", "text/html", "text/xml", "text/*",
+		"application/pdf", "application/postscript", "application/ogg",
+		"application/zip", "application/x-gzip", "application/x-rar-compressed",
+		"application/wasm", "application/vnd.ms-fontobject", "application/octet-stream":
+		return true
+	}
+	return false
+}
@@ -236,7 +236,7 @@ func Test_inputValidations(t *testing.T) {
 			Name:     "wrong tag",
 			Type:     types.Universe.Lookup("int").Type(),
 			Template: `<form type="number" name="field" min="32"></form>`,
-			Error:    `expected element to have tag <input> got <form>`,
+			Error:    `expected element to have tag <input>, <textarea>, or <select> got <form>`,
 		},
 		{
 			Name:     "zero max",
@@ -289,6 +289,184 @@ func Test_inputValidations(t *testing.T) {
 	}
 }`,
 		},
+		{
+			Name:     "step",
+			Type:     types.Universe.Lookup("int").Type(),
+			Template: `<input type="number" name="field" step="5">`,
+			Result: `{
+	if v%5 != 0 {
+		http.Error(response, "field must be a multiple of 5", http.StatusBadRequest)
+		return
+	}
+}`,
+		},
+		{
+			Name:     "step from min",
+			Type:     types.Universe.Lookup("int").Type(),
+			Template: `<input type="range" name="field" min="1" step="2">`,
+			Result: `{
+	if v < 1 {
+		http.Error(response, "field must not be less than 1", http.StatusBadRequest)
+		return
+	}
+	if (v-1)%2 != 0 {
+		http.Error(response, "field must be 1 plus a multiple of 2", http.StatusBadRequest)
+		return
+	}
+}`,
+		},
+		{
+			Name:     "step and min with leading zeros",
+			Type:     types.Universe.Lookup("int").Type(),
+			Template: `<input type="number" name="field" min="09" step="010">`,
+			Result: `{
+	if v < 9 {
+		http.Error(response, "field must not be less than 9", http.StatusBadRequest)
+		return
+	}
+	if (v-9)%10 != 0 {
+		http.Error(response, "field must be 9 plus a multiple of 10", http.StatusBadRequest)
+		return
+	}
+}`,
+		},
+		{
+			Name:     "step any",
+			Type:     types.Universe.Lookup("int").Type(),
+			Template: `<input type="number" name="field" step="any">`,
+			Result: `{
+}`,
+		},
+		{
+			Name:     "step one",
+			Type:     types.Universe.Lookup("int").Type(),
+			Template: `<input type="number" name="field" step="1">`,
+			Result: `{
+}`,
+		},
+		{
+			Name:     "step zero",
+			Type:     types.Universe.Lookup("uint").Type(),
+			Template: `<input type="number" name="field" step="0">`,
+			Error:    `step must be greater than zero`,
+		},
+		{
+			Name:     "float step from min",
+			Type:     types.Universe.Lookup("float64").Type(),
+			Template: `<input type="number" name="field" min="0.5" step="0.25">`,
+			Result: `{
+	if v < 0.5 {
+		http.Error(response, "field must not be less than 0.5", http.StatusBadRequest)
+		return
+	}
+	if steps := (float64(v) - 0.5) / 0.25; math.Abs(steps-math.Round(steps)) > 1e-9*math.Max(1, math.Abs(steps)) {
+		http.Error(response, "field must be 0.5 plus a multiple of 0.25", http.StatusBadRequest)
+		return
+	}
+}`,
+		},
+		{
+			Name:     "float32 step",
+			Type:     types.Universe.Lookup("float32").Type(),
+			Template: `<input type="number" name="field" step="0.1">`,
+			Result: `{
+	if steps := float64(v) / 0.1; math.Abs(steps-math.Round(steps)) > 1e-6*math.Max(1, math.Abs(steps)) {
+		http.Error(response, "field must be a multiple of 0.1", http.StatusBadRequest)
+		return
+	}
+}`,
+		},
+		{
+			Name:     "week bound to time",
+			Type:     types.NewNamed(types.NewTypeName(0, types.NewPackage("time", "time"), "Time", nil), types.NewStruct(nil, nil), nil),
+			Template: `<input type="week" name="field">`,
+			Error:    `type="week" inputs can not bind to time.Time: time.Parse has no layout for ISO weeks (2006-W01); use a string field`,
+		},
+		{
+			Name:     "step not a number",
+			Type:     types.Universe.Lookup("int").Type(),
+			Template: `<input type="number" name="field" step="1.5">`,
+			Error:    `step must parse as int: strconv.ParseInt: parsing "1.5": invalid syntax`,
+		},
+		{
+			Name:     "email",
+			Type:     types.Universe.Lookup("string").Type(),
+			Template: `<input type="email" name="field">`,
+			Result: `{
+	if v != "" && !regexp.MustCompile("^[a-zA-Z0-9.!#$%&'*+/=?^_` + "`" + `{|}~-]+@[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?(?:\\.[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*$").MatchString(v) {
+		http.Error(response, "field must be an email address", http.StatusBadRequest)
+		return
+	}
+}`,
+		},
+		{
+			Name:     "url",
+			Type:     types.Universe.Lookup("string").Type(),
+			Template: `<input type="url" name="field">`,
+			Result: `{
+	if u, err := url.Parse(v); v != "" && (err != nil || !u.IsAbs()) {
+		http.Error(response, "field must be an absolute URL", http.StatusBadRequest)
+		return
+	}
+}`,
+		},
+		{
+			Name:     "textarea length",
+			Type:     types.Universe.Lookup("string").Type(),
+			Template: `<textarea name="field" maxlength="140" required></textarea>`,
+			Result: `{
+	if len(v) > 140 {
+		http.Error(response, "field is too long (the max length is 140)", http.StatusBadRequest)
+		return
+	}
+}`,
+		},
+		{
+			Name:     "select options",
+			Type:     types.Universe.Lookup("string").Type(),
+			Template: `<select name="field"><option>red</option><option value="g">Green</option></select>`,
+			Result: `{
+	if v != "" && v != "red" && v != "g" {
+		http.Error(response, "field must be one of [\"red\" \"g\"]", http.StatusBadRequest)
+		return
+	}
+}`,
+		},
+		{
+			Name:     "integer select options",
+			Type:     types.Universe.Lookup("int").Type(),
+			Template: `<select name="field"><option value="1">One</option><option value="2">Two</option></select>`,
+			Result: `{
+	if v != 1 && v != 2 {
+		http.Error(response, "field must be one of [\"1\" \"2\"]", http.StatusBadRequest)
+		return
+	}
+}`,
+		},
+		{
+			Name:     "integer select options with leading zeros",
+			Type:     types.Universe.Lookup("int").Type(),
+			Template: `<select name="field"><option value="08">Aug</option><option value="010">Oct</option></select>`,
+			Result: `{
+	if v != 8 && v != 10 {
+		http.Error(response, "field must be one of [\"8\" \"10\"]", http.StatusBadRequest)
+		return
+	}
+}`,
+		},
+		{
+			Name:     "dynamic select options",
+			Type:     types.Universe.Lookup("string").Type(),
+			Template: `<select name="field">{{range .}}<option>{{.}}</option>{{end}}</select>`,
+			Result: `{
+}`,
+		},
+		{
+			Name:     "integer select option not a number",
+			Type:     types.Universe.Lookup("int").Type(),
+			Template: `<select name="field"><option>one</option></select>`,
+			Error:    `option value "one" must parse as int: strconv.ParseInt: parsing "one": invalid syntax`,
+		},
 	} {
 		t.Run(fmt.Sprintf("%s for type %s", tt.Name, tt.Type), func(t *testing.T) {
 			v := ast.NewIdent("v")
@@ -318,7 +496,7 @@ func Test_inputValidations(t *testing.T) {
 				assert.Equal(t, tt.Error, err.Error())
 			} else {
 				require.NoError(t, err)
-				statements := renderValidations(file, v, validations, func(s string) *ast.BlockStmt {
+				statements := renderValidations(file, v, validations, func(_, _, s string) *ast.BlockStmt {
 					return &ast.BlockStmt{List: []ast.Stmt{
 						&ast.ExprStmt{X: &ast.CallExpr{
 							Fun: &ast.SelectorExpr{X: ast.NewIdent("http"), Sel: ast.NewIdent("Error")},
@@ -336,3 +514,71 @@ func Test_inputValidations(t *testing.T) {
 		})
 	}
 }
+
+func Test_fileInputValidations(t *testing.T) {
+	for _, tt := range []struct {
+		Name     string
+		Template string
+		MaxSize  string
+		Result   []muxt.InputValidation
+		Error    string
+	}{
+		{
+			Name:     "accept and data-max-size",
+			Template: `<input type="file" name="field" accept="image/*, .PNG" data-max-size="2KB">`,
+			Result: []muxt.InputValidation{
+				muxt.FileAcceptValidation{Name: "field", Accept: "image/*, .PNG", Extensions: []string{".png"}, MIMETypes: []string{"image/*"}},
+				muxt.FileMaxSizeValidation{Name: "field", MaxSize: 2000},
+			},
+		},
+		{
+			Name:     "struct tag overrides data-max-size",
+			Template: `<input type="file" name="field" data-max-size="2KB">`,
+			MaxSize:  "1KiB",
+			Result:   []muxt.InputValidation{muxt.FileMaxSizeValidation{Name: "field", MaxSize: 1024}},
+		},
+		{
+			Name:     "accept anything",
+			Template: `<input type="file" name="field" accept="*/*">`,
+		},
+		{
+			Name:     "not a file input",
+			Template: `<input type="text" name="field">`,
+			Error:    `expected file field field to bind to <input type="file"> got <input type="text">`,
+		},
+		{
+			Name:     "bad accept token",
+			Template: `<input type="file" name="field" accept="png">`,
+			Error:    `accept value "png" must be a file extension or MIME type`,
+		},
+		{
+			Name:     "bad size",
+			Template: `<input type="file" name="field" data-max-size="large">`,
+			Error:    `max size for field must be a byte size: strconv.ParseFloat: parsing "": invalid syntax`,
+		},
+		{
+			Name:     "zero size",
+			Template: `<input type="file" name="field" data-max-size="0">`,
+			Error:    `max size for field must be between 1 byte and 9223372036854775807 bytes got "0"`,
+		},
+	} {
+		t.Run(tt.Name, func(t *testing.T) {
+			nodes, err := html.ParseFragment(strings.NewReader(tt.Template), &html.Node{
+				Type:     html.ElementNode,
+				DataAtom: atom.Body,
+				Data:     atom.Body.String(),
+			})
+			require.NoError(t, err)
+			input := dom.NewDocumentFragment(nodes).QuerySelector(`[name="field"]`)
+			require.NotNil(t, input)
+			validations, err := muxt.ParseFileInputValidations("field", input, tt.MaxSize)
+			if tt.Error != "" {
+				require.Error(t, err)
+				assert.Equal(t, tt.Error, err.Error())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.Result, validations)
+		})
+	}
+}
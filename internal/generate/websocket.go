@@ -0,0 +1,346 @@
+package generate
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"net/http"
+	"slices"
+
+	"github.com/typelate/muxt/internal/astgen"
+	"github.com/typelate/muxt/internal/muxt"
+)
+
+const (
+	webSocketPackagePath     = "golang.org/x/net/websocket"
+	webSocketSameOriginIdent = "webSocketSameOrigin"
+)
+
+// webSocketSameOriginFunc returns the handshake ws routes upgrade with. It
+// rejects a handshake (websocket.Server responds 403) whose Origin host is
+// not the request host, so another site cannot open a connection with the
+// user's cookies. RoutesFileConfiguration.WebSocketAllowCrossOrigin omits it.
+//
+//	func webSocketSameOrigin(config *websocket.Config, request *http.Request) error {
+//		origin, err := websocket.Origin(config, request)
+//		if err != nil {
+//			return err
+//		}
+//		if origin == nil || origin.Host != request.Host {
+//			return errors.New("websocket origin does not match the request host")
+//		}
+//		config.Origin = origin
+//		return nil
+//	}
+func webSocketSameOriginFunc(file *File) *ast.FuncDecl {
+	const (
+		configIdent = "config"
+		originIdent = "origin"
+	)
+	request := muxt.TemplateNameScopeIdentifierHTTPRequest
+	return &ast.FuncDecl{
+		Name: ast.NewIdent(webSocketSameOriginIdent),
+		Type: &ast.FuncType{
+			Params: &ast.FieldList{List: []*ast.Field{
+				{Names: []*ast.Ident{ast.NewIdent(configIdent)}, Type: &ast.StarExpr{X: astgen.ExportedIdentifier(file, "", webSocketPackagePath, "Config")}},
+				{Names: []*ast.Ident{ast.NewIdent(request)}, Type: astgen.HTTPRequestPtr(file)},
+			}},
+			Results: &ast.FieldList{List: []*ast.Field{{Type: ast.NewIdent("error")}}},
+		},
+		Body: &ast.BlockStmt{List: []ast.Stmt{
+			&ast.AssignStmt{
+				Lhs: []ast.Expr{ast.NewIdent(originIdent), ast.NewIdent(errIdent)},
+				Tok: token.DEFINE,
+				Rhs: []ast.Expr{astgen.Call(file, "", webSocketPackagePath, "Origin", ast.NewIdent(configIdent), ast.NewIdent(request))},
+			},
+			&ast.IfStmt{
+				Cond: &ast.BinaryExpr{X: ast.NewIdent(errIdent), Op: token.NEQ, Y: astgen.Nil()},
+				Body: &ast.BlockStmt{List: []ast.Stmt{&ast.ReturnStmt{Results: []ast.Expr{ast.NewIdent(errIdent)}}}},
+			},
+			&ast.IfStmt{
+				Cond: &ast.BinaryExpr{
+					X:  &ast.BinaryExpr{X: ast.NewIdent(originIdent), Op: token.EQL, Y: astgen.Nil()},
+					Op: token.LOR,
+					Y: &ast.BinaryExpr{
+						X:  &ast.SelectorExpr{X: ast.NewIdent(originIdent), Sel: ast.NewIdent("Host")},
+						Op: token.NEQ,
+						Y:  &ast.SelectorExpr{X: ast.NewIdent(request), Sel: ast.NewIdent("Host")},
+					},
+				},
+				Body: &ast.BlockStmt{List: []ast.Stmt{&ast.ReturnStmt{Results: []ast.Expr{
+					astgen.Call(file, "", "errors", "New", astgen.String("websocket origin does not match the request host")),
+				}}}},
+			},
+			singleAssignment(token.ASSIGN, &ast.SelectorExpr{X: ast.NewIdent(configIdent), Sel: ast.NewIdent("Origin")})(ast.NewIdent(originIdent)),
+			&ast.ReturnStmt{Results: []ast.Expr{astgen.Nil()}},
+		}},
+	}
+}
+
+// webSocketMethodHandlerFunc builds the http.HandlerFunc for a route wrapped in
+// ws(...). Arguments are parsed before the upgrade (a parse failure responds
+// 400), then the connection is upgraded with websocket.Server and the
+// receiver method is invoked with an execute closure that renders the route
+// template into one text frame per call and a receive closure that decodes
+// the next client message.
+func webSocketMethodHandlerFunc(file *File, config RoutesFileConfiguration, def muxt.Definition, sig *types.Signature, receiverInterfaceName string) (*ast.FuncLit, error) {
+	const (
+		connIdent  = "conn"
+		mutexIdent = "mut"
+	)
+	response := muxt.TemplateNameScopeIdentifierHTTPResponse
+	request := muxt.TemplateNameScopeIdentifierHTTPRequest
+
+	methodReturnsErr := def.ResultShape() == muxt.ResultShapeError
+
+	functionIdent := def.FunctionIdentifier().Name
+
+	var callFun ast.Expr
+	if def.IsMethod() {
+		callFun = &ast.SelectorExpr{X: ast.NewIdent(receiverIdent), Sel: ast.NewIdent(functionIdent)}
+	} else {
+		callFun = ast.NewIdent(def.FunctionIdentifier().Name)
+	}
+
+	handlerFunc := &ast.FuncLit{
+		Type: astgen.HTTPHandlerFuncType(file, response, request),
+		Body: &ast.BlockStmt{},
+	}
+
+	// Parse ctx and any path params into locals. A typed parse failure
+	// responds 400 and returns before the connection is upgraded.
+	parseErrBlock := func() *ast.BlockStmt {
+		return &ast.BlockStmt{List: []ast.Stmt{
+			&ast.ExprStmt{X: astgen.HTTPErrorCall(file, ast.NewIdent(response), astgen.CallError(errIdent), http.StatusBadRequest)},
+			&ast.ReturnStmt{},
+		}}
+	}
+	validationFailureBlock := func(string, string, string) *ast.BlockStmt { return parseErrBlock() }
+	body, err := appendParseArgumentStatements(nil, def, file, types.NewStruct(nil, nil), sig, def.Arguments, nil, "", config, def.CallExpression(), validationFailureBlock, parseErrBlock)
+	if err != nil {
+		return nil, err
+	}
+	body = append(body, authorizeStatements(file, def, "")...)
+
+	callArgs := slices.Clone(def.CallExpression().Args)
+	for i, arg := range def.Arguments {
+		switch arg.Type {
+		case muxt.ArgumentTypeExecute:
+			if arg.Identifier != muxt.TemplateNameScopeIdentifierExecute {
+				continue
+			}
+			closure, err := webSocketExecuteClosure(file, config, def, arg.Template().Name(), arg.CallbackResultType(), arg.CallbackHasArg(), receiverInterfaceName, connIdent, mutexIdent)
+			if err != nil {
+				return nil, err
+			}
+			callArgs[i] = closure
+		case muxt.ArgumentTypeReceive:
+			closure, err := webSocketReceiveClosure(file, arg.CallbackResultType(), connIdent)
+			if err != nil {
+				return nil, err
+			}
+			callArgs[i] = closure
+		}
+	}
+	callExpr := &ast.CallExpr{Fun: callFun, Args: callArgs}
+
+	connBody := []ast.Stmt{
+		// defer func() { _ = conn.Close() }()
+		&ast.DeferStmt{Call: &ast.CallExpr{Fun: &ast.FuncLit{
+			Type: &ast.FuncType{Params: &ast.FieldList{}},
+			Body: &ast.BlockStmt{List: []ast.Stmt{&ast.AssignStmt{
+				Lhs: []ast.Expr{ast.NewIdent("_")},
+				Tok: token.ASSIGN,
+				Rhs: []ast.Expr{&ast.CallExpr{Fun: &ast.SelectorExpr{X: ast.NewIdent(connIdent), Sel: ast.NewIdent("Close")}}},
+			}}},
+		}}},
+		// var mut sync.Mutex
+		&ast.DeclStmt{Decl: &ast.GenDecl{Tok: token.VAR, Specs: []ast.Spec{&ast.ValueSpec{
+			Names: []*ast.Ident{ast.NewIdent(mutexIdent)},
+			Type:  astgen.ExportedIdentifier(file, "", "sync", "Mutex"),
+		}}}},
+	}
+	if methodReturnsErr {
+		connBody = append(connBody, &ast.IfStmt{
+			Init: &ast.AssignStmt{Lhs: []ast.Expr{ast.NewIdent(errIdent)}, Tok: token.DEFINE, Rhs: []ast.Expr{callExpr}},
+			Cond: &ast.BinaryExpr{X: ast.NewIdent(errIdent), Op: token.NEQ, Y: astgen.Nil()},
+			Body: &ast.BlockStmt{List: []ast.Stmt{&ast.ExprStmt{X: executeTemplateFailedLogLine(file, "ws handler returned an error", errIdent)}}},
+		})
+	} else {
+		connBody = append(connBody, &ast.ExprStmt{X: callExpr})
+	}
+
+	// websocket.Server{Handshake: webSocketSameOrigin, Handler: func(conn *websocket.Conn) { ... }}.ServeHTTP(response, request)
+	var server []ast.Expr
+	if !config.WebSocketAllowCrossOrigin {
+		server = append(server, &ast.KeyValueExpr{Key: ast.NewIdent("Handshake"), Value: ast.NewIdent(webSocketSameOriginIdent)})
+	}
+	server = append(server, &ast.KeyValueExpr{Key: ast.NewIdent("Handler"), Value: &ast.FuncLit{
+		Type: &ast.FuncType{Params: &ast.FieldList{List: []*ast.Field{{
+			Names: []*ast.Ident{ast.NewIdent(connIdent)},
+			Type:  &ast.StarExpr{X: astgen.ExportedIdentifier(file, "", webSocketPackagePath, "Conn")},
+		}}}},
+		Body: &ast.BlockStmt{List: connBody},
+	}})
+	body = append(body, &ast.ExprStmt{X: &ast.CallExpr{
+		Fun: &ast.SelectorExpr{
+			X:   &ast.CompositeLit{Type: astgen.ExportedIdentifier(file, "", webSocketPackagePath, "Server"), Elts: server},
+			Sel: ast.NewIdent("ServeHTTP"),
+		},
+		Args: []ast.Expr{ast.NewIdent(response), ast.NewIdent(request)},
+	}})
+
+	handlerFunc.Body.List = slices.Concat(recoverPanicStatements(file, config, def, "", nil), routeOptionStatements(file, def), body)
+	return handlerFunc, nil
+}
+
+// webSocketExecuteClosure builds the execute callback passed to the receiver
+// method. Each call renders the route template into a pooled buffer and sends
+// it as one text frame under a mutex:
+//
+//	func(result T) error {
+//		buf := bytesBufferPool.Get().(*bytes.Buffer)
+//		buf.Reset()
+//		defer bytesBufferPool.Put(buf)
+//		td := WebSocketTemplateData[Recv, T]{receiver: receiver, request: request, pathsPrefix: pathsPrefix, result: result}
+//		if err := routeTemplate.Execute(buf, &td); err != nil { slog...; return err }
+//		mut.Lock()
+//		defer mut.Unlock()
+//		return websocket.Message.Send(conn, buf.String())
+//	}
+//
+// For the zero-arg form it omits the parameter and the result field.
+func webSocketExecuteClosure(file *File, config RoutesFileConfiguration, def muxt.Definition, templateName string, resultType types.Type, hasArg bool, receiverInterfaceName, connIdent, mutexIdent string) (*ast.FuncLit, error) {
+	const (
+		bufIdent    = "buf"
+		tdIdent     = "td"
+		resultIdent = "result"
+	)
+	request := muxt.TemplateNameScopeIdentifierHTTPRequest
+
+	resultTypeExpr, err := file.TypeASTExpression(resultType)
+	if err != nil {
+		return nil, err
+	}
+
+	var params []*ast.Field
+	tdElts := []ast.Expr{
+		&ast.KeyValueExpr{Key: ast.NewIdent(TemplateDataFieldIdentifierReceiver), Value: ast.NewIdent(receiverIdent)},
+		&ast.KeyValueExpr{Key: ast.NewIdent(request), Value: ast.NewIdent(request)},
+		&ast.KeyValueExpr{Key: ast.NewIdent(pathPrefixPathsStructFieldName), Value: ast.NewIdent(pathPrefixPathsStructFieldName)},
+	}
+	if hasArg {
+		params = append(params, &ast.Field{Names: []*ast.Ident{ast.NewIdent(resultIdent)}, Type: resultTypeExpr})
+		tdElts = append(tdElts, &ast.KeyValueExpr{Key: ast.NewIdent(TemplateDataFieldIdentifierResult), Value: ast.NewIdent(resultIdent)})
+	}
+
+	body := astgen.GetBufferFromPool(file, bufferPoolIdent, bufIdent)
+	body = append(body,
+		// td := WebSocketTemplateData[Recv, T]{...}
+		&ast.AssignStmt{
+			Lhs: []ast.Expr{ast.NewIdent(tdIdent)},
+			Tok: token.DEFINE,
+			Rhs: []ast.Expr{&ast.CompositeLit{
+				Type: &ast.IndexListExpr{X: ast.NewIdent(config.WebSocketTemplateDataType), Indices: []ast.Expr{ast.NewIdent(receiverInterfaceName), resultTypeExpr}},
+				Elts: tdElts,
+			}},
+		},
+		// if err := routeTemplate.Execute(buf, &td); err != nil { slog...; return err }
+		&ast.IfStmt{
+			Init: &ast.AssignStmt{Lhs: []ast.Expr{ast.NewIdent(errIdent)}, Tok: token.DEFINE, Rhs: []ast.Expr{executeTemplateCall(def, templateName, ast.NewIdent(bufIdent), &ast.UnaryExpr{Op: token.AND, X: ast.NewIdent(tdIdent)})}},
+			Cond: &ast.BinaryExpr{X: ast.NewIdent(errIdent), Op: token.NEQ, Y: astgen.Nil()},
+			Body: &ast.BlockStmt{List: []ast.Stmt{
+				&ast.ExprStmt{X: executeTemplateFailedLogLine(file, executeTemplateErrorMessage, errIdent)},
+				&ast.ReturnStmt{Results: []ast.Expr{ast.NewIdent(errIdent)}},
+			}},
+		},
+		// mut.Lock()
+		&ast.ExprStmt{X: &ast.CallExpr{Fun: &ast.SelectorExpr{X: ast.NewIdent(mutexIdent), Sel: ast.NewIdent("Lock")}}},
+		// defer mut.Unlock()
+		&ast.DeferStmt{Call: &ast.CallExpr{Fun: &ast.SelectorExpr{X: ast.NewIdent(mutexIdent), Sel: ast.NewIdent("Unlock")}}},
+		// return websocket.Message.Send(conn, buf.String())
+		&ast.ReturnStmt{Results: []ast.Expr{&ast.CallExpr{
+			Fun: &ast.SelectorExpr{X: astgen.ExportedIdentifier(file, "", webSocketPackagePath, "Message"), Sel: ast.NewIdent("Send")},
+			Args: []ast.Expr{
+				ast.NewIdent(connIdent),
+				&ast.CallExpr{Fun: &ast.SelectorExpr{X: ast.NewIdent(bufIdent), Sel: ast.NewIdent("String")}},
+			},
+		}}},
+	)
+
+	return &ast.FuncLit{
+		Type: &ast.FuncType{
+			Params:  &ast.FieldList{List: params},
+			Results: &ast.FieldList{List: []*ast.Field{{Type: ast.NewIdent("error")}}},
+		},
+		Body: &ast.BlockStmt{List: body},
+	}, nil
+}
+
+// webSocketReceiveClosure builds the receive callback passed to the receiver
+// method. Each call blocks for the next client message and decodes it into T:
+// a string receives the raw message, url.Values parses it as a form-encoded
+// query, and any other type is decoded as JSON.
+//
+//	func() (T, error) {
+//		var result T
+//		var message string
+//		if err := websocket.Message.Receive(conn, &message); err != nil { return result, err }
+//		err := json.Unmarshal([]byte(message), &result)
+//		return result, err
+//	}
+func webSocketReceiveClosure(file *File, resultType types.Type, connIdent string) (*ast.FuncLit, error) {
+	const (
+		resultIdent  = "result"
+		messageIdent = "message"
+	)
+	resultTypeExpr, err := file.TypeASTExpression(resultType)
+	if err != nil {
+		return nil, err
+	}
+
+	body := []ast.Stmt{
+		&ast.DeclStmt{Decl: &ast.GenDecl{Tok: token.VAR, Specs: []ast.Spec{&ast.ValueSpec{
+			Names: []*ast.Ident{ast.NewIdent(resultIdent)},
+			Type:  resultTypeExpr,
+		}}}},
+		&ast.DeclStmt{Decl: &ast.GenDecl{Tok: token.VAR, Specs: []ast.Spec{&ast.ValueSpec{
+			Names: []*ast.Ident{ast.NewIdent(messageIdent)},
+			Type:  ast.NewIdent("string"),
+		}}}},
+		// if err := websocket.Message.Receive(conn, &message); err != nil { return result, err }
+		&ast.IfStmt{
+			Init: &ast.AssignStmt{Lhs: []ast.Expr{ast.NewIdent(errIdent)}, Tok: token.DEFINE, Rhs: []ast.Expr{&ast.CallExpr{
+				Fun:  &ast.SelectorExpr{X: astgen.ExportedIdentifier(file, "", webSocketPackagePath, "Message"), Sel: ast.NewIdent("Receive")},
+				Args: []ast.Expr{ast.NewIdent(connIdent), &ast.UnaryExpr{Op: token.AND, X: ast.NewIdent(messageIdent)}},
+			}}},
+			Cond: &ast.BinaryExpr{X: ast.NewIdent(errIdent), Op: token.NEQ, Y: astgen.Nil()},
+			Body: &ast.BlockStmt{List: []ast.Stmt{&ast.ReturnStmt{Results: []ast.Expr{ast.NewIdent(resultIdent), ast.NewIdent(errIdent)}}}},
+		},
+	}
+
+	switch {
+	case types.Identical(resultType, types.Universe.Lookup("string").Type()):
+		body = append(body, &ast.ReturnStmt{Results: []ast.Expr{ast.NewIdent(messageIdent), astgen.Nil()}})
+	case muxt.IsNamedType(resultType, "net/url", "Values"):
+		body = append(body, &ast.ReturnStmt{Results: []ast.Expr{astgen.Call(file, "", "net/url", "ParseQuery", ast.NewIdent(messageIdent))}})
+	default:
+		body = append(body,
+			&ast.AssignStmt{Lhs: []ast.Expr{ast.NewIdent(errIdent)}, Tok: token.DEFINE, Rhs: []ast.Expr{
+				astgen.Call(file, "", "encoding/json", "Unmarshal",
+					&ast.CallExpr{Fun: &ast.ArrayType{Elt: ast.NewIdent("byte")}, Args: []ast.Expr{ast.NewIdent(messageIdent)}},
+					&ast.UnaryExpr{Op: token.AND, X: ast.NewIdent(resultIdent)},
+				),
+			}},
+			&ast.ReturnStmt{Results: []ast.Expr{ast.NewIdent(resultIdent), ast.NewIdent(errIdent)}},
+		)
+	}
+
+	return &ast.FuncLit{
+		Type: &ast.FuncType{
+			Params:  &ast.FieldList{},
+			Results: &ast.FieldList{List: []*ast.Field{{Type: resultTypeExpr}, {Type: ast.NewIdent("error")}}},
+		},
+		Body: &ast.BlockStmt{List: body},
+	}, nil
+}
@@ -9,6 +9,8 @@ import (
 	"html/template"
 	"slices"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	"golang.org/x/tools/go/packages"
 
@@ -23,16 +25,22 @@ type Argument struct {
 	template *template.Template
 
 	// sig, args, and isMethod describe a nested call argument
-	// (Type == ArgumentTypeCall): the nested call's signature, its own
-	// hydrated arguments, and whether it resolves to a receiver method.
+	// (Type == ArgumentTypeCall) or a provider argument
+	// (Type == ArgumentTypeProvider): the called function's signature, its
+	// own hydrated arguments, and whether it resolves to a receiver method.
 	sig      *types.Signature
 	args     []Argument
 	isMethod bool
 
+	// provider is the receiver method a provider argument calls.
+	provider string
+
 	// callbackResult and callbackHasArg describe a validated render-callback
 	// argument (Type == ArgumentTypeExecute): the template data type T the
 	// callback receives and whether the callback takes that data argument
-	// (func(T) error vs func() error, where T = struct{}).
+	// (func(T) error vs func() error, where T = struct{}). For a ws receive
+	// callback (Type == ArgumentTypeReceive) callbackResult is the message
+	// type T of func() (T, error).
 	callbackResult types.Type
 	callbackHasArg bool
 
@@ -43,7 +51,8 @@ type Argument struct {
 }
 
 // Signature returns the resolved signature of a nested call argument
-// (Type == ArgumentTypeCall), or nil for a leaf argument.
+// (Type == ArgumentTypeCall) or of a provider method
+// (Type == ArgumentTypeProvider), or nil for a leaf argument.
 func (a Argument) Signature() *types.Signature { return a.sig }
 
 // IsMethod reports whether a nested call argument resolves to a receiver method
@@ -53,6 +62,9 @@ func (a Argument) IsMethod() bool { return a.isMethod }
 // Arguments returns the hydrated arguments of a nested call argument.
 func (a Argument) Arguments() []Argument { return a.args }
 
+// Provider returns the receiver method name a provider argument calls.
+func (a Argument) Provider() string { return a.provider }
+
 // Template returns the template a render-callback argument (ArgumentTypeExecute)
 // renders: the route template for the base execute callback, or the same-named
 // template for an sse-prefixed callback (nil if that template does not exist).
@@ -69,7 +81,8 @@ func (a Argument) CallbackSignature() *types.Signature {
 }
 
 // CallbackResultType returns the template data type T a validated
-// render-callback argument receives (struct{} for a func() error callback).
+// render-callback argument receives (struct{} for a func() error callback),
+// or the message type T a validated receive callback returns.
 func (a Argument) CallbackResultType() types.Type { return a.callbackResult }
 
 // CallbackHasArg reports whether a validated render-callback argument's
@@ -95,6 +108,10 @@ const (
 	ArgumentTypeLastEventID
 	ArgumentTypeRequestBodyJSON
 	ArgumentTypeCall
+	// ArgumentTypeProvider is an identifier outside the template name scope
+	// resolved by a Provide<Name>(*http.Request) receiver method.
+	ArgumentTypeProvider
+	ArgumentTypeReceive
 )
 
 // ResultShape classifies a handler method's results. It is resolved during
@@ -104,7 +121,7 @@ type ResultShape int
 
 const (
 	ResultShapeInvalid ResultShape = iota
-	// ResultShapeNone is an sse handler method with no results: func(...)
+	// ResultShapeNone is an sse or ws handler method with no results: func(...)
 	ResultShapeNone
 	// ResultShapeData is func(...) T
 	ResultShapeData
@@ -113,14 +130,37 @@ const (
 	// ResultShapeDataOK is func(...) (T, bool)
 	ResultShapeDataOK
 	// ResultShapeError is func(...) error: required for methods receiving the
-	// execute callback and permitted for sse handler methods.
+	// execute callback and permitted for sse and ws handler methods.
 	ResultShapeError
 )
 
-func ResolveCall(def *Definition, templatesPackage *types.Package, receiver *types.Named, pl []*packages.Package) error {
+// ResultSequence classifies a data result the route template ranges over
+// while the handler streams the response. It is resolved alongside
+// ResultShape.
+type ResultSequence int
+
+const (
+	// ResultSequenceNone is a result that is not a range-over-func sequence.
+	ResultSequenceNone ResultSequence = iota
+	// ResultSequenceSeq is a result with the shape of iter.Seq[T] or
+	// iter.Seq2[K, V] where V is not error.
+	ResultSequenceSeq
+	// ResultSequenceSeqError is a result with the shape of
+	// iter.Seq2[T, error]; iteration stops at the first error.
+	ResultSequenceSeqError
+)
+
+func ResolveCall(def *Definition, templatesPackage *types.Package, receiver *types.Named, pl []*packages.Package, parsers Parsers) error {
+	authorize, err := AuthorizeMethod(receiver)
+	if err != nil {
+		return err
+	}
+	def.authorize = authorize
 	if def.call == nil || def.fun == nil {
 		return nil
 	}
+	def.templatesPackage = templatesPackage
+	def.parsers = parsers
 	sig, isMethod, args, err := resolveCall(def, def.call, templatesPackage, receiver, pl)
 	if err != nil {
 		return err
@@ -133,18 +173,30 @@ func ResolveCall(def *Definition, templatesPackage *types.Package, receiver *typ
 		return err
 	}
 	def.resultShape = shape
+	if shape == ResultShapeData || shape == ResultShapeDataError || shape == ResultShapeDataOK {
+		def.resultSequence = classifyResultSequence(def.sig.Results().At(0).Type())
+	}
 	return resolveCallbackShapes(def)
 }
 
 // resolveCallbackShapes validates each render-callback argument against the
 // callback contract — func() error (T = struct{}) or func(T) error — and
 // records T and whether the callback takes the data argument. On sse routes
-// every callback argument is checked; on html routes only the base execute
-// argument is (sse-prefixed callbacks are inert there).
+// every callback argument is checked; on html and ws routes only the base
+// execute argument is (sse-prefixed callbacks are inert there). A ws receive
+// argument must be a func() (T, error).
 func resolveCallbackShapes(def *Definition) error {
 	errIface := types.Universe.Lookup("error").Type().Underlying().(*types.Interface)
 	for i := range def.Arguments {
 		a := &def.Arguments[i]
+		if a.Type == ArgumentTypeReceive {
+			callback := a.CallbackSignature()
+			if callback == nil || callback.Params().Len() != 0 || callback.Results().Len() != 2 || !types.Implements(callback.Results().At(1).Type(), errIface) {
+				return fmt.Errorf("receive argument for %s must be a func() (T, error)", def.fun.Name)
+			}
+			a.callbackResult = callback.Results().At(0).Type()
+			continue
+		}
 		if a.Type != ArgumentTypeExecute {
 			continue
 		}
@@ -179,20 +231,20 @@ func resolveCallbackShapes(def *Definition) error {
 }
 
 // classifyResultShape validates def's method results against its contract:
-// sse methods return nothing or an error, methods receiving the execute
+// sse and ws methods return nothing or an error, methods receiving the execute
 // callback return only error, and all other methods return a value plus an
 // optional error or bool.
 func classifyResultShape(def *Definition) (ResultShape, error) {
 	results := def.sig.Results()
 	errIface := types.Universe.Lookup("error").Type().Underlying().(*types.Interface)
-	if def.Representation == RepresentationSSE {
+	if def.Representation == RepresentationSSE || def.Representation == RepresentationWebSocket {
 		switch {
 		case results.Len() == 0:
 			return ResultShapeNone, nil
 		case results.Len() == 1 && types.Implements(results.At(0).Type(), errIface):
 			return ResultShapeError, nil
 		default:
-			return ResultShapeInvalid, fmt.Errorf("method %s using the sse callback must return nothing or an error", def.fun.Name)
+			return ResultShapeInvalid, fmt.Errorf("method %s using the %s callback must return nothing or an error", def.fun.Name, def.Representation)
 		}
 	}
 	if slices.ContainsFunc(def.Arguments, func(a Argument) bool {
@@ -222,6 +274,31 @@ func classifyResultShape(def *Definition) (ResultShape, error) {
 	}
 }
 
+// classifyResultSequence reports whether tp can be ranged over as a function
+// iterator (func(yield func(V) bool) or func(yield func(K, V) bool)), the
+// shape text/template ranges over.
+func classifyResultSequence(tp types.Type) ResultSequence {
+	sig, ok := tp.Underlying().(*types.Signature)
+	if !ok || sig.Variadic() || sig.Params().Len() != 1 || sig.Results().Len() != 0 {
+		return ResultSequenceNone
+	}
+	yield, ok := sig.Params().At(0).Type().Underlying().(*types.Signature)
+	if !ok || yield.Variadic() || yield.Results().Len() != 1 || !types.Identical(yield.Results().At(0).Type(), types.Typ[types.Bool]) {
+		return ResultSequenceNone
+	}
+	switch yield.Params().Len() {
+	case 1:
+		return ResultSequenceSeq
+	case 2:
+		if types.Identical(yield.Params().At(1).Type(), types.Universe.Lookup("error").Type()) {
+			return ResultSequenceSeqError
+		}
+		return ResultSequenceSeq
+	default:
+		return ResultSequenceNone
+	}
+}
+
 // checkNestedCallResultShape validates a nested call's results: one value,
 // optionally followed by an error or bool.
 func checkNestedCallResultShape(name string, sig *types.Signature) error {
@@ -309,6 +386,14 @@ func resolveCall(def *Definition, call *ast.CallExpr, templatesPackage *types.Pa
 				args = append(args, Argument{Identifier: argument.Name})
 				continue
 			}
+			if isProviderArgument(def, argument.Name) {
+				arg, err := newProviderArgument(pl, receiver, argument, i, paramType, qual)
+				if err != nil {
+					return nil, false, nil, err
+				}
+				args = append(args, arg)
+				continue
+			}
 			arg, err := newArgumentFromIdentifier(def, pl, argument, paramType, qual)
 			if err != nil {
 				return nil, false, nil, err
@@ -350,18 +435,27 @@ func resolveCall(def *Definition, call *ast.CallExpr, templatesPackage *types.Pa
 func synthesizeCallSignature(def *Definition, call *ast.CallExpr, templatesPackage *types.Package, receiver *types.Named, pl []*packages.Package) (*types.Signature, error) {
 	var params []*types.Var
 	hasSSE := false
-	for _, a := range call.Args {
+	for i, a := range call.Args {
 		switch arg := a.(type) {
 		case *ast.Ident:
 			if arg.Name == TemplateNameScopeIdentifierExecute {
 				return nil, fmt.Errorf("method %s using the execute callback must be defined on the receiver type", call.Fun.(*ast.Ident).Name)
 			}
+			if arg.Name == TemplateNameScopeIdentifierReceive && def.Representation == RepresentationWebSocket {
+				params = append(params, types.NewVar(0, receiver.Obj().Pkg(), arg.Name, receiveCallbackSignature()))
+				continue
+			}
 			if IsSSEArgument(arg.Name) {
 				hasSSE = true
 				params = append(params, types.NewVar(0, receiver.Obj().Pkg(), arg.Name, sseCallbackSignature()))
 				continue
 			}
 			tp, ok := DefaultScopeType(pl, def, arg.Name)
+			if !ok && isProviderArgument(def, arg.Name) {
+				if tp, ok = providerResultType(receiver, arg.Name); !ok {
+					return nil, fmt.Errorf("unknown argument %s at index %d: it is not in scope and the receiver has no %s method", arg.Name, i, ProviderMethodName(arg.Name))
+				}
+			}
 			if !ok {
 				return nil, fmt.Errorf("could not determine a type for %s", arg.Name)
 			}
@@ -373,7 +467,7 @@ func synthesizeCallSignature(def *Definition, call *ast.CallExpr, templatesPacka
 		}
 	}
 	results := types.NewTuple(types.NewVar(0, nil, "", types.Universe.Lookup("any").Type()))
-	if hasSSE {
+	if hasSSE || def.Representation == RepresentationWebSocket {
 		results = types.NewTuple()
 	}
 	return types.NewSignatureType(types.NewVar(0, nil, "", receiver.Obj().Type()), nil, nil, types.NewTuple(params...), results, false), nil
@@ -451,6 +545,17 @@ func sseCallbackSignature() *types.Signature {
 		false)
 }
 
+// receiveCallbackSignature is the func() (string, error) type synthesized for
+// a ws receive argument when the receiver method is not already defined.
+func receiveCallbackSignature() *types.Signature {
+	stringType := types.Universe.Lookup("string").Type()
+	errType := types.Universe.Lookup("error").Type()
+	return types.NewSignatureType(nil, nil, nil,
+		types.NewTuple(),
+		types.NewTuple(types.NewVar(0, nil, "", stringType), types.NewVar(0, nil, "", errType)),
+		false)
+}
+
 // typeQualifier renders types the way they read in the receiver's package:
 // types from that package are unqualified and all others use the package name
 // (*http.Request, not *net/http.Request).
@@ -500,16 +605,19 @@ func newArgumentFromIdentifier(def *Definition, pl []*packages.Package, arg *ast
 		}
 	case TemplateNameScopeIdentifierLastEventID:
 		a.Type = ArgumentTypeLastEventID
-		if err := checkParsedArgument(pl, param, qual); err != nil {
+		if err := checkParsedArgument(def, pl, param, qual); err != nil {
 			return a, err
 		}
 	case TemplateNameScopeIdentifierExecute:
 		a.Type = ArgumentTypeExecute
 		a.template = def.template
+	case TemplateNameScopeIdentifierReceive:
+		// The receive callback contract is validated in resolveCallbackShapes.
+		a.Type = ArgumentTypeReceive
 	default:
 		if slices.Contains(def.pathValueNames, arg.Name) {
 			a.Type = ArgumentTypeRequestPathValue
-			if err := checkParsedArgument(pl, param, qual); err != nil {
+			if err := checkParsedArgument(def, pl, param, qual); err != nil {
 				return a, err
 			}
 			return a, nil
@@ -538,6 +646,122 @@ func newArgumentFromIdentifier(def *Definition, pl []*packages.Package, arg *ast
 	return a, nil
 }
 
+// ProviderMethodName returns the receiver method that provides an argument:
+// "user" is provided by ProvideUser.
+func ProviderMethodName(argument string) string {
+	r, size := utf8.DecodeRuneInString(argument)
+	return "Provide" + string(unicode.ToUpper(r)) + argument[size:]
+}
+
+// isProviderArgument reports whether an identifier is outside the template
+// name scope (and so must be resolved by a provider method).
+func isProviderArgument(def *Definition, name string) bool {
+	if slices.Contains(patternScope(), name) || slices.Contains(def.pathValueNames, name) || IsSSEArgument(name) {
+		return false
+	}
+	switch def.Representation {
+	case RepresentationSSE:
+		return !IsSSEMessageArgument(name)
+	case RepresentationWebSocket:
+		return name != TemplateNameScopeIdentifierReceive
+	}
+	return true
+}
+
+// AuthorizeMethodName is the receiver method the generated handlers call to
+// guard every route.
+const AuthorizeMethodName = "Authorize"
+
+// AuthorizeMethod looks up the receiver's Authorize method and checks it has
+// the signature func(*http.Request, string) error. It returns nil when the
+// receiver has no such method.
+func AuthorizeMethod(receiver *types.Named) (*types.Func, error) {
+	if receiver == nil {
+		return nil, nil
+	}
+	obj, _, _ := types.LookupFieldOrMethod(receiver, true, receiver.Obj().Pkg(), AuthorizeMethodName)
+	fn, ok := obj.(*types.Func)
+	if !ok {
+		return nil, nil
+	}
+	sig := fn.Type().(*types.Signature)
+	validParams := sig.Params().Len() == 2 &&
+		isNamedPointer(sig.Params().At(0).Type(), "net/http", "Request") &&
+		types.Identical(sig.Params().At(1).Type(), types.Typ[types.String])
+	validResults := sig.Results().Len() == 1 && types.Identical(sig.Results().At(0).Type(), types.Universe.Lookup("error").Type())
+	if !validParams || !validResults || sig.Variadic() {
+		return nil, fmt.Errorf("receiver method %s must be a func(*http.Request, string) error", AuthorizeMethodName)
+	}
+	return fn, nil
+}
+
+// providerMethod looks up the provider method for an argument and checks it
+// has the signature func(*http.Request) T or func(*http.Request) (T, error).
+func providerMethod(receiver *types.Named, argument string) (*types.Func, error) {
+	name := ProviderMethodName(argument)
+	obj, _, _ := types.LookupFieldOrMethod(receiver, true, receiver.Obj().Pkg(), name)
+	fn, ok := obj.(*types.Func)
+	if !ok {
+		return nil, nil
+	}
+	sig := fn.Type().(*types.Signature)
+	validParam := sig.Params().Len() == 1 && isNamedPointer(sig.Params().At(0).Type(), "net/http", "Request")
+	validResults := sig.Results().Len() == 1 ||
+		(sig.Results().Len() == 2 && types.Identical(sig.Results().At(1).Type(), types.Universe.Lookup("error").Type()))
+	if !validParam || !validResults || sig.Variadic() {
+		return nil, fmt.Errorf("provider %s for argument %s must be a func(*http.Request) T or func(*http.Request) (T, error)", name, argument)
+	}
+	return fn, nil
+}
+
+func isNamedPointer(tp types.Type, pkgPath, name string) bool {
+	ptr, ok := tp.(*types.Pointer)
+	return ok && IsNamedType(ptr.Elem(), pkgPath, name)
+}
+
+// providerResultType is the type a provider method provides.
+func providerResultType(receiver *types.Named, argument string) (types.Type, bool) {
+	fn, err := providerMethod(receiver, argument)
+	if fn == nil || err != nil {
+		return nil, false
+	}
+	return fn.Type().(*types.Signature).Results().At(0).Type(), true
+}
+
+// newProviderArgument resolves an identifier outside the template name scope
+// to its provider method. The provided type must be assignable to the
+// parameter the argument is passed to.
+func newProviderArgument(pl []*packages.Package, receiver *types.Named, arg *ast.Ident, index int, paramType types.Type, qual types.Qualifier) (Argument, error) {
+	fn, err := providerMethod(receiver, arg.Name)
+	if err != nil {
+		return Argument{}, err
+	}
+	if fn == nil {
+		return Argument{}, fmt.Errorf("unknown argument %s at index %d: it is not in scope and the receiver has no %s method", arg.Name, index, ProviderMethodName(arg.Name))
+	}
+	sig := fn.Type().(*types.Signature)
+	if provided := sig.Results().At(0).Type(); !types.AssignableTo(provided, paramType) {
+		return Argument{}, fmt.Errorf("method expects type %s but %s is %s", types.TypeString(paramType, qual), arg.Name, types.TypeString(provided, qual))
+	}
+	requestType, err := stdlibType(pl, "net/http", "Request", true)
+	if err != nil {
+		return Argument{}, err
+	}
+	return Argument{
+		Identifier: arg.Name,
+		Type:       ArgumentTypeProvider,
+		ParamType:  paramType,
+		sig:        sig,
+		isMethod:   true,
+		provider:   fn.Name(),
+		args: []Argument{{
+			Identifier: TemplateNameScopeIdentifierHTTPRequest,
+			Type:       ArgumentTypeRequest,
+			ParamType:  requestType,
+		}},
+	}, nil
+}
+
 func stdlibType(pl []*packages.Package, pkgPath, name string, pointer bool) (types.Type, error) {
 	pkg, ok := findPackageTypes(pl, pkgPath)
 	if !ok {
@@ -595,6 +819,9 @@ const (
 	TemplateNameScopeIdentifierHTTPResponse = "response"
 	TemplateNameScopeIdentifierExecute      = "execute"
 	TemplateNameScopeIdentifierLastEventID  = "lastEventID"
+
+	// TemplateNameScopeIdentifierReceive is only in scope on ws routes.
+	TemplateNameScopeIdentifierReceive = "receive"
 )
 
 func patternScope() []string {
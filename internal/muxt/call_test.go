@@ -4,6 +4,7 @@ import (
 	"go/token"
 	"go/types"
 	"html/template"
+	"slices"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -47,11 +48,14 @@ func TestArgument(t *testing.T) {
 	// mime/multipart.Form
 
 	serverType := examplePkg.Scope().Lookup("Server").Type().(*types.Named)
+	urlParsers, err := NewParsers(packageList, []string{"net/url.Parse", "ParseUserinfo"}, examplePkg.Path())
+	require.NoError(t, err)
 	emptyStruct := examplePkg.Scope().Lookup("Empty").Type().(*types.Named)
 
 	for _, tc := range []struct {
 		Name     string
 		Receiver *types.Named
+		Parsers  Parsers
 		Template string
 		Expect   func(t *testing.T, defs []Definition, err error)
 	}{
@@ -244,6 +248,24 @@ func TestArgument(t *testing.T) {
 			require.NoError(t, err)
 			require.Equal(t, ResultShapeDataOK, defs[0].ResultShape())
 		}},
+		{Name: "sequence result", Receiver: serverType, Template: `{{define "GET / Seq()"}}{{end}}`, Expect: func(t *testing.T, defs []Definition, err error) {
+			require.NoError(t, err)
+			require.Equal(t, ResultShapeData, defs[0].ResultShape())
+			require.Equal(t, ResultSequenceSeq, defs[0].ResultSequence())
+		}},
+		{Name: "sequence with error result", Receiver: serverType, Template: `{{define "GET / SeqError()"}}{{end}}`, Expect: func(t *testing.T, defs []Definition, err error) {
+			require.NoError(t, err)
+			require.Equal(t, ResultShapeDataError, defs[0].ResultShape())
+			require.Equal(t, ResultSequenceSeqError, defs[0].ResultSequence())
+		}},
+		{Name: "sequence of pairs result", Receiver: serverType, Template: `{{define "GET / SeqPairs()"}}{{end}}`, Expect: func(t *testing.T, defs []Definition, err error) {
+			require.NoError(t, err)
+			require.Equal(t, ResultSequenceSeq, defs[0].ResultSequence())
+		}},
+		{Name: "non-sequence result", Receiver: serverType, Template: `{{define "GET / StringError()"}}{{end}}`, Expect: func(t *testing.T, defs []Definition, err error) {
+			require.NoError(t, err)
+			require.Equal(t, ResultSequenceNone, defs[0].ResultSequence())
+		}},
 		{Name: "method with no results", Receiver: serverType, Template: `{{define "GET / NoResults()"}}{{end}}`, Expect: func(t *testing.T, defs []Definition, err error) {
 			require.ErrorContains(t, err, `method for pattern "GET / NoResults()" has no results it should have one or two`)
 		}},
@@ -310,14 +332,70 @@ func TestArgument(t *testing.T) {
 			require.NotNil(t, defs[0].Arguments[0].Template())
 			require.Equal(t, "fooMessage", defs[0].Arguments[0].Template().Name())
 		}},
+		{Name: "ws method with execute and receive", Receiver: serverType, Template: `{{define "GET /x ws(WSJoin(execute, receive))"}}{{end}}`, Expect: func(t *testing.T, defs []Definition, err error) {
+			require.NoError(t, err)
+			require.Equal(t, RepresentationWebSocket, defs[0].Representation)
+			require.Equal(t, ResultShapeError, defs[0].ResultShape())
+			require.Equal(t, ArgumentTypeReceive, defs[0].Arguments[1].Type)
+			named, ok := defs[0].Arguments[1].CallbackResultType().(*types.Named)
+			require.True(t, ok)
+			require.Equal(t, "TD", named.Obj().Name())
+		}},
+		{Name: "ws method must return nothing or an error", Receiver: serverType, Template: `{{define "GET /x ws(WSReturnsValue(receive))"}}{{end}}`, Expect: func(t *testing.T, defs []Definition, err error) {
+			require.ErrorContains(t, err, "method WSReturnsValue using the ws callback must return nothing or an error")
+		}},
+		{Name: "ws receive parameter is not a function", Receiver: serverType, Template: `{{define "GET /x ws(WSReceiveNotFunc(receive))"}}{{end}}`, Expect: func(t *testing.T, defs []Definition, err error) {
+			require.ErrorContains(t, err, "receive argument for WSReceiveNotFunc must be a func() (T, error)")
+		}},
 		{Name: "method with three results", Receiver: serverType, Template: `{{define "GET / ThreeResults()"}}{{end}}`, Expect: func(t *testing.T, defs []Definition, err error) {
 			require.ErrorContains(t, err, "method ThreeResults has 3 results it should have one or two")
 		}},
 		{Name: "nested call with three results", Receiver: serverType, Template: `{{define "GET / Any(ThreeResults())"}}{{end}}`, Expect: func(t *testing.T, defs []Definition, err error) {
 			require.ErrorContains(t, err, "method ThreeResults has 3 results it should have one or two")
 		}},
-		{Name: "path value with unsupported basic type", Receiver: serverType, Template: `{{define "GET /{id} Float64(id)"}}{{end}}`, Expect: func(t *testing.T, defs []Definition, err error) {
-			require.ErrorContains(t, err, "method param type float64 not supported")
+		{Name: "path value with unsupported basic type", Receiver: serverType, Template: `{{define "GET /{id} Complex128(id)"}}{{end}}`, Expect: func(t *testing.T, defs []Definition, err error) {
+			require.ErrorContains(t, err, "method param type complex128 not supported")
+		}},
+		{Name: "path value with float type", Receiver: serverType, Template: `{{define "GET /{id} Float64(id)"}}{{end}}`, Expect: func(t *testing.T, defs []Definition, err error) {
+			require.NoError(t, err)
+			require.Equal(t, ArgumentTypeRequestPathValue, defs[0].Arguments[0].Type)
+		}},
+		{Name: "path value with optional type", Receiver: serverType, Template: `{{define "GET /{id} OptionalInt(id)"}}{{end}}`, Expect: func(t *testing.T, defs []Definition, err error) {
+			require.NoError(t, err)
+		}},
+		{Name: "path value with pointer to unsupported type", Receiver: serverType, Template: `{{define "GET /{id} OptionalURL(id)"}}{{end}}`, Expect: func(t *testing.T, defs []Definition, err error) {
+			require.ErrorContains(t, err, "unsupported type: *url.URL")
+		}},
+		{Name: "path value with registered parser", Receiver: serverType, Parsers: urlParsers, Template: `{{define "GET /{id} OptionalURL(id)"}}{{end}}`, Expect: func(t *testing.T, defs []Definition, err error) {
+			require.NoError(t, err)
+			require.Equal(t, UnmarshalParser, defs[0].UnmarshalMethod(packageList, defs[0].Signature().Params().At(0).Type()))
+		}},
+		{Name: "form struct field with registered parser", Receiver: serverType, Parsers: urlParsers, Template: `{{define "GET / FormUnsupportedField(form)"}}{{end}}`, Expect: func(t *testing.T, defs []Definition, err error) {
+			require.NoError(t, err)
+			fields := defs[0].Arguments[0].FormFields()
+			i := slices.IndexFunc(fields[0].Fields, func(fb FieldBinding) bool { return fb.Field.Name() == "User" })
+			require.Equal(t, UnmarshalParser, fields[0].Fields[i].Method)
+		}},
+		{Name: "provider argument", Receiver: serverType, Template: `{{define "GET /account Account(ctx, user)"}}{{end}}`, Expect: func(t *testing.T, defs []Definition, err error) {
+			require.NoError(t, err)
+			arg := defs[0].Arguments[1]
+			require.Equal(t, ArgumentTypeProvider, arg.Type)
+			require.Equal(t, "ProvideUser", arg.Provider())
+			require.True(t, arg.IsMethod())
+			require.Equal(t, ArgumentTypeRequest, arg.Arguments()[0].Type)
+		}},
+		{Name: "provider argument with a synthesized method", Receiver: serverType, Template: `{{define "GET /tenant Missing(tenant)"}}{{end}}`, Expect: func(t *testing.T, defs []Definition, err error) {
+			require.NoError(t, err)
+			require.Equal(t, "string", defs[0].Signature().Params().At(0).Type().String())
+		}},
+		{Name: "provider argument without a provider method", Receiver: serverType, Template: `{{define "GET / Any(unknown)"}}{{end}}`, Expect: func(t *testing.T, defs []Definition, err error) {
+			require.EqualError(t, err, "unknown argument unknown at index 0: it is not in scope and the receiver has no ProvideUnknown method")
+		}},
+		{Name: "provider argument with the wrong signature", Receiver: serverType, Template: `{{define "GET / Any(session)"}}{{end}}`, Expect: func(t *testing.T, defs []Definition, err error) {
+			require.EqualError(t, err, "provider ProvideSession for argument session must be a func(*http.Request) T or func(*http.Request) (T, error)")
+		}},
+		{Name: "provider argument with the wrong type", Receiver: serverType, Template: `{{define "GET / Tenant(tenant)"}}{{end}}`, Expect: func(t *testing.T, defs []Definition, err error) {
+			require.EqualError(t, err, "method expects type int but tenant is string")
 		}},
 		{Name: "path value with unsupported named type", Receiver: serverType, Template: `{{define "GET /{id} URLParam(id)"}}{{end}}`, Expect: func(t *testing.T, defs []Definition, err error) {
 			require.ErrorContains(t, err, "unsupported type: url.URL")
@@ -326,11 +404,14 @@ func TestArgument(t *testing.T) {
 			require.NoError(t, err)
 			require.Equal(t, ArgumentTypeRequestPathValue, defs[0].Arguments[0].Type)
 		}},
-		{Name: "last event id with unsupported basic type", Receiver: serverType, Template: `{{define "GET / Float64(lastEventID)"}}{{end}}`, Expect: func(t *testing.T, defs []Definition, err error) {
-			require.ErrorContains(t, err, "method param type float64 not supported")
+		{Name: "last event id with unsupported basic type", Receiver: serverType, Template: `{{define "GET / Complex128(lastEventID)"}}{{end}}`, Expect: func(t *testing.T, defs []Definition, err error) {
+			require.ErrorContains(t, err, "method param type complex128 not supported")
+		}},
+		{Name: "last event id with duration type", Receiver: serverType, Template: `{{define "GET / Duration(lastEventID)"}}{{end}}`, Expect: func(t *testing.T, defs []Definition, err error) {
+			require.NoError(t, err)
 		}},
 		{Name: "form struct with unsupported field type", Receiver: serverType, Template: `{{define "GET / FormUnsupportedField(form)"}}{{end}}`, Expect: func(t *testing.T, defs []Definition, err error) {
-			require.ErrorContains(t, err, "failed to generate parse statements for form field href: unsupported type: url.URL")
+			require.ErrorContains(t, err, "failed to generate parse statements for form field href.User: unsupported type: *url.Userinfo")
 		}},
 		{Name: "multipart struct with file header fields", Receiver: serverType, Template: `{{define "POST / Upload(multipart)"}}{{end}}`, Expect: func(t *testing.T, defs []Definition, err error) {
 			require.NoError(t, err)
@@ -371,6 +452,42 @@ func TestArgument(t *testing.T) {
 			require.True(t, ok)
 			require.Equal(t, types.String, basic.Kind())
 		}},
+		{Name: "form struct nested field bindings", Receiver: serverType, Template: `{{define "POST / Order(form)"}}{{end}}{{define "qty-template"}}{{range $i, $item := .}}<input type="number" name="items[{{$i}}].qty" min="1">{{end}}{{end}}`, Expect: func(t *testing.T, defs []Definition, err error) {
+			require.NoError(t, err)
+			fields := defs[0].Arguments[0].FormFields()
+			require.Len(t, fields, 4)
+
+			meta := fields[0]
+			require.True(t, meta.Embedded)
+			require.Len(t, meta.Fields, 1)
+			require.Equal(t, "note", meta.Fields[0].InputName)
+
+			address := fields[1]
+			require.False(t, address.Slice)
+			require.Len(t, address.Fields, 2)
+			require.Equal(t, "address.street", address.Fields[0].InputName)
+			require.Equal(t, "address.Zip", address.Fields[1].InputName)
+			require.Equal(t, UnmarshalInt, address.Fields[1].Method)
+
+			items := fields[2]
+			require.True(t, items.Slice)
+			require.Equal(t, "items", items.InputName)
+			require.Len(t, items.Fields, 2)
+			qty := items.Fields[1]
+			require.Equal(t, "qty", qty.InputName)
+			require.Len(t, qty.Validations, 1)
+			minimum, ok := qty.Validations[0].(MinValidation)
+			require.True(t, ok)
+			require.Equal(t, "qty", minimum.Name)
+
+			counts := fields[3]
+			require.True(t, counts.Map)
+			require.Equal(t, "counts", counts.InputName)
+			require.Equal(t, UnmarshalInt, counts.Method)
+		}},
+		{Name: "form struct slice of structs in a slice of structs", Receiver: serverType, Template: `{{define "POST / NestedItems(form)"}}{{end}}`, Expect: func(t *testing.T, defs []Definition, err error) {
+			require.ErrorContains(t, err, "failed to generate parse statements for form field Groups[].Items: a slice of structs is not supported in a slice of structs")
+		}},
 		{Name: "form field validation attribute is invalid", Receiver: serverType, Template: `{{define "GET / TaggedForm(form)"}}{{end}}{{define "count-template"}}<input name="count-input" minlength="abc">{{end}}`, Expect: func(t *testing.T, defs []Definition, err error) {
 			require.ErrorContains(t, err, "minlength must be an integer")
 		}},
@@ -402,7 +519,7 @@ func TestArgument(t *testing.T) {
 			}
 
 			for i := range defs {
-				err = ResolveCall(&defs[i], examplePkg, tc.Receiver, packageList)
+				err = ResolveCall(&defs[i], examplePkg, tc.Receiver, packageList, tc.Parsers)
 				if err != nil {
 					break
 				}
@@ -439,3 +556,61 @@ func findImport(example *types.Package, pkg string) *types.Package {
 	}
 	return nil
 }
+
+func TestNewParsers(t *testing.T) {
+	packageList, err := packages.Load(&packages.Config{
+		Mode: packages.NeedModule | packages.NeedTypesInfo | packages.NeedName | packages.NeedFiles | packages.NeedTypes | packages.NeedSyntax | packages.NeedEmbedPatterns | packages.NeedEmbedFiles | packages.NeedImports,
+		Dir:  "testdata/example",
+	}, ".")
+	require.NoError(t, err)
+	examplePkgPath := packageList[0].PkgPath
+
+	for _, tc := range []struct {
+		Name       string
+		References []string
+		Error      string
+	}{
+		{Name: "function in another package", References: []string{"net/url.Parse"}},
+		{Name: "function in the default package", References: []string{"ParseUserinfo"}},
+		{Name: "package not loaded", References: []string{"example.com/missing.Parse"}, Error: "parser example.com/missing.Parse: package example.com/missing is not imported by the routes package"},
+		{Name: "function not found", References: []string{"net/url.Missing"}, Error: "parser net/url.Missing: could not find function Missing in net/url"},
+		{Name: "not a function", References: []string{"net/url.Values"}, Error: "parser net/url.Values: could not find function Values in net/url"},
+		{Name: "wrong signature", References: []string{"net/url.PathEscape"}, Error: "parser net/url.PathEscape: expected signature func(string) (T, error) got func(s string) string"},
+		{Name: "duplicate result type", References: []string{"net/url.Parse", "net/url.ParseRequestURI"}, Error: "parser net/url.ParseRequestURI: net/url.Parse already parses *net/url.URL"},
+	} {
+		t.Run(tc.Name, func(t *testing.T) {
+			parsers, err := NewParsers(packageList, tc.References, examplePkgPath)
+			if tc.Error != "" {
+				require.EqualError(t, err, tc.Error)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, parsers, len(tc.References))
+		})
+	}
+}
+
+func TestAuthorizeMethod(t *testing.T) {
+	packageList, err := packages.Load(&packages.Config{
+		Mode: packages.NeedModule | packages.NeedTypesInfo | packages.NeedName | packages.NeedFiles | packages.NeedTypes | packages.NeedSyntax | packages.NeedEmbedPatterns | packages.NeedEmbedFiles | packages.NeedImports,
+		Dir:  "testdata/example",
+	}, ".")
+	require.NoError(t, err)
+	scope := packageList[0].Types.Scope()
+
+	t.Run("receiver without Authorize", func(t *testing.T) {
+		fn, err := AuthorizeMethod(scope.Lookup("Server").Type().(*types.Named))
+		require.NoError(t, err)
+		require.Nil(t, fn)
+	})
+	t.Run("receiver with Authorize", func(t *testing.T) {
+		fn, err := AuthorizeMethod(scope.Lookup("Guarded").Type().(*types.Named))
+		require.NoError(t, err)
+		require.NotNil(t, fn)
+		require.Equal(t, AuthorizeMethodName, fn.Name())
+	})
+	t.Run("Authorize with the wrong signature", func(t *testing.T) {
+		_, err := AuthorizeMethod(scope.Lookup("BadGuard").Type().(*types.Named))
+		require.EqualError(t, err, "receiver method Authorize must be a func(*http.Request, string) error")
+	})
+}
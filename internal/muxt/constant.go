@@ -0,0 +1,220 @@
+package muxt
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"html"
+	"html/template"
+	"strconv"
+	"text/template/parse"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// templateFuncConstants returns the constant template functions of the
+// package declaring def's templates variable, computed on first use.
+func (def *Definition) templateFuncConstants(pl []*packages.Package) map[string]string {
+	if def.funcConstants == nil && def.templatesPackage != nil {
+		def.funcConstants = packageFuncConstants(pl, def.templatesPackage.Path())
+	}
+	return def.funcConstants
+}
+
+// packageFuncConstants returns the template functions registered in the
+// template.FuncMap literals of the package at pkgPath whose Go body is a
+// single return of a constant expression, keyed by function name. Entries set
+// by index (funcs["name"] = fn) count as registrations too:
+//
+//	const MinAge = 18
+//
+//	template.New("").Funcs(template.FuncMap{
+//		"minAge": func() int { return MinAge },
+//	})
+//
+// Named functions declared in the package are followed. A name registered by
+// more than one FuncMap is omitted when any registration is not constant or
+// the values differ, since the templates may be parsed with either.
+func packageFuncConstants(pl []*packages.Package, pkgPath string) map[string]string {
+	var pkg *packages.Package
+	for _, p := range pl {
+		if p.PkgPath == pkgPath {
+			pkg = p
+			break
+		}
+	}
+	if pkg == nil || pkg.TypesInfo == nil {
+		return nil
+	}
+	funcDecls := make(map[types.Object]*ast.FuncDecl)
+	for _, file := range pkg.Syntax {
+		for _, decl := range file.Decls {
+			if fd, ok := decl.(*ast.FuncDecl); ok && fd.Recv == nil {
+				funcDecls[pkg.TypesInfo.Defs[fd.Name]] = fd
+			}
+		}
+	}
+	constants := make(map[string]string)
+	ambiguous := make(map[string]bool)
+	register := func(key, value ast.Expr) {
+		lit, ok := key.(*ast.BasicLit)
+		if !ok || lit.Kind != token.STRING {
+			return
+		}
+		name, err := strconv.Unquote(lit.Value)
+		if err != nil || ambiguous[name] {
+			return
+		}
+		val, ok := funcConstant(pkg.TypesInfo, funcDecls, value)
+		if prev, found := constants[name]; !ok || found && prev != val {
+			delete(constants, name)
+			ambiguous[name] = true
+			return
+		}
+		constants[name] = val
+	}
+	for _, file := range pkg.Syntax {
+		for node := range ast.Preorder(file) {
+			switch node := node.(type) {
+			case *ast.CompositeLit:
+				if !isFuncMapType(pkg.TypesInfo.TypeOf(node)) {
+					continue
+				}
+				for _, elt := range node.Elts {
+					if kv, ok := elt.(*ast.KeyValueExpr); ok {
+						register(kv.Key, kv.Value)
+					}
+				}
+			case *ast.AssignStmt:
+				// funcs["name"] = fn
+				if len(node.Lhs) != len(node.Rhs) {
+					continue
+				}
+				for i, lhs := range node.Lhs {
+					if index, ok := lhs.(*ast.IndexExpr); ok && isFuncMapType(pkg.TypesInfo.TypeOf(index.X)) {
+						register(index.Index, node.Rhs[i])
+					}
+				}
+			}
+		}
+	}
+	return constants
+}
+
+func isFuncMapType(tp types.Type) bool {
+	named, ok := types.Unalias(tp).(*types.Named)
+	if !ok || named.Obj().Pkg() == nil || named.Obj().Name() != "FuncMap" {
+		return false
+	}
+	switch named.Obj().Pkg().Path() {
+	case "html/template", "text/template":
+		return true
+	}
+	return false
+}
+
+// funcConstant returns the constant a FuncMap value returns when it is a
+// function literal, or a package-level function, with no parameters whose
+// body is a single return statement of a constant expression.
+func funcConstant(info *types.Info, funcDecls map[types.Object]*ast.FuncDecl, expr ast.Expr) (string, bool) {
+	var (
+		fnType *ast.FuncType
+		body   *ast.BlockStmt
+	)
+	switch fn := expr.(type) {
+	case *ast.FuncLit:
+		fnType, body = fn.Type, fn.Body
+	case *ast.Ident:
+		decl, ok := funcDecls[info.Uses[fn]]
+		if !ok {
+			return "", false
+		}
+		fnType, body = decl.Type, decl.Body
+	default:
+		return "", false
+	}
+	if body == nil || fnType.Params.NumFields() != 0 || len(body.List) != 1 {
+		return "", false
+	}
+	ret, ok := body.List[0].(*ast.ReturnStmt)
+	if !ok || len(ret.Results) != 1 {
+		return "", false
+	}
+	tv, ok := info.Types[ret.Results[0]]
+	if !ok || tv.Value == nil {
+		return "", false
+	}
+	return constantString(tv.Value)
+}
+
+// constantString formats val the way text/template prints it.
+func constantString(val constant.Value) (string, bool) {
+	switch val.Kind() {
+	case constant.String:
+		return constant.StringVal(val), true
+	case constant.Int:
+		return val.ExactString(), true
+	case constant.Float:
+		f, _ := constant.Float64Val(val)
+		return strconv.FormatFloat(f, 'g', -1, 64), true
+	case constant.Bool:
+		return strconv.FormatBool(constant.BoolVal(val)), true
+	default:
+		return "", false
+	}
+}
+
+// resolveConstantActions renders t's source with every action that calls a
+// constant template function without arguments (like {{minAge}}) replaced by
+// the HTML-escaped constant, so its element attributes can be read at
+// generate time. Other actions are left as is.
+func resolveConstantActions(t *template.Template, constants map[string]string) string {
+	root := t.Tree.Root
+	if len(constants) > 0 {
+		root = root.CopyList()
+		replaceConstantActions(root, constants)
+	}
+	return root.String()
+}
+
+func replaceConstantActions(list *parse.ListNode, constants map[string]string) {
+	if list == nil {
+		return
+	}
+	for i, node := range list.Nodes {
+		switch n := node.(type) {
+		case *parse.ActionNode:
+			if val, ok := constantAction(n, constants); ok {
+				list.Nodes[i] = &parse.TextNode{NodeType: parse.NodeText, Pos: n.Pos, Text: []byte(html.EscapeString(val))}
+			}
+		case *parse.IfNode:
+			replaceConstantActions(n.List, constants)
+			replaceConstantActions(n.ElseList, constants)
+		case *parse.RangeNode:
+			replaceConstantActions(n.List, constants)
+			replaceConstantActions(n.ElseList, constants)
+		case *parse.WithNode:
+			replaceConstantActions(n.List, constants)
+			replaceConstantActions(n.ElseList, constants)
+		case *parse.ListNode:
+			replaceConstantActions(n, constants)
+		}
+	}
+}
+
+func constantAction(n *parse.ActionNode, constants map[string]string) (string, bool) {
+	if n.Pipe == nil || len(n.Pipe.Decl) != 0 || len(n.Pipe.Cmds) != 1 {
+		return "", false
+	}
+	cmd := n.Pipe.Cmds[0]
+	if len(cmd.Args) != 1 {
+		return "", false
+	}
+	ident, ok := cmd.Args[0].(*parse.IdentifierNode)
+	if !ok {
+		return "", false
+	}
+	val, ok := constants[ident.Ident]
+	return val, ok
+}
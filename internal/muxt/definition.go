@@ -8,14 +8,18 @@ import (
 	"go/token"
 	"go/types"
 	"html/template"
+	"math"
 	"net/http"
 	"regexp"
 	"slices"
 	"strconv"
 	"strings"
 	"text/template/parse"
+	"time"
 
+	"github.com/dustin/go-humanize"
 	"github.com/typelate/muxt/internal/astgen"
+	"golang.org/x/tools/go/packages"
 )
 
 func Definitions(ts *template.Template, templatesVariable string) ([]Definition, error) {
@@ -44,6 +48,7 @@ func Definitions(ts *template.Template, templatesVariable string) ([]Definition,
 
 	// Analyze templates to determine which ones can call Redirect
 	analyzeRedirectCalls(ts, defs)
+	analyzeStaticTemplates(ts, defs)
 
 	return defs, nil
 }
@@ -78,12 +83,18 @@ type Definition struct {
 	// defaultStatusCode is the status code to use in the response header for this template endpoint
 	defaultStatusCode int
 
+	// timeout and maxBodyBytes are the route options timeout=<duration> and
+	// maxbody=<bytes>; zero means the option was not set.
+	timeout      time.Duration
+	maxBodyBytes int64
+
 	fun  *ast.Ident
 	call *ast.CallExpr
 	sig  *types.Signature
 
-	isMethod    bool
-	resultShape ResultShape
+	isMethod       bool
+	resultShape    ResultShape
+	resultSequence ResultSequence
 
 	fileSet *token.FileSet
 
@@ -104,10 +115,32 @@ type Definition struct {
 	// This is determined by static analysis of the template's action nodes.
 	canRedirect bool
 
+	// static indicates a call-less template (and every template it calls)
+	// that does not read request dependent TemplateData, so it renders the
+	// same page for every request. See analyzeStaticTemplates.
+	static bool
+
+	// readsRequestData indicates this template (or a template it calls)
+	// reads request dependent TemplateData. See analyzeStaticTemplates.
+	readsRequestData bool
+
 	// templatesVariable is the name of the package-level *template.Template
 	// variable that contains this template (e.g., "templates", "adminTemplates")
 	templatesVariable string
 
+	// templatesPackage is the package declaring the templates variable, set
+	// by ResolveCall. funcConstants caches its constant template functions
+	// (see templateFuncConstants) once a field template needs them.
+	templatesPackage *types.Package
+	funcConstants    map[string]string
+
+	// parsers are the registered parser functions, set by ResolveCall.
+	parsers Parsers
+
+	// authorize is the receiver's Authorize method, set by ResolveCall when
+	// the receiver has one.
+	authorize *types.Func
+
 	Representation Representation
 
 	Arguments []Argument
@@ -118,7 +151,8 @@ type Representation string
 const (
 	// RepresentationTextHTML Representation = ""
 
-	RepresentationSSE Representation = "sse"
+	RepresentationSSE       Representation = "sse"
+	RepresentationWebSocket Representation = "ws"
 )
 
 func (def Definition) SourceFile() string { return def.sourceFile }
@@ -149,7 +183,11 @@ func (def Definition) Host() string { return strings.ToLower(strings.TrimSpace(d
 func (def Definition) HTTPMethod() string { return strings.ToUpper(strings.TrimSpace(def.method)) }
 
 func (def Definition) DefaultStatusCode() int         { return def.defaultStatusCode }
+func (def Definition) Timeout() time.Duration         { return def.timeout }
+func (def Definition) MaxBodyBytes() int64            { return def.maxBodyBytes }
 func (def Definition) MayRedirect() bool              { return def.canRedirect }
+func (def Definition) Static() bool                   { return def.static }
+func (def Definition) ReadsRequestData() bool         { return def.readsRequestData }
 func (def Definition) Template() *template.Template   { return def.template }
 func (def Definition) FunctionIdentifier() *ast.Ident { return def.fun }
 func (def Definition) CallExpression() *ast.CallExpr  { return def.call }
@@ -159,6 +197,22 @@ func (def Definition) TemplatesVariable() string      { return def.templatesVari
 func (def Definition) Signature() *types.Signature    { return def.sig }
 func (def Definition) IsMethod() bool                 { return def.isMethod }
 func (def Definition) ResultShape() ResultShape       { return def.resultShape }
+func (def Definition) ResultSequence() ResultSequence { return def.resultSequence }
+
+// Authorize returns the receiver's Authorize method, or nil when the receiver
+// has none and the route is not guarded.
+func (def Definition) Authorize() *types.Func { return def.authorize }
+
+// Parser returns the registered parser function for tp.
+func (def Definition) Parser(tp types.Type) (*types.Func, bool) { return def.parsers.Lookup(tp) }
+
+// UnmarshalMethod is UnmarshalMethodFor that prefers a registered parser.
+func (def Definition) UnmarshalMethod(pl []*packages.Package, tp types.Type) UnmarshalMethod {
+	if _, ok := def.parsers.Lookup(tp); ok {
+		return UnmarshalParser
+	}
+	return UnmarshalMethodFor(pl, tp)
+}
 
 func (def Definition) SetArgumentType(name string, tp types.Type) { def.pathValueTypes[name] = tp }
 func (def Definition) ArgumentType(name string) (types.Type, bool) {
@@ -210,6 +264,10 @@ func newDefinition(t *template.Template) (Definition, error, bool) {
 		}
 	}
 
+	if err := parseRouteOptions(&def, matches[templateNameMux.SubexpIndex("OPTIONS")]); err != nil {
+		return Definition{}, err, true
+	}
+
 	if len(def.path) > 1 {
 		segments := strings.Split(def.path[1:], "/")
 		for _, segment := range segments {
@@ -251,9 +309,50 @@ func newDefinition(t *template.Template) (Definition, error, bool) {
 
 var (
 	pathSegmentPattern = regexp.MustCompile(`/\{([^}]*)}`)
-	templateNameMux    = regexp.MustCompile(`^(?P<pattern>((?P<METHOD>[A-Z]+)\s+)?(?P<HOST>([^/])*)(?P<PATH>(/(\S)*)))(\s+(?P<HTTP_STATUS>(\d|http\.Status)\S+))?(?P<CALL>.*)?$`)
+	templateNameMux    = regexp.MustCompile(`^(?P<pattern>((?P<METHOD>[A-Z]+)\s+)?(?P<HOST>([^/])*)(?P<PATH>(/(\S)*)))(\s+(?P<HTTP_STATUS>(\d|http\.Status)\S+))?(?P<OPTIONS>(\s+[a-z]+=\S*)*)(?P<CALL>.*)?$`)
+)
+
+const (
+	routeOptionTimeout = "timeout"
+	routeOptionMaxBody = "maxbody"
 )
 
+// parseRouteOptions parses the space separated key=value route options
+// between the status code and the call.
+func parseRouteOptions(def *Definition, options string) error {
+	seen := make(map[string]struct{})
+	for _, option := range strings.Fields(options) {
+		key, value, _ := strings.Cut(option, "=")
+		if _, ok := seen[key]; ok {
+			return fmt.Errorf("duplicate route option %q", key)
+		}
+		seen[key] = struct{}{}
+		switch key {
+		case routeOptionTimeout:
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				return fmt.Errorf("failed to parse %s option: %w", key, err)
+			}
+			if d <= 0 {
+				return fmt.Errorf("%s option must be positive got %s", key, value)
+			}
+			def.timeout = d
+		case routeOptionMaxBody:
+			n, err := humanize.ParseBytes(value)
+			if err != nil {
+				return fmt.Errorf("failed to parse %s option: %w", key, err)
+			}
+			if n == 0 || n > math.MaxInt64 {
+				return fmt.Errorf("%s option must be between 1 byte and %d bytes got %s", key, int64(math.MaxInt64), value)
+			}
+			def.maxBodyBytes = int64(n)
+		default:
+			return fmt.Errorf("unknown route option %q: expected %s or %s", key, routeOptionTimeout, routeOptionMaxBody)
+		}
+	}
+	return nil
+}
+
 func (def Definition) PathValueIdentifiers() []string {
 	var result []string
 	for _, match := range pathSegmentPattern.FindAllStringSubmatch(def.path, strings.Count(def.path, "/")) {
@@ -327,12 +426,12 @@ func parseHandler(fileSet *token.FileSet, def *Definition, pathParameterNames []
 	if !ok {
 		return fmt.Errorf("expected function identifier, got got: %s", astgen.Format(call.Fun))
 	}
-	if fun.Name == string(RepresentationSSE) && len(call.Args) == 1 {
+	if rep := Representation(fun.Name); (rep == RepresentationSSE || rep == RepresentationWebSocket) && len(call.Args) == 1 {
 		actualCall, ok := call.Args[0].(*ast.CallExpr)
 		if ok {
 			actualFun, ok := actualCall.Fun.(*ast.Ident)
 			if ok {
-				def.Representation = RepresentationSSE
+				def.Representation = rep
 				call = actualCall
 				fun = actualFun
 			}
@@ -344,7 +443,7 @@ func parseHandler(fileSet *token.FileSet, def *Definition, pathParameterNames []
 
 	scope := append(patternScope(), pathParameterNames...)
 	slices.Sort(scope)
-	if err := checkArguments(scope, call, def.Representation == RepresentationSSE); err != nil {
+	if err := checkArguments(scope, call, def.Representation); err != nil {
 		return err
 	}
 
@@ -357,6 +456,15 @@ func parseHandler(fileSet *token.FileSet, def *Definition, pathParameterNames []
 		return fmt.Errorf("sse handler cannot use a %q argument", TemplateNameScopeIdentifierHTTPResponse)
 	}
 
+	if def.Representation == RepresentationWebSocket {
+		if def.hasResponseWriterArg {
+			return fmt.Errorf("ws handler cannot use a %q argument", TemplateNameScopeIdentifierHTTPResponse)
+		}
+		if def.method != "" && def.method != http.MethodGet {
+			return fmt.Errorf("ws handler must use the GET method (or no method) got %s", def.method)
+		}
+	}
+
 	return nil
 }
 
@@ -409,15 +517,20 @@ func IsSSEArgument(name string) bool {
 	return ok && rest != "" && token.IsIdentifier(rest)
 }
 
-func checkArguments(identifiers []string, call *ast.CallExpr, sse bool) error {
+func checkArguments(identifiers []string, call *ast.CallExpr, representation Representation) error {
 	hasForm, hasMultipart := false, false
 	for i, a := range call.Args {
 		switch exp := a.(type) {
 		case *ast.Ident:
 			// sse-prefixed render callbacks and Message-suffixed send-message
-			// templates are only in scope on sse routes.
-			sseScoped := sse && (IsSSEArgument(exp.Name) || IsSSEMessageArgument(exp.Name))
-			if _, ok := slices.BinarySearch(identifiers, exp.Name); !ok && !sseScoped {
+			// templates are only in scope on sse routes; the receive callback
+			// is only in scope on ws routes.
+			sseScoped := representation == RepresentationSSE && (IsSSEArgument(exp.Name) || IsSSEMessageArgument(exp.Name))
+			wsScoped := representation == RepresentationWebSocket && exp.Name == TemplateNameScopeIdentifierReceive
+			// Any other identifier may be provided by a receiver method (see
+			// newProviderArgument); that is checked once the receiver is known.
+			outOfScope := IsSSEArgument(exp.Name) || exp.Name == TemplateNameScopeIdentifierReceive
+			if _, ok := slices.BinarySearch(identifiers, exp.Name); !ok && !sseScoped && !wsScoped && outOfScope {
 				return fmt.Errorf("unknown argument %s at index %d", exp.Name, i)
 			}
 			switch exp.Name {
@@ -427,7 +540,7 @@ func checkArguments(identifiers []string, call *ast.CallExpr, sse bool) error {
 				hasMultipart = true
 			}
 		case *ast.CallExpr:
-			if err := checkArguments(identifiers, exp, sse); err != nil {
+			if err := checkArguments(identifiers, exp, representation); err != nil {
 				return fmt.Errorf("call %s argument error: %w", astgen.Format(call.Fun), err)
 			}
 		default:
@@ -614,6 +727,12 @@ func containsRedirectCall(cmd *parse.CommandNode) bool {
 }
 
 func callsMethodOnTemplateData(cmd *parse.CommandNode) bool {
+	return callsTemplateDataMethodOutside(cmd, isSafeTemplateDataMethod)
+}
+
+// callsTemplateDataMethodOutside reports whether cmd calls a TemplateData
+// method that safe does not accept, or passes TemplateData to a function.
+func callsTemplateDataMethodOutside(cmd *parse.CommandNode, safe func(methodName string) bool) bool {
 	if cmd == nil || len(cmd.Args) == 0 {
 		return false
 	}
@@ -630,7 +749,7 @@ func callsMethodOnTemplateData(cmd *parse.CommandNode) bool {
 					return true
 				case *parse.FieldNode:
 					// Check if it's a safe method call
-					if !isAllSafeMethods(arg.Ident) {
+					if !isAllSafeMethods(arg.Ident, safe) {
 						return true
 					}
 				case *parse.ChainNode:
@@ -645,7 +764,7 @@ func callsMethodOnTemplateData(cmd *parse.CommandNode) bool {
 	for _, arg := range cmd.Args {
 		if field, ok := arg.(*parse.FieldNode); ok {
 			// Check if all methods in the chain are safe
-			if !isAllSafeMethods(field.Ident) {
+			if !isAllSafeMethods(field.Ident, safe) {
 				return true
 			}
 		}
@@ -655,12 +774,12 @@ func callsMethodOnTemplateData(cmd *parse.CommandNode) bool {
 }
 
 // isAllSafeMethods checks if all identifiers in a field chain are safe methods
-func isAllSafeMethods(idents []string) bool {
+func isAllSafeMethods(idents []string, safe func(methodName string) bool) bool {
 	if len(idents) == 0 {
 		return true
 	}
 	// First identifier must be a safe TemplateData method
-	if !isSafeTemplateDataMethod(idents[0]) {
+	if !safe(idents[0]) {
 		return false
 	}
 	// If there are more identifiers, we're chaining off the result
@@ -679,9 +798,113 @@ func isSafeTemplateDataMethod(methodName string) bool {
 		"Receiver":    true, // returns R (the receiver type)
 		"Ok":          true, // returns bool
 		"Err":         true, // returns error
+		"FieldErrors": true, // returns map[string]TemplateDataFieldError
+		"FieldError":  true, // returns *TemplateDataFieldError
+		"Form":        true, // returns url.Values
+		"FormValue":   true, // returns string
+		"FormData":    true, // returns F (the form type, on TemplateDataForm)
 		"MuxtVersion": true, // returns string
 		"StatusCode":  true, // sets statusCode field, returns *TemplateData but doesn't set redirectURL
 		"Header":      true, // sets response headers, returns *TemplateData but doesn't set redirectURL
 	}
 	return safeMethodsSet[methodName]
 }
+
+// analyzeStaticTemplates marks the definitions whose templates read request
+// dependent TemplateData, and the call-less definitions whose templates render
+// the same page for every request, so they can be pre-rendered when the routes
+// function runs. It updates the readsRequestData and static fields on each
+// Definition.
+func analyzeStaticTemplates(ts *template.Template, defs []Definition) {
+	for i := range defs {
+		t := ts.Lookup(defs[i].name)
+		if t == nil || t.Tree == nil {
+			continue
+		}
+		defs[i].readsRequestData = usesRequestData(t.Tree.Root, ts, make(map[string]bool))
+		defs[i].static = defs[i].fun == nil && !defs[i].readsRequestData
+	}
+}
+
+// usesRequestData recursively checks if a template tree (or a template it
+// calls) reads request dependent TemplateData (see isRequestIndependentMethod)
+// or passes TemplateData to a function. Like canTemplateRedirect it is
+// conservative: a method call on the dot inside range or with counts even
+// though the dot is no longer TemplateData.
+func usesRequestData(node parse.Node, ts *template.Template, visited map[string]bool) bool {
+	if node == nil {
+		return false
+	}
+
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return false
+		}
+		for _, child := range n.Nodes {
+			if usesRequestData(child, ts, visited) {
+				return true
+			}
+		}
+
+	case *parse.ActionNode:
+		return usesRequestData(n.Pipe, ts, visited)
+
+	case *parse.IfNode:
+		return usesRequestData(n.Pipe, ts, visited) || usesRequestData(n.List, ts, visited) || usesRequestData(n.ElseList, ts, visited)
+
+	case *parse.RangeNode:
+		return usesRequestData(n.Pipe, ts, visited) || usesRequestData(n.List, ts, visited) || usesRequestData(n.ElseList, ts, visited)
+
+	case *parse.WithNode:
+		return usesRequestData(n.Pipe, ts, visited) || usesRequestData(n.List, ts, visited) || usesRequestData(n.ElseList, ts, visited)
+
+	case *parse.TemplateNode:
+		if usesRequestData(n.Pipe, ts, visited) {
+			return true
+		}
+		// Prevent infinite recursion on circular template references
+		if visited[n.Name] {
+			return false
+		}
+		visited[n.Name] = true
+		defer delete(visited, n.Name)
+
+		calledTemplate := ts.Lookup(n.Name)
+		if calledTemplate != nil && calledTemplate.Tree != nil {
+			return usesRequestData(calledTemplate.Tree.Root, ts, visited)
+		}
+
+	case *parse.PipeNode:
+		if n == nil {
+			return false
+		}
+		for _, cmd := range n.Cmds {
+			if callsTemplateDataMethodOutside(cmd, isRequestIndependentMethod) {
+				return true
+			}
+			for _, arg := range cmd.Args {
+				// $.Request reads TemplateData through the root variable.
+				if v, ok := arg.(*parse.VariableNode); ok && len(v.Ident) > 1 && v.Ident[0] == "$" && !isAllSafeMethods(v.Ident[1:], isRequestIndependentMethod) {
+					return true
+				}
+				if p, ok := arg.(*parse.PipeNode); ok && usesRequestData(p, ts, visited) {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+// isRequestIndependentMethod returns true for TemplateData methods that
+// return the same value for every request to a call-less route. Receiver is
+// excluded since receiver methods may return something different each time.
+func isRequestIndependentMethod(methodName string) bool {
+	switch methodName {
+	case "Path", "Result", "Ok", "Err", "MuxtVersion":
+		return true
+	}
+	return false
+}
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"slices"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -334,6 +335,30 @@ func TestNewTemplateName(t *testing.T) {
 				assert.ErrorContains(t, err, "unknown argument sseClock at index 0")
 			},
 		},
+		{
+			Name:     "receive argument on a non-ws route",
+			In:       "GET / F(receive)",
+			ExpMatch: true,
+			Error: func(t *testing.T, err error) {
+				assert.ErrorContains(t, err, "unknown argument receive at index 0")
+			},
+		},
+		{
+			Name:     "ws handler cannot use response argument",
+			In:       "GET /x ws(Endpoint(response))",
+			ExpMatch: true,
+			Error: func(t *testing.T, err error) {
+				assert.ErrorContains(t, err, `ws handler cannot use a "response" argument`)
+			},
+		},
+		{
+			Name:     "ws handler must use GET",
+			In:       "POST /x ws(Endpoint(execute, receive))",
+			ExpMatch: true,
+			Error: func(t *testing.T, err error) {
+				assert.ErrorContains(t, err, "ws handler must use the GET method (or no method) got POST")
+			},
+		},
 		{
 			Name:     "sse handler cannot use response argument",
 			In:       "GET /x sse(Endpoint(response))",
@@ -412,6 +437,68 @@ func TestNewTemplateName(t *testing.T) {
 				assert.ErrorContains(t, err, "failed to parse status code: unknown http.StatusBANANA")
 			},
 		},
+		{
+			Name:     "with route options",
+			In:       "POST /upload 201 timeout=1500ms maxbody=1MiB F(form)",
+			ExpMatch: true,
+			TemplateName: func(t *testing.T, def Definition) {
+				assert.Equal(t, http.StatusCreated, def.defaultStatusCode)
+				assert.Equal(t, 1500*time.Millisecond, def.timeout)
+				assert.Equal(t, int64(1<<20), def.maxBodyBytes)
+				assert.Equal(t, "F(form)", def.handler)
+				assert.Equal(t, "POST /upload", def.pattern)
+			},
+		},
+		{
+			Name:     "with route options and no status code or handler",
+			In:       "GET /slow timeout=5s",
+			ExpMatch: true,
+			TemplateName: func(t *testing.T, def Definition) {
+				assert.Equal(t, 5*time.Second, def.timeout)
+				assert.Zero(t, def.maxBodyBytes)
+				assert.Equal(t, "", def.handler)
+			},
+		},
+		{
+			Name:     "with an unknown route option",
+			In:       "GET / retries=3 F()",
+			ExpMatch: true,
+			Error: func(t *testing.T, err error) {
+				assert.ErrorContains(t, err, `unknown route option "retries": expected timeout or maxbody`)
+			},
+		},
+		{
+			Name:     "with a malformed timeout",
+			In:       "GET / timeout=soon F()",
+			ExpMatch: true,
+			Error: func(t *testing.T, err error) {
+				assert.ErrorContains(t, err, "failed to parse timeout option")
+			},
+		},
+		{
+			Name:     "with a zero timeout",
+			In:       "GET / timeout=0s F()",
+			ExpMatch: true,
+			Error: func(t *testing.T, err error) {
+				assert.ErrorContains(t, err, "timeout option must be positive got 0s")
+			},
+		},
+		{
+			Name:     "with a malformed maxbody",
+			In:       "GET / maxbody=lots F()",
+			ExpMatch: true,
+			Error: func(t *testing.T, err error) {
+				assert.ErrorContains(t, err, "failed to parse maxbody option")
+			},
+		},
+		{
+			Name:     "with a duplicate route option",
+			In:       "GET / timeout=1s timeout=2s F()",
+			ExpMatch: true,
+			Error: func(t *testing.T, err error) {
+				assert.ErrorContains(t, err, `duplicate route option "timeout"`)
+			},
+		},
 		{
 			Name:     "with call expression parameter",
 			In:       "GET /{id} F(S(response, request), id)",
@@ -444,12 +531,11 @@ func TestNewTemplateName(t *testing.T) {
 			},
 		},
 		{
+			// identifiers outside the scope may be resolved by a provider method
+			// once the receiver is known (see TestArgument)
 			Name:     "when an identifier is not defined",
 			In:       "GET / F(unknown)",
 			ExpMatch: true,
-			Error: func(t *testing.T, err error) {
-				assert.ErrorContains(t, err, "unknown argument unknown")
-			},
 		},
 		{
 			Name:     "execute argument with sse representation",
@@ -16,6 +16,30 @@ func TestDefinitions(t *testing.T) {
 		_, err := muxt.Definitions(ts, "ts")
 		require.Error(t, err)
 	})
+	for _, tt := range []struct {
+		Name     string
+		Template string
+		Static   bool
+	}{
+		{Name: "call-less route with text", Template: `{{define "GET /about"}}<h1>About</h1>{{end}}`, Static: true},
+		{Name: "route path and version", Template: `{{define "GET /about"}}<a href="{{.Path.About}}">{{.MuxtVersion}}</a>{{end}}`, Static: true},
+		{Name: "called template", Template: `{{define "GET /about"}}{{template "nav" .}}{{end}}{{define "nav"}}{{.Path.About}}{{end}}`, Static: true},
+		{Name: "route with a call", Template: `{{define "GET /about About()"}}<h1>About</h1>{{end}}`},
+		{Name: "request", Template: `{{define "GET /about"}}{{.Request.URL.Path}}{{end}}`},
+		{Name: "request through the root variable", Template: `{{define "GET /about"}}{{with 1}}{{$.Request.Method}}{{end}}{{end}}`},
+		{Name: "receiver", Template: `{{define "GET /about"}}{{.Receiver}}{{end}}`},
+		{Name: "status code", Template: `{{define "GET /about"}}{{.StatusCode 404}}{{end}}`},
+		{Name: "called template reads the request", Template: `{{define "GET /about"}}{{template "nav" .}}{{end}}{{define "nav"}}{{.FormValue "q"}}{{end}}`},
+		{Name: "template data passed to a function", Template: `{{define "GET /about"}}{{printf "%v" .}}{{end}}`},
+	} {
+		t.Run("static "+tt.Name, func(t *testing.T) {
+			ts := template.Must(template.New("").Parse(tt.Template))
+			defs, err := muxt.Definitions(ts, "ts")
+			require.NoError(t, err)
+			require.Len(t, defs, 1)
+			assert.Equal(t, tt.Static, defs[0].Static())
+		})
+	}
 }
 
 func TestCheckPathMethodCollisions(t *testing.T) {
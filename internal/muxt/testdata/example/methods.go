@@ -3,9 +3,11 @@ package example
 import (
 	"context"
 	"encoding"
+	"iter"
 	"mime/multipart"
 	"net/http"
 	"net/url"
+	"time"
 )
 
 type Empty struct{}
@@ -35,6 +37,9 @@ func (srv *Server) NoResults()                                     {}
 func (srv *Server) TwoResultsSecondNotErrorOrBool() (int, float64) { return 0, 0 }
 func (srv *Server) StringOK() (string, bool)                       { return "", false }
 func (srv *Server) StringError() (string, error)                   { return "", nil }
+func (srv *Server) Seq() iter.Seq[string]                          { return nil }
+func (srv *Server) SeqError() (iter.Seq2[string, error], error)    { return nil, nil }
+func (srv *Server) SeqPairs() iter.Seq2[int, string]               { return nil }
 func (srv *Server) ExecuteReturnsValue(func() error) (int, error)  { return 0, nil }
 func (srv *Server) SSEReturnsValue(func(string) error) int         { return 0 }
 func (srv *Server) SSEEvents(func(string) error)                   {}
@@ -50,10 +55,18 @@ func (srv *Server) SSECallbackMultiArg(func(int, int) error)   {}
 
 func (srv *Server) SSETwoCallbacks(func(string) error, func(string) error) {}
 
+func (srv *Server) WSJoin(func(TD) error, func() (TD, error)) error { return nil }
+func (srv *Server) WSReturnsValue(func() (string, error)) int       { return 0 }
+func (srv *Server) WSReceiveNotFunc(string)                         {}
+
 func (srv *Server) ThreeResults() (int, int, error) { return 0, 0, nil }
 
-func (srv *Server) Float64(float64) any  { return nil }
-func (srv *Server) URLParam(url.URL) any { return nil }
+func (srv *Server) Float64(float64) any        { return nil }
+func (srv *Server) Complex128(complex128) any  { return nil }
+func (srv *Server) Duration(time.Duration) any { return nil }
+func (srv *Server) OptionalInt(*int) any       { return nil }
+func (srv *Server) OptionalURL(*url.URL) any   { return nil }
+func (srv *Server) URLParam(url.URL) any       { return nil }
 
 // ID implements encoding.TextUnmarshaler; the interface assertion also keeps
 // the encoding package in the load graph for classification.
@@ -69,6 +82,9 @@ type FormWithURL struct{ href url.URL }
 
 func (srv *Server) FormUnsupportedField(FormWithURL) any { return nil }
 
+// ParseUserinfo is registered as a parser in tests.
+func ParseUserinfo(s string) (*url.Userinfo, error) { return url.User(s), nil }
+
 type UploadForm struct {
 	Name  string
 	Tags  []string
@@ -89,8 +105,53 @@ type TaggedForm struct {
 
 func (srv *Server) TaggedForm(TaggedForm) any { return nil }
 
+type OrderMeta struct {
+	Note string `name:"note"`
+}
+
+type OrderAddress struct {
+	Street string `name:"street"`
+	Zip    int
+}
+
+type OrderItem struct {
+	Name string `name:"name"`
+	Qty  int    `name:"qty" template:"qty-template"`
+}
+
+type OrderForm struct {
+	OrderMeta
+	Address OrderAddress   `name:"address"`
+	Items   []OrderItem    `name:"items"`
+	Counts  map[string]int `name:"counts"`
+}
+
+func (srv *Server) Order(OrderForm) any { return nil }
+
+type NestedItemsForm struct {
+	Groups []struct{ Items []OrderItem }
+}
+
+func (srv *Server) NestedItems(NestedItemsForm) any { return nil }
+
 func (srv *Server) Function(func() error) any                            { return nil }
 func (srv *Server) AnyFunction(func(any) error) any                      { return nil }
 func (srv *Server) StringFunction(func(string) error) any                { return nil }
 func (srv *Server) IntFunction(func(int) error) any                      { return nil }
 func (srv *Server) Functions(func(string) error, func(string) error) any { return nil }
+
+type User struct{ Name string }
+
+func (srv *Server) ProvideUser(*http.Request) (User, error)        { return User{}, nil }
+func (srv *Server) ProvideTenant(*http.Request) string             { return "" }
+func (srv *Server) ProvideSession(context.Context) (string, error) { return "", nil }
+func (srv *Server) Account(context.Context, User) any              { return nil }
+func (srv *Server) Tenant(int) any                                 { return nil }
+
+type Guarded struct{}
+
+func (Guarded) Authorize(*http.Request, string) error { return nil }
+
+type BadGuard struct{}
+
+func (BadGuard) Authorize(*http.Request) bool { return true }
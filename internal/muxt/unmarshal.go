@@ -6,8 +6,10 @@ import (
 	"html/template"
 	"reflect"
 	"strings"
+	"time"
 
 	"github.com/typelate/dom"
+	"github.com/typelate/dom/spec"
 	"golang.org/x/net/html"
 	"golang.org/x/net/html/atom"
 	"golang.org/x/tools/go/packages"
@@ -31,14 +33,21 @@ const (
 	UnmarshalUint16
 	UnmarshalUint32
 	UnmarshalUint64
+	UnmarshalFloat32
+	UnmarshalFloat64
+	UnmarshalDuration
 	UnmarshalTextUnmarshaler
+	// UnmarshalParser parses with a function registered in Parsers.
+	UnmarshalParser
 )
 
 // UnmarshalMethodFor classifies how tp parses from its string form: a basic
 // type parsed with strconv (matched by name, so the byte and rune aliases are
-// not supported), or a named type whose pointer implements
-// encoding.TextUnmarshaler. TextUnmarshaler detection requires the encoding
-// package to be reachable in the load graph.
+// not supported), time.Duration parsed with time.ParseDuration, or a named
+// type whose pointer implements encoding.TextUnmarshaler. TextUnmarshaler
+// detection requires the encoding package to be reachable in the load graph.
+//
+// Pointers to these types are not classified here; see checkOptionalUnmarshalable.
 func UnmarshalMethodFor(pl []*packages.Package, tp types.Type) UnmarshalMethod {
 	switch t := tp.(type) {
 	case *types.Basic:
@@ -67,8 +76,15 @@ func UnmarshalMethodFor(pl []*packages.Package, tp types.Type) UnmarshalMethod {
 			return UnmarshalUint32
 		case "uint64":
 			return UnmarshalUint64
+		case "float32":
+			return UnmarshalFloat32
+		case "float64":
+			return UnmarshalFloat64
 		}
 	case *types.Named:
+		if IsNamedType(t, "time", "Duration") {
+			return UnmarshalDuration
+		}
 		if encPkg, ok := findPackageTypes(pl, "encoding"); ok {
 			textUnmarshaler := encPkg.Scope().Lookup("TextUnmarshaler").Type().Underlying().(*types.Interface)
 			if types.Implements(types.NewPointer(t), textUnmarshaler) {
@@ -79,11 +95,71 @@ func UnmarshalMethodFor(pl []*packages.Package, tp types.Type) UnmarshalMethod {
 	return UnmarshalUnsupported
 }
 
+// Parsers are the functions registered (with --use-parser) to parse a type
+// from a string. A parser takes precedence over UnmarshalMethodFor, so it
+// may replace UnmarshalText or strconv parsing for the type it returns.
+type Parsers []*types.Func
+
+// NewParsers resolves parser function references of the form
+// "import/path.Func" or "Func". A reference without a package path names a
+// function in defaultPackagePath. Each function must have the signature
+// func(string) (T, error) and its package must be in the load graph.
+func NewParsers(pl []*packages.Package, references []string, defaultPackagePath string) (Parsers, error) {
+	var result Parsers
+	for _, ref := range references {
+		pkgPath, name := defaultPackagePath, ref
+		if i := strings.LastIndex(ref, "."); i > strings.LastIndex(ref, "/") {
+			pkgPath, name = ref[:i], ref[i+1:]
+		}
+		pkg, ok := findPackageTypes(pl, pkgPath)
+		if !ok {
+			return nil, fmt.Errorf("parser %s: package %s is not imported by the routes package", ref, pkgPath)
+		}
+		fn, ok := pkg.Scope().Lookup(name).(*types.Func)
+		if !ok {
+			return nil, fmt.Errorf("parser %s: could not find function %s in %s", ref, name, pkgPath)
+		}
+		if pkgPath != defaultPackagePath && !fn.Exported() {
+			return nil, fmt.Errorf("parser %s: function is not exported", ref)
+		}
+		sig := fn.Type().(*types.Signature)
+		if !isParserSignature(sig) {
+			return nil, fmt.Errorf("parser %s: expected signature func(string) (T, error) got %s", ref, types.TypeString(sig, types.RelativeTo(pkg)))
+		}
+		if existing, ok := result.Lookup(sig.Results().At(0).Type()); ok {
+			return nil, fmt.Errorf("parser %s: %s already parses %s", ref, existing.FullName(), types.TypeString(sig.Results().At(0).Type(), nil))
+		}
+		result = append(result, fn)
+	}
+	return result, nil
+}
+
+func isParserSignature(sig *types.Signature) bool {
+	if sig.TypeParams().Len() > 0 || sig.Variadic() || sig.Params().Len() != 1 || sig.Results().Len() != 2 {
+		return false
+	}
+	param, ok := sig.Params().At(0).Type().(*types.Basic)
+	if !ok || param.Kind() != types.String {
+		return false
+	}
+	return types.Identical(sig.Results().At(1).Type(), types.Universe.Lookup("error").Type())
+}
+
+// Lookup returns the parser whose result type is identical to tp.
+func (ps Parsers) Lookup(tp types.Type) (*types.Func, bool) {
+	for _, fn := range ps {
+		if types.Identical(fn.Type().(*types.Signature).Results().At(0).Type(), tp) {
+			return fn, true
+		}
+	}
+	return nil, false
+}
+
 // checkUnmarshalable reports whether tp parses from a string, matching the
 // error wording of the pre-hydration generator: unsupported basic types name
 // the type directly, other types render in Go syntax.
-func checkUnmarshalable(pl []*packages.Package, tp types.Type, qual types.Qualifier) error {
-	if UnmarshalMethodFor(pl, tp) != UnmarshalUnsupported {
+func checkUnmarshalable(def *Definition, pl []*packages.Package, tp types.Type, qual types.Qualifier) error {
+	if def.UnmarshalMethod(pl, tp) != UnmarshalUnsupported {
 		return nil
 	}
 	if _, ok := tp.(*types.Basic); ok {
@@ -92,13 +168,50 @@ func checkUnmarshalable(pl []*packages.Package, tp types.Type, qual types.Qualif
 	return fmt.Errorf("unsupported type: %s", types.TypeString(tp, qual))
 }
 
+// checkOptionalUnmarshalable is checkUnmarshalable that also permits a
+// pointer to a type that parses from a string. An absent or empty value
+// leaves the pointer nil rather than failing to parse.
+func checkOptionalUnmarshalable(def *Definition, pl []*packages.Package, tp types.Type, qual types.Qualifier) error {
+	if ptr, ok := tp.(*types.Pointer); ok && def.UnmarshalMethod(pl, tp) == UnmarshalUnsupported && def.UnmarshalMethod(pl, ptr.Elem()) != UnmarshalUnsupported {
+		return nil
+	}
+	return checkUnmarshalable(def, pl, tp, qual)
+}
+
 // checkParsedArgument validates a path value or lastEventID parameter: it
 // either receives the raw string or parses from one.
-func checkParsedArgument(pl []*packages.Package, paramType types.Type, qual types.Qualifier) error {
+func checkParsedArgument(def *Definition, pl []*packages.Package, paramType types.Type, qual types.Qualifier) error {
 	if types.AssignableTo(types.Universe.Lookup("string").Type(), paramType) {
 		return nil
 	}
-	return checkUnmarshalable(pl, paramType, qual)
+	return checkOptionalUnmarshalable(def, pl, paramType, qual)
+}
+
+// IsNamedType reports whether tp is the named type pkgPath.name.
+func IsNamedType(tp types.Type, pkgPath, name string) bool {
+	named, ok := types.Unalias(tp).(*types.Named)
+	return ok && named.Obj().Pkg() != nil && named.Obj().Pkg().Path() == pkgPath && named.Obj().Name() == name
+}
+
+// IsTimeType reports whether tp is time.Time.
+func IsTimeType(tp types.Type) bool { return IsNamedType(tp, "time", "Time") }
+
+// TimeInputLayout returns the time.Parse layout of the value submitted by an
+// <input> of the given type. Inputs with seconds (a step below 60) are not
+// supported.
+func TimeInputLayout(typeAttr string) (string, bool) {
+	switch strings.ToLower(typeAttr) {
+	case "date":
+		return time.DateOnly, true
+	case "datetime-local":
+		return "2006-01-02T15:04", true
+	case "time":
+		return "15:04", true
+	case "month":
+		return "2006-01", true
+	default:
+		return "", false
+	}
 }
 
 const (
@@ -108,6 +221,9 @@ const (
 	// InputAttributeTemplateStructTag names the template whose input element
 	// attributes (minlength, maxlength, ...) generate validations for the field.
 	InputAttributeTemplateStructTag = "template"
+	// InputAttributeMaxSizeStructTag limits the size of each file uploaded to a
+	// *multipart.FileHeader field (e.g. `max-size:"2MB"`).
+	InputAttributeMaxSizeStructTag = "max-size"
 )
 
 // FieldBinding describes how one struct field of a form or multipart
@@ -115,21 +231,42 @@ const (
 type FieldBinding struct {
 	// Field is the bound struct field.
 	Field *types.Var
-	// InputName is the form input name: the name struct tag or the field name.
+	// InputName is the form input name: the name struct tag or the field name,
+	// after the names of enclosing struct fields ("address.street"). Inside a
+	// slice of structs it is relative to the element ("qty" for the input
+	// "items[0].qty"). Undefined for Embedded fields.
 	InputName string
 	// Template is the field's validation template (template struct tag), or
 	// nil when the tag is absent or names an undefined template.
 	Template *template.Template
 	// Elem is the type parsed from one string value: the field type, or the
-	// slice element type when Slice is set. Undefined for FileHeader fields.
+	// slice element or map value type when Slice or Map is set. For struct
+	// fields (see Fields) it is the struct type or slice element type.
+	// Undefined for FileHeader fields.
 	Elem types.Type
-	// Slice binds every request value for InputName, not just the first.
+	// Slice binds every request value for InputName, not just the first. With
+	// Fields, it binds one Elem per index submitted as InputName[<index>].
 	Slice bool
+	// Map binds a map[string]Elem field from the inputs named
+	// InputName[<key>].
+	Map bool
 	// FileHeader binds the field from request.MultipartForm.File instead of a
 	// text value: *multipart.FileHeader or (with Slice) []*multipart.FileHeader.
 	FileHeader bool
-	// Method is how Elem parses from a string. Undefined for FileHeader fields.
+	// Fields are the bindings of a struct (or with Slice, slice of structs)
+	// field's own fields.
+	Fields []FieldBinding
+	// Embedded marks an untagged embedded struct field, whose Fields bind
+	// under the enclosing struct's input names.
+	Embedded bool
+	// Method is how Elem (or for a pointer field, the type it points to)
+	// parses from a string. Undefined for FileHeader fields and fields with
+	// Fields.
 	Method UnmarshalMethod
+	// TimeLayout is the time.Parse layout of a time.Time (or *time.Time) field
+	// bound to a date, datetime-local, time, or month input. When empty the
+	// value parses with UnmarshalText (RFC 3339).
+	TimeLayout string
 	// Validations are the constraints parsed from the field's <input> element
 	// in Template (the element whose name attribute equals InputName).
 	Validations []InputValidation
@@ -138,9 +275,10 @@ type FieldBinding struct {
 // checkFormArgument permits a form or multipart parameter to either receive
 // the raw request value (url.Values / *multipart.Form) or be a struct whose
 // fields parse from the submitted form, returning one FieldBinding per struct
-// field (nil in raw mode). Struct fields must be a supported scalar or slice
-// of scalars; multipart structs may also bind *multipart.FileHeader and
-// []*multipart.FileHeader fields.
+// field (nil in raw mode). Struct fields must be a supported scalar, a slice
+// of or map[string] of scalars, or a struct, slice of structs, or embedded
+// struct whose fields follow the same rules; multipart structs may also bind
+// *multipart.FileHeader and []*multipart.FileHeader fields.
 func checkFormArgument(def *Definition, pl []*packages.Package, paramType types.Type, argName, packagePath, identifier string, pointer bool, qual types.Qualifier, allowFileFields bool) ([]FieldBinding, error) {
 	at, err := stdlibType(pl, packagePath, identifier, pointer)
 	if err != nil {
@@ -153,10 +291,6 @@ func checkFormArgument(def *Definition, pl []*packages.Package, paramType types.
 	if !ok {
 		return nil, fmt.Errorf("expected %s parameter type to be a struct", argName)
 	}
-	return formStructBindings(def, pl, st, argName, qual, allowFileFields)
-}
-
-func formStructBindings(def *Definition, pl []*packages.Package, st *types.Struct, argName string, qual types.Qualifier, allowFileFields bool) ([]FieldBinding, error) {
 	var fileHeaderPtr types.Type
 	if allowFileFields {
 		if mp, ok := findPackageTypes(pl, "mime/multipart"); ok {
@@ -165,60 +299,189 @@ func formStructBindings(def *Definition, pl []*packages.Package, st *types.Struc
 			}
 		}
 	}
+	return formStructBindings(def, pl, st, argName, qual, fileHeaderPtr, formBindingScope{})
+}
+
+// formBindingScope locates the fields of a nested form struct.
+type formBindingScope struct {
+	// namePrefix is prepended to input names ("address." inside Address).
+	namePrefix string
+	// fieldPrefix is prepended to field names in errors ("Address.").
+	fieldPrefix string
+	// element is the input name of the enclosing slice of structs, if any.
+	// Input names inside it are relative to "<element>[<index>].".
+	element string
+}
+
+// selector matches the template elements bound to inputName. Inside a slice
+// of structs the index is dynamic, so it matches any index.
+func (scope formBindingScope) selector(inputName string) string {
+	if scope.element == "" {
+		return fmt.Sprintf("[name=%q]", inputName)
+	}
+	return fmt.Sprintf("[name^=%q][name$=%q]", scope.element+"[", "]."+inputName)
+}
+
+func formStructBindings(def *Definition, pl []*packages.Package, st *types.Struct, argName string, qual types.Qualifier, fileHeaderPtr types.Type, scope formBindingScope) ([]FieldBinding, error) {
 	bindings := make([]FieldBinding, 0, st.NumFields())
 	for i := 0; i < st.NumFields(); i++ {
 		field, tags := st.Field(i), reflect.StructTag(st.Tag(i))
+		fieldPath := scope.fieldPrefix + field.Name()
 		fb := FieldBinding{
 			Field:     field,
-			InputName: field.Name(),
+			InputName: scope.namePrefix + field.Name(),
 		}
-		if name, found := tags.Lookup(InputAttributeNameStructTag); found {
-			fb.InputName = name
+		name, named := tags.Lookup(InputAttributeNameStructTag)
+		if named {
+			fb.InputName = scope.namePrefix + name
+		}
+		if name, found := tags.Lookup(InputAttributeTemplateStructTag); found {
+			fb.Template = def.template.Lookup(name)
 		}
 		ft := field.Type()
 		if fileHeaderPtr != nil && (types.Identical(ft, fileHeaderPtr) || types.Identical(ft, types.NewSlice(fileHeaderPtr))) {
+			if scope.element != "" {
+				return nil, fmt.Errorf("failed to generate parse statements for %s field %s: file fields are not supported in a slice of structs", argName, fieldPath)
+			}
 			fb.FileHeader = true
 			fb.Slice = types.Identical(ft, types.NewSlice(fileHeaderPtr))
+			validations, err := fileFieldValidations(fb, def.templateFuncConstants(pl), scope.selector(fb.InputName), tags.Get(InputAttributeMaxSizeStructTag))
+			if err != nil {
+				return nil, err
+			}
+			fb.Validations = validations
 			bindings = append(bindings, fb)
 			continue
 		}
-		if name, found := tags.Lookup(InputAttributeTemplateStructTag); found {
-			fb.Template = def.template.Lookup(name)
-		}
 		fb.Elem = ft
-		if slice, ok := ft.(*types.Slice); ok {
-			fb.Slice = true
-			fb.Elem = slice.Elem()
+		if def.UnmarshalMethod(pl, ft) == UnmarshalUnsupported {
+			switch t := ft.Underlying().(type) {
+			case *types.Struct:
+				nested := formBindingScope{namePrefix: fb.InputName + ".", fieldPrefix: fieldPath + ".", element: scope.element}
+				if field.Embedded() && !named {
+					fb.Embedded = true
+					fb.InputName = ""
+					nested.namePrefix = scope.namePrefix
+				}
+				fields, err := formStructBindings(def, pl, t, argName, qual, fileHeaderPtr, nested)
+				if err != nil {
+					return nil, err
+				}
+				fb.Fields = fields
+				bindings = append(bindings, fb)
+				continue
+			case *types.Slice:
+				fb.Slice = true
+				fb.Elem = t.Elem()
+				if st, ok := t.Elem().Underlying().(*types.Struct); ok && def.UnmarshalMethod(pl, t.Elem()) == UnmarshalUnsupported {
+					if scope.element != "" {
+						return nil, fmt.Errorf("failed to generate parse statements for %s field %s: a slice of structs is not supported in a slice of structs", argName, fieldPath)
+					}
+					fields, err := formStructBindings(def, pl, st, argName, qual, fileHeaderPtr, formBindingScope{fieldPrefix: fieldPath + "[].", element: fb.InputName})
+					if err != nil {
+						return nil, err
+					}
+					fb.Fields = fields
+					bindings = append(bindings, fb)
+					continue
+				}
+			case *types.Map:
+				if key, ok := t.Key().(*types.Basic); ok && key.Kind() == types.String && def.UnmarshalMethod(pl, t.Elem()) != UnmarshalUnsupported {
+					if scope.element != "" {
+						return nil, fmt.Errorf("failed to generate parse statements for %s field %s: a map is not supported in a slice of structs", argName, fieldPath)
+					}
+					fb.Map = true
+					fb.Elem = t.Elem()
+				}
+			}
+		}
+		selector := scope.selector(fb.InputName)
+		if fb.Map {
+			selector = fmt.Sprintf("[name^=%q][name$=%q]", fb.InputName+"[", "]")
 		}
-		validations, err := fieldTemplateValidations(fb)
+		check, parsed := checkUnmarshalable, fb.Elem
+		if !fb.Slice && !fb.Map {
+			check = checkOptionalUnmarshalable
+			if ptr, ok := fb.Elem.(*types.Pointer); ok && def.UnmarshalMethod(pl, ptr) == UnmarshalUnsupported {
+				parsed = ptr.Elem()
+			}
+		}
+		if err := check(def, pl, fb.Elem, qual); err != nil {
+			return nil, fmt.Errorf("failed to generate parse statements for %s field %s: %w", argName, fieldPath, err)
+		}
+		validations, typeAttr, err := fieldTemplateValidations(fb, def.templateFuncConstants(pl), selector, parsed)
 		if err != nil {
 			return nil, err
 		}
 		fb.Validations = validations
-		if err := checkUnmarshalable(pl, fb.Elem, qual); err != nil {
-			return nil, fmt.Errorf("failed to generate parse statements for %s field %s: %w", argName, field.Name(), err)
+		fb.Method = def.UnmarshalMethod(pl, parsed)
+		if layout, ok := TimeInputLayout(typeAttr); ok && IsTimeType(parsed) {
+			fb.TimeLayout = layout
 		}
-		fb.Method = UnmarshalMethodFor(pl, fb.Elem)
 		bindings = append(bindings, fb)
 	}
 	return bindings, nil
 }
 
-// fieldTemplateValidations parses the constraint attributes of the <input>
-// element bound to fb in its field template. Fields without a template tag or
-// whose template has no matching input have no validations.
-func fieldTemplateValidations(fb FieldBinding) ([]InputValidation, error) {
-	if fb.Template == nil {
-		return nil, nil
+// fileFieldValidations parses the upload constraints of a FileHeader field
+// from its <input type="file"> in the field template and its max-size tag.
+func fileFieldValidations(fb FieldBinding, constants map[string]string, selector, maxSize string) ([]InputValidation, error) {
+	var input spec.Element
+	if fb.Template != nil {
+		input = templateFragment(fb.Template, constants).QuerySelector(selector)
 	}
-	nodes, _ := html.ParseFragment(strings.NewReader(fb.Template.Tree.Root.String()), &html.Node{
+	return ParseFileInputValidations(fb.InputName, input, maxSize)
+}
+
+// templateFragment parses t's source as HTML, first resolving actions that
+// call constant template functions (see templateFuncConstants).
+func templateFragment(t *template.Template, constants map[string]string) spec.DocumentFragment {
+	nodes, _ := html.ParseFragment(strings.NewReader(resolveConstantActions(t, constants)), &html.Node{
 		Type:     html.ElementNode,
 		DataAtom: atom.Body,
 		Data:     atom.Body.String(),
 	})
-	input := dom.NewDocumentFragment(nodes).QuerySelector(fmt.Sprintf("[name=%q]", fb.InputName))
-	if input == nil {
-		return nil, nil
+	return dom.NewDocumentFragment(nodes)
+}
+
+// fieldTemplateValidations parses the constraint attributes of the element
+// bound to fb in its field template (the first element matching selector)
+// against tp, the type the field parses as, and returns the element's input
+// type.
+// When that element is a radio input, the values of every radio sharing the
+// name restrict the field. Fields without a template tag or whose template has
+// no matching element have no validations.
+func fieldTemplateValidations(fb FieldBinding, constants map[string]string, selector string, tp types.Type) ([]InputValidation, string, error) {
+	if fb.Template == nil {
+		return nil, "", nil
+	}
+	elements := templateFragment(fb.Template, constants).QuerySelectorAll(selector)
+	if elements.Length() == 0 {
+		return nil, "", nil
+	}
+	input := elements.Item(0)
+	var typeAttr string
+	if strings.EqualFold(input.TagName(), atom.Input.String()) {
+		typeAttr = input.GetAttribute("type")
+	}
+	validations, err := ParseInputValidations(fb.InputName, input, tp)
+	if err != nil {
+		return nil, "", err
+	}
+	if strings.EqualFold(typeAttr, "radio") {
+		var radios []spec.Element
+		for i := range elements.Length() {
+			if el := elements.Item(i); strings.EqualFold(el.GetAttribute("type"), "radio") {
+				radios = append(radios, el)
+			}
+		}
+		validation, ok, err := ParseRadioGroupValidation(fb.InputName, radios, tp)
+		if err != nil {
+			return nil, "", err
+		}
+		if ok {
+			validations = append(validations, validation)
+		}
 	}
-	return ParseInputValidations(fb.InputName, input, fb.Elem)
+	return validations, typeAttr, nil
 }
@@ -4,11 +4,15 @@ import (
 	"cmp"
 	"fmt"
 	"go/types"
+	"math"
+	"reflect"
 	"regexp"
 	"slices"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/dustin/go-humanize"
 	"github.com/typelate/dom/spec"
 	"golang.org/x/net/html/atom"
 
@@ -16,22 +20,26 @@ import (
 )
 
 // InputValidation is one request-value constraint parsed from a form field's
-// <input> element attributes. The generate package renders each constraint as
-// a guard statement in the handler.
+// <input>, <textarea>, or <select> element attributes. The generate package
+// renders each constraint as a guard statement in the handler.
 type InputValidation interface{ inputValidation() }
 
 // MinValidation is the min attribute of a numeric or temporal input; Min
-// holds the attribute value already checked against the field's type.
+// holds the attribute value already checked against the field's type. For a
+// time.Time field Layout is the time.Parse layout of Min.
 type MinValidation struct {
-	Name string
-	Min  string
+	Name   string
+	Min    string
+	Layout string
 }
 
 // MaxValidation is the max attribute of a numeric or temporal input; Max
-// holds the attribute value already checked against the field's type.
+// holds the attribute value already checked against the field's type. For a
+// time.Time field Layout is the time.Parse layout of Max.
 type MaxValidation struct {
-	Name string
-	Max  string
+	Name   string
+	Max    string
+	Layout string
 }
 
 // PatternValidation is the pattern attribute of a textual input.
@@ -52,38 +60,168 @@ type MaxLengthValidation struct {
 	MaxLength int
 }
 
-func (MinValidation) inputValidation()       {}
-func (MaxValidation) inputValidation()       {}
-func (PatternValidation) inputValidation()   {}
-func (MinLengthValidation) inputValidation() {}
-func (MaxLengthValidation) inputValidation() {}
+// RequiredValidation is the required attribute: at least one non-empty value
+// must be submitted. It is checked against the raw request value before
+// parsing, so an empty numeric field reports "required" rather than a parse
+// error.
+type RequiredValidation struct {
+	Name string
+}
+
+// StepValidation is the step attribute of a number or range input. Values
+// must be Base plus a multiple of Step, where Base is the min attribute (or
+// 0). For a floating point field Tolerance is how far a value may be from a
+// multiple, relative to its number of steps, to absorb rounding error; it is
+// empty for integers.
+type StepValidation struct {
+	Name      string
+	Step      string
+	Base      string
+	Tolerance string
+}
+
+// EmailValidation is the format check implied by <input type="email">.
+type EmailValidation struct {
+	Name string
+}
 
-// ParseInputValidations parses the constraint attributes (min, max, pattern,
-// minlength, maxlength) of a form field's <input> element. Attribute values
-// are validated here — min and max must parse as the field's type tp — so
-// resolution fails before any code generation begins.
+// URLValidation is the format check implied by <input type="url">: the value
+// must be an absolute URL.
+type URLValidation struct {
+	Name string
+}
+
+// OptionValidation restricts a <select> or radio group field to the values
+// of its static options. When String is set, Options are compared as string
+// literals and the empty string is also permitted (leave that to required);
+// otherwise they have been checked against the field's integer type.
+type OptionValidation struct {
+	Name    string
+	Options []string
+	String  bool
+}
+
+// FileAcceptValidation is the accept attribute of a file input. An upload is
+// accepted when its filename has one of Extensions or, for MIMETypes, both
+// the part's Content-Type header (unless missing or application/octet-stream)
+// and the type sniffed from its content match one of them; for types the
+// sniffer cannot identify (text/csv) a generic sniffed type defers to the
+// header. MIME types ending in "/*" match any subtype.
+type FileAcceptValidation struct {
+	Name       string
+	Accept     string
+	Extensions []string
+	MIMETypes  []string
+}
+
+// FileMaxSizeValidation limits the size in bytes of each uploaded file, from
+// the file input's data-max-size attribute or the field's max-size struct tag.
+type FileMaxSizeValidation struct {
+	Name    string
+	MaxSize int64
+}
+
+func (MinValidation) inputValidation()         {}
+func (MaxValidation) inputValidation()         {}
+func (PatternValidation) inputValidation()     {}
+func (MinLengthValidation) inputValidation()   {}
+func (MaxLengthValidation) inputValidation()   {}
+func (RequiredValidation) inputValidation()    {}
+func (StepValidation) inputValidation()        {}
+func (EmailValidation) inputValidation()       {}
+func (URLValidation) inputValidation()         {}
+func (OptionValidation) inputValidation()      {}
+func (FileAcceptValidation) inputValidation()  {}
+func (FileMaxSizeValidation) inputValidation() {}
+
+// EmailPattern is the "valid email address" production from the HTML
+// specification, which browsers use to validate <input type="email">.
+const EmailPattern = `^[a-zA-Z0-9.!#$%&'*+/=?^_` + "`" + `{|}~-]+@[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?(?:\.[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*$`
+
+// ParseInputValidations parses the constraint attributes (required, min, max,
+// step, pattern, minlength, maxlength), the email and url input types, and
+// the static <option> values of a form field's <input>, <textarea>, or
+// <select> element. Attribute values are validated here — min, max, and step
+// must parse as the field's type tp — so resolution fails before any code
+// generation begins.
 func ParseInputValidations(name string, input spec.Element, tp types.Type) ([]InputValidation, error) {
-	if tag := strings.ToLower(input.TagName()); tag != atom.Input.String() {
-		return nil, fmt.Errorf("expected element to have tag <input> got <%s>", tag)
+	var typeAttr string
+	switch tag := strings.ToLower(input.TagName()); tag {
+	case atom.Input.String():
+		typeAttr = cmp.Or(input.GetAttribute("type"), "text")
+	case atom.Textarea.String(), atom.Select.String():
+	default:
+		return nil, fmt.Errorf("expected element to have tag <input>, <textarea>, or <select> got <%s>", tag)
 	}
 	var result []InputValidation
-	typeAttr := cmp.Or(input.GetAttribute("type"), "text")
+	if input.HasAttribute("required") {
+		result = append(result, RequiredValidation{Name: name})
+	}
 	if slices.Contains([]string{
 		"date", "month", "week", "time", "datetime-local", "number", "range",
 	}, typeAttr) {
+		layout, isTime := TimeInputLayout(typeAttr)
+		if typeAttr == "week" && IsTimeType(tp) {
+			return nil, fmt.Errorf(`type="week" inputs can not bind to %s: time.Parse has no layout for ISO weeks (2006-W01); use a string field`, tp)
+		}
+		isTime = isTime && IsTimeType(tp)
+		parseBound := func(val string) (string, error) {
+			if isTime {
+				_, err := time.Parse(layout, val)
+				return val, err
+			}
+			return parseNumber(val, tp)
+		}
+		if !isTime {
+			layout = ""
+		}
 		if input.HasAttribute("min") {
-			val := input.GetAttribute("min")
-			if _, err := asteval.ParseWithType(val, tp); err != nil {
+			val, err := parseBound(input.GetAttribute("min"))
+			if err != nil {
 				return nil, err
 			}
-			result = append(result, MinValidation{Name: name, Min: val})
+			result = append(result, MinValidation{Name: name, Min: val, Layout: layout})
 		}
 		if input.HasAttribute("max") {
-			val := input.GetAttribute("max")
-			if _, err := asteval.ParseWithType(val, tp); err != nil {
+			val, err := parseBound(input.GetAttribute("max"))
+			if err != nil {
 				return nil, err
 			}
-			result = append(result, MaxValidation{Name: name, Max: val})
+			result = append(result, MaxValidation{Name: name, Max: val, Layout: layout})
+		}
+	}
+	if (typeAttr == "number" || typeAttr == "range") && input.HasAttribute("step") && !strings.EqualFold(input.GetAttribute("step"), "any") {
+		val := input.GetAttribute("step")
+		step, err := asteval.ParseWithType(val, tp)
+		if err != nil {
+			return nil, fmt.Errorf("step must parse as %s: %w", tp, err)
+		}
+		if step.IsZero() || (step.CanInt() && step.Int() < 0) || (step.CanFloat() && step.Float() < 0) {
+			return nil, fmt.Errorf("step must be greater than zero")
+		}
+		// every integer is a multiple of 1 from an integer base
+		if (step.CanInt() && step.Int() != 1) || (step.CanUint() && step.Uint() != 1) || step.CanFloat() {
+			base, err := parseNumber(cmp.Or(input.GetAttribute("min"), "0"), tp)
+			if err != nil {
+				return nil, fmt.Errorf("min must parse as %s: %w", tp, err)
+			}
+			validation := StepValidation{Name: name, Step: fmt.Sprint(step.Interface()), Base: base}
+			if step.CanFloat() {
+				// float32 values carry about 7 significant digits
+				validation.Tolerance = "1e-9"
+				if step.Kind() == reflect.Float32 {
+					validation.Tolerance = "1e-6"
+				}
+			}
+			result = append(result, validation)
+		}
+	}
+	if !input.HasAttribute("multiple") {
+		switch typeAttr {
+		case "email":
+			result = append(result, EmailValidation{Name: name})
+		case "url":
+			result = append(result, URLValidation{Name: name})
 		}
 	}
 	if slices.Contains([]string{
@@ -123,5 +261,135 @@ func ParseInputValidations(name string, input spec.Element, tp types.Type) ([]In
 		}
 		result = append(result, MaxLengthValidation{Name: name, MaxLength: maxLength})
 	}
+	if strings.EqualFold(input.TagName(), atom.Select.String()) {
+		options := input.QuerySelectorAll("option")
+		values := make([]string, 0, options.Length())
+		for i := range options.Length() {
+			option := options.Item(i)
+			if option.HasAttribute("value") {
+				values = append(values, option.GetAttribute("value"))
+			} else {
+				values = append(values, strings.TrimSpace(option.TextContent()))
+			}
+		}
+		validation, ok, err := newOptionValidation(name, values, strings.Contains(input.InnerHTML(), "{{"), tp)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			result = append(result, validation)
+		}
+	}
+	return result, nil
+}
+
+// ParseRadioGroupValidation returns the OptionValidation for the radio inputs
+// sharing a form field's name. It returns false when there are no radios or
+// a value is computed by a template action.
+func ParseRadioGroupValidation(name string, radios []spec.Element, tp types.Type) (InputValidation, bool, error) {
+	values := make([]string, 0, len(radios))
+	dynamic := false
+	for _, radio := range radios {
+		// a radio without a value attribute submits "on"
+		val := cmp.Or(radio.GetAttribute("value"), "on")
+		dynamic = dynamic || strings.Contains(val, "{{")
+		values = append(values, val)
+	}
+	if len(values) == 0 {
+		return nil, false, nil
+	}
+	return newOptionValidation(name, values, dynamic, tp)
+}
+
+// parseNumber parses val as tp the way the generated handler parses the
+// submitted value and returns it formatted as a decimal Go literal, so "08"
+// and "010" become 8 and 10 rather than invalid or octal literals.
+func parseNumber(val string, tp types.Type) (string, error) {
+	n, err := asteval.ParseWithType(val, tp)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprint(n.Interface()), nil
+}
+
+// newOptionValidation does not enforce option sets that depend on template
+// actions (dynamic) since the rendered values are unknown at generate time.
+// Options must parse as tp and integer options are stored in decimal; option
+// sets on types other than strings and integers are not enforced.
+func newOptionValidation(name string, values []string, dynamic bool, tp types.Type) (OptionValidation, bool, error) {
+	if dynamic || len(values) == 0 {
+		return OptionValidation{}, false, nil
+	}
+	basic, ok := tp.Underlying().(*types.Basic)
+	if !ok {
+		return OptionValidation{}, false, nil
+	}
+	validation := OptionValidation{Name: name}
+	switch {
+	case basic.Info()&types.IsString != 0:
+		validation.String = true
+	case basic.Info()&types.IsInteger != 0:
+		for i, val := range values {
+			n, err := parseNumber(val, tp)
+			if err != nil {
+				return OptionValidation{}, false, fmt.Errorf("option value %q must parse as %s: %w", val, tp, err)
+			}
+			values[i] = n
+		}
+	default:
+		return OptionValidation{}, false, nil
+	}
+	for _, val := range values {
+		if !slices.Contains(validation.Options, val) {
+			validation.Options = append(validation.Options, val)
+		}
+	}
+	return validation, true, nil
+}
+
+// ParseFileInputValidations parses the accept and data-max-size attributes of
+// a *multipart.FileHeader field's <input type="file"> element. The element may
+// be nil when only maxSize (the field's max-size struct tag) is set; maxSize
+// takes precedence over data-max-size. Sizes are human-readable byte counts
+// such as 512KB or 2MiB.
+func ParseFileInputValidations(name string, input spec.Element, maxSize string) ([]InputValidation, error) {
+	var result []InputValidation
+	if input != nil {
+		if tag, typeAttr := strings.ToLower(input.TagName()), strings.ToLower(input.GetAttribute("type")); tag != atom.Input.String() || typeAttr != "file" {
+			return nil, fmt.Errorf("expected file field %s to bind to <input type=\"file\"> got <%s type=%q>", name, tag, typeAttr)
+		}
+		if accept := input.GetAttribute("accept"); strings.TrimSpace(accept) != "" {
+			validation := FileAcceptValidation{Name: name, Accept: accept}
+			anything := false
+			for token := range strings.SplitSeq(accept, ",") {
+				token = strings.ToLower(strings.TrimSpace(token))
+				switch {
+				case token == "":
+				case token == "*/*":
+					anything = true
+				case strings.HasPrefix(token, "."):
+					validation.Extensions = append(validation.Extensions, token)
+				case strings.Count(token, "/") == 1 && !strings.HasPrefix(token, "/") && !strings.HasSuffix(token, "/"):
+					validation.MIMETypes = append(validation.MIMETypes, token)
+				default:
+					return nil, fmt.Errorf("accept value %q must be a file extension or MIME type", token)
+				}
+			}
+			if !anything {
+				result = append(result, validation)
+			}
+		}
+		maxSize = cmp.Or(maxSize, input.GetAttribute("data-max-size"))
+	}
+	if maxSize != "" {
+		n, err := humanize.ParseBytes(maxSize)
+		if err != nil {
+			return nil, fmt.Errorf("max size for %s must be a byte size: %w", name, err)
+		}
+		if n == 0 || n > math.MaxInt64 {
+			return nil, fmt.Errorf("max size for %s must be between 1 byte and %d bytes got %q", name, int64(math.MaxInt64), maxSize)
+		}
+		result = append(result, FileMaxSizeValidation{Name: name, MaxSize: int64(n)})
+	}
 	return result, nil
 }